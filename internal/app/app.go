@@ -1,12 +1,26 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/auth"
 	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/email"
+	"mts/booking_service/internal/history"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/repository/memory"
+	"mts/booking_service/internal/repository/postgres"
+	"mts/booking_service/internal/repository/sqlite"
+	"mts/booking_service/internal/repository/sqlstore"
 	"mts/booking_service/internal/repository/supabase"
 	"mts/booking_service/internal/services/standservice"
+	"mts/booking_service/internal/webhook"
 	"mts/booking_service/internal/ws/handlers"
 	"mts/booking_service/internal/ws/server"
+	"net/http"
 )
 
 // Run запускает приложение.
@@ -16,17 +30,195 @@ func Run(configPath string) {
 	if err != nil {
 		log.Fatalf("Ошибка при загрузке конфигурации: %v", err)
 	}
+	if len(cfg.Components) > 0 {
+		if err := models.SetComponents(cfg.Components); err != nil {
+			log.Fatalf("Ошибка в реестре компонентов: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// 2. Инициализация зависимостей
-	standsRepo := supabase.NewStandsRepository(&cfg.Supabase)
+	standsRepo, err := newStandsRepository(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Ошибка при инициализации хранилища стендов: %v", err)
+	}
+	if closer, ok := standsRepo.(io.Closer); ok {
+		defer closer.Close()
+	}
 	hub := handlers.NewHub()
-	standSvc := standservice.NewStandService(standsRepo, hub)
+	notifiers := standservice.Notifiers{hub}
+	var webhooks *webhook.Notifier
+	if len(cfg.Webhooks.Endpoints) > 0 {
+		webhooks, err = webhook.NewNotifier(cfg.Webhooks)
+		if err != nil {
+			log.Fatalf("Ошибка при инициализации webhook-уведомлений: %v", err)
+		}
+		notifiers = append(notifiers, webhooks)
+	}
+	standSvc := standservice.NewStandService(standsRepo, notifiers)
 	hub.SetService(standSvc)
+	auditStore, err := newAuditStore(cfg, standsRepo)
+	if err != nil {
+		log.Fatalf("Ошибка при инициализации журнала изменений: %v", err)
+	}
+	standSvc.SetAuditStore(auditStore)
+	historyStore, err := newHistoryStore(cfg, standsRepo)
+	if err != nil {
+		log.Fatalf("Ошибка при инициализации истории бронирований: %v", err)
+	}
+	standSvc.SetHistoryStore(historyStore)
+	hub.SetHeartbeat(handlers.Heartbeat{
+		PingInterval: cfg.WebSocket.PingInterval,
+		PongTimeout:  cfg.WebSocket.PongTimeout,
+		WriteTimeout: cfg.WebSocket.WriteTimeout,
+	})
+	hub.SetAllowedOrigins(cfg.WebSocket.AllowedOrigins)
 	go hub.Run()
 
-	standsHandler := handlers.NewStandsHandler(standsRepo)
+	if cfg.Storage.Driver == config.StorageDriverSupabase && cfg.Supabase.Realtime {
+		if source, ok := standsRepo.(standservice.ChangeSource); ok {
+			go standSvc.WatchChanges(ctx, source)
+		}
+	}
+
+	if webhooks != nil {
+		// Уведомления отправляются только об изменениях после запуска сервиса.
+		if stands, err := standSvc.GetStands(ctx); err == nil {
+			webhooks.Broadcast(stands)
+		} else {
+			log.Printf("Ошибка получения начального состояния стендов для webhook-уведомлений: %v", err)
+		}
+		go webhooks.Run(ctx)
+	}
+
+	expiryWorker := standservice.NewExpiryWorker(standSvc, cfg.Booking.ExpiryCheckInterval)
+	go expiryWorker.Run(ctx)
+
+	var wsHandler, standsHandler http.Handler = hub, handlers.NewStandsHandler(standSvc)
+	var auditHandler, reportsHandler http.Handler = handlers.NewAuditHandler(standSvc), handlers.NewReportsHandler(standSvc)
+	if cfg.Auth.Enabled {
+		authenticator, err := auth.NewAuthenticator(&cfg.Auth)
+		if err != nil {
+			log.Fatalf("Ошибка при инициализации аутентификации: %v", err)
+		}
+		standSvc.SetAuthRequired(true)
+		wsHandler = handlers.RequireAuth(authenticator, wsHandler)
+		standsHandler = handlers.RequireAuth(authenticator, standsHandler)
+		auditHandler = handlers.RequireAuth(authenticator, auditHandler)
+		reportsHandler = handlers.RequireAuth(authenticator, reportsHandler)
+	} else {
+		log.Println("Аутентификация выключена: /ws, /stands, /audit и /reports доступны без токена.")
+	}
 
 	// 3. Создание и запуск сервера
-	srv := server.New(":"+cfg.Server.Port, hub, standsHandler)
+	srv := server.New(":"+cfg.Server.Port, wsHandler, standsHandler)
+	srv.Handle("/audit", auditHandler)
+	srv.Handle("/reports", reportsHandler)
+	if cfg.Reminders.Enabled {
+		reminders, err := newReminderScheduler(cfg, standSvc, hub, webhooks)
+		if err != nil {
+			log.Fatalf("Ошибка при инициализации напоминаний: %v", err)
+		}
+		go reminders.Run(ctx)
+		srv.Handle("/reminders/", handlers.NewRemindersHandler(reminders))
+	}
+	if cfg.Hooks.Secret != "" {
+		// События развертывания подписываются общим секретом, токен пользователя не нужен.
+		srv.Handle("/hooks/", handlers.NewDeployHookHandler(standSvc, cfg.Hooks))
+	} else {
+		log.Println("Секрет hooks.secret не задан: /hooks/deploy выключен.")
+	}
 	srv.Run()
 }
+
+// newStandsRepository создает хранилище стендов по драйверу из конфигурации.
+func newStandsRepository(ctx context.Context, cfg *config.Config) (standservice.Repository, error) {
+	switch cfg.Storage.Driver {
+	case config.StorageDriverSupabase:
+		if err := supabase.CheckComponents(models.Components()); err != nil {
+			return nil, err
+		}
+		return supabase.NewStandsRepository(&cfg.Supabase), nil
+	case config.StorageDriverPostgres:
+		return postgres.NewStandsRepository(ctx, &cfg.Postgres)
+	case config.StorageDriverSQLite:
+		return sqlite.NewStandsRepository(ctx, &cfg.SQLite)
+	case config.StorageDriverMemory:
+		return memory.LoadStandsRepository(cfg.Memory.SeedFile)
+	default:
+		return nil, fmt.Errorf("неизвестный драйвер хранилища: %s", cfg.Storage.Driver)
+	}
+}
+
+// newAuditStore создает хранилище журнала изменений по драйверу из конфигурации.
+func newAuditStore(cfg *config.Config, standsRepo standservice.Repository) (standservice.AuditStore, error) {
+	switch cfg.Audit.Driver {
+	case config.AuditDriverMemory:
+		return audit.NewMemoryStore(), nil
+	case config.AuditDriverFile:
+		return audit.NewFileStore(cfg.Audit.FilePath)
+	case config.AuditDriverDatabase:
+		switch repo := standsRepo.(type) {
+		case *sqlstore.StandsRepository:
+			return repo.AuditStore(), nil
+		default:
+			return nil, fmt.Errorf("драйвер журнала %s не поддерживается хранилищем %s", cfg.Audit.Driver, cfg.Storage.Driver)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный драйвер журнала изменений: %s", cfg.Audit.Driver)
+	}
+}
+
+// newHistoryStore создает хранилище истории бронирований по драйверу из конфигурации.
+func newHistoryStore(cfg *config.Config, standsRepo standservice.Repository) (standservice.HistoryStore, error) {
+	switch cfg.History.Driver {
+	case config.HistoryDriverMemory:
+		return history.NewMemoryStore(), nil
+	case config.HistoryDriverDatabase:
+		switch repo := standsRepo.(type) {
+		case *sqlstore.StandsRepository:
+			return repo.HistoryStore(), nil
+		default:
+			return nil, fmt.Errorf("драйвер истории %s не поддерживается хранилищем %s", cfg.History.Driver, cfg.Storage.Driver)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный драйвер истории бронирований: %s", cfg.History.Driver)
+	}
+}
+
+// newReminderScheduler создает планировщик напоминаний с каналами доставки из конфигурации.
+func newReminderScheduler(cfg *config.Config, standSvc *standservice.StandService, hub *handlers.Hub, webhooks *webhook.Notifier) (*standservice.ReminderScheduler, error) {
+	var channels []standservice.ReminderChannel
+	for _, name := range cfg.Reminders.Channels {
+		if (name == config.ReminderChannelWebhook || name == config.ReminderChannelEmail) && cfg.Reminders.Secret == "" {
+			return nil, fmt.Errorf("канал напоминаний %s требует reminders.secret: без него ссылки продления перестают действовать после перезапуска", name)
+		}
+		switch name {
+		case config.ReminderChannelWebSocket:
+			channels = append(channels, hub)
+		case config.ReminderChannelWebhook:
+			if webhooks == nil {
+				return nil, fmt.Errorf("канал напоминаний webhook требует получателей в webhooks.endpoints")
+			}
+			channels = append(channels, webhooks)
+		case config.ReminderChannelEmail:
+			smtpChannel, err := email.NewSMTPChannel(cfg.Reminders.SMTP)
+			if err != nil {
+				return nil, err
+			}
+			channels = append(channels, smtpChannel)
+		default:
+			return nil, fmt.Errorf("неизвестный канал напоминаний: %s", name)
+		}
+	}
+
+	return standservice.NewReminderScheduler(standSvc, standservice.ReminderOptions{
+		Offsets:       cfg.Reminders.Offsets,
+		ExtendBy:      cfg.Reminders.ExtendBy,
+		Secret:        cfg.Reminders.Secret,
+		BaseURL:       cfg.Reminders.BaseURL,
+		CheckInterval: cfg.Reminders.CheckInterval,
+	}, channels...)
+}
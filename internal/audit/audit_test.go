@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"mts/booking_service/internal/models"
+)
+
+// store - общий интерфейс MemoryStore и FileStore.
+type store interface {
+	Append(ctx context.Context, entry models.AuditEntry) error
+	Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+func testStore(t *testing.T, s store) {
+	ctx := context.Background()
+	entries := []models.AuditEntry{
+		{Time: 100, Actor: "Иванов", StandID: 41, Transport: models.TransportWS},
+		{Time: 200, Actor: "Петров", StandID: 41, Transport: models.TransportREST},
+		{Time: 300, Actor: "Иванов", StandID: 42, Transport: models.TransportREST},
+	}
+	for _, entry := range entries {
+		entry.Changes = map[string]models.FieldChange{"users": {Before: json.RawMessage(`""`), After: json.RawMessage(`"` + entry.Actor + `"`)}}
+		if err := s.Append(ctx, entry); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+	}
+
+	standID, from, to := int64(41), int64(150), int64(300)
+	cases := map[string]struct {
+		filter models.AuditFilter
+		ids    []int64
+	}{
+		"без фильтра":      {models.AuditFilter{}, []int64{1, 2, 3}},
+		"по стенду":        {models.AuditFilter{StandID: &standID}, []int64{1, 2}},
+		"по пользователю":  {models.AuditFilter{Actor: "Иванов"}, []int64{1, 3}},
+		"по времени":       {models.AuditFilter{From: &from, To: &to}, []int64{2, 3}},
+		"по всем условиям": {models.AuditFilter{StandID: &standID, Actor: "Петров", From: &from}, []int64{2}},
+		"пустой результат": {models.AuditFilter{Actor: "Сидоров"}, []int64{}},
+	}
+	for name, tc := range cases {
+		got, err := s.Query(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: ожидалась ошибка nil, получено %v", name, err)
+		}
+		if len(got) != len(tc.ids) {
+			t.Errorf("%s: ожидались записи %v, получено %+v", name, tc.ids, got)
+			continue
+		}
+		for i, entry := range got {
+			if entry.ID != tc.ids[i] {
+				t.Errorf("%s: ожидались записи %v, получено %+v", name, tc.ids, got)
+				break
+			}
+		}
+	}
+
+	got, _ := s.Query(ctx, models.AuditFilter{Actor: "Петров"})
+	if string(got[0].Changes["users"].After) != `"Петров"` || got[0].Transport != models.TransportREST {
+		t.Errorf("Запись журнала сохранена неверно: %+v", got[0])
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "audit.jsonl")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	testStore(t, s)
+
+	// После перезапуска записи сохраняются, а нумерация продолжается.
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if err := reopened.Append(context.Background(), models.AuditEntry{Time: 400, StandID: 41}); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	entries, _ := reopened.Query(context.Background(), models.AuditFilter{})
+	if len(entries) != 4 || entries[3].ID != 4 {
+		t.Errorf("Ожидалось 4 записи после перезапуска, получено %+v", entries)
+	}
+}
+
+func TestTransportFromContext(t *testing.T) {
+	if transport := TransportFromContext(context.Background()); transport != models.TransportSystem {
+		t.Errorf("Ожидался транспорт system, получено %s", transport)
+	}
+	if transport := TransportFromContext(WithTransport(context.Background(), models.TransportWS)); transport != models.TransportWS {
+		t.Errorf("Ожидался транспорт ws, получено %s", transport)
+	}
+}
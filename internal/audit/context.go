@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"context"
+
+	"mts/booking_service/internal/models"
+)
+
+type transportKey struct{}
+
+// WithTransport возвращает контекст с транспортом, через который клиент прислал запрос.
+func WithTransport(ctx context.Context, transport string) context.Context {
+	return context.WithValue(ctx, transportKey{}, transport)
+}
+
+// TransportFromContext возвращает транспорт запроса или models.TransportSystem,
+// если изменение сделано самим сервисом.
+func TransportFromContext(ctx context.Context) string {
+	if transport, ok := ctx.Value(transportKey{}).(string); ok {
+		return transport
+	}
+	return models.TransportSystem
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"mts/booking_service/internal/models"
+)
+
+// FileStore хранит журнал изменений в файле, по одной JSON-записи на строку.
+// Записи только дописываются в конец файла.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	lastID int64
+}
+
+// NewFileStore открывает журнал в файле path, создавая файл и каталог при необходимости.
+func NewFileStore(path string) (*FileStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("ошибка создания каталога журнала изменений: %w", err)
+		}
+	}
+
+	s := &FileStore{path: path}
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		s.lastID = entries[len(entries)-1].ID
+	}
+	return s, nil
+}
+
+// Append дописывает запись в конец файла и присваивает ей id.
+func (s *FileStore) Append(ctx context.Context, entry models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = s.lastID + 1
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации записи журнала изменений: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия журнала изменений: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("ошибка записи в журнал изменений: %w", err)
+	}
+
+	s.lastID = entry.ID
+	return nil
+}
+
+// Query читает журнал и возвращает записи, подходящие под фильтр, в порядке добавления.
+func (s *FileStore) Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	s.mu.Lock()
+	entries, err := s.read()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []models.AuditEntry{}
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
+
+// read читает все записи журнала. Вызывающий должен удерживать s.mu.
+func (s *FileStore) read() ([]models.AuditEntry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия журнала изменений: %w", err)
+	}
+	defer f.Close()
+
+	var entries []models.AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("ошибка разбора журнала изменений %s: %w", s.path, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала изменений: %w", err)
+	}
+	return entries, nil
+}
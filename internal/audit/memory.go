@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"mts/booking_service/internal/models"
+)
+
+// MemoryStore хранит журнал изменений в памяти процесса. Журнал теряется при перезапуске.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+}
+
+// NewMemoryStore создает пустой журнал в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append добавляет запись в журнал и присваивает ей id.
+func (s *MemoryStore) Append(ctx context.Context, entry models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = int64(len(s.entries)) + 1
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Query возвращает записи, подходящие под фильтр, в порядке добавления.
+func (s *MemoryStore) Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := []models.AuditEntry{}
+	for _, entry := range s.entries {
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"mts/booking_service/internal/config"
+)
+
+// Алгоритмы подписи JWT.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// defaultUserClaim - claim с именем пользователя, если в конфигурации не указан другой.
+const defaultUserClaim = "sub"
+
+// ErrUnauthorized возвращается, если запрос не содержит действительного токена.
+var ErrUnauthorized = errors.New("требуется аутентификация")
+
+// Identity - аутентифицированный пользователь.
+type Identity struct {
+	// User - имя пользователя, под которым он бронирует стенды.
+	User string
+	// Role - роль пользователя из конфигурации или claims токена.
+	Role Role
+	// Claims - все claims токена.
+	Claims jwt.MapClaims
+}
+
+// Authenticator проверяет bearer JWT из запросов.
+type Authenticator struct {
+	algorithm  string
+	key        any
+	userClaim  string
+	rolesClaim string
+	userRoles  map[string]Role
+	parser     *jwt.Parser
+}
+
+// NewAuthenticator создает Authenticator по конфигурации: для HS256 используется
+// общий секрет, для RS256 - открытый ключ из PEM-файла.
+func NewAuthenticator(cfg *config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		algorithm:  strings.ToUpper(cfg.Algorithm),
+		userClaim:  cfg.UserClaim,
+		rolesClaim: cfg.RolesClaim,
+		userRoles:  make(map[string]Role),
+	}
+	if a.userClaim == "" {
+		a.userClaim = defaultUserClaim
+	}
+	if a.rolesClaim == "" {
+		a.rolesClaim = defaultRolesClaim
+	}
+	for name, users := range cfg.Roles {
+		role, ok := ParseRole(name)
+		if !ok {
+			return nil, fmt.Errorf("неизвестная роль в auth.roles: %s", name)
+		}
+		for _, user := range users {
+			if current, ok := a.userRoles[user]; !ok || role.AtLeast(current) {
+				a.userRoles[user] = role
+			}
+		}
+	}
+
+	switch a.algorithm {
+	case AlgorithmHS256:
+		if cfg.Secret == "" {
+			return nil, errors.New("для HS256 не задан auth.secret")
+		}
+		a.key = []byte(cfg.Secret)
+	case AlgorithmRS256:
+		key, err := loadRSAPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		a.key = key
+	default:
+		return nil, fmt.Errorf("неподдерживаемый алгоритм JWT: %s", cfg.Algorithm)
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{a.algorithm}), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+	return a, nil
+}
+
+func loadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	if path == "" {
+		return nil, errors.New("для RS256 не задан auth.public_key_file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения открытого ключа JWT: %w", err)
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора открытого ключа JWT: %w", err)
+	}
+	return key, nil
+}
+
+// Authenticate проверяет токен из заголовка Authorization: Bearer или, для WebSocket,
+// из параметра запроса token, и возвращает пользователя.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return Identity{}, fmt.Errorf("%w: токен не передан", ErrUnauthorized)
+	}
+	return a.Verify(token)
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает пользователя.
+func (a *Authenticator) Verify(token string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return a.key, nil
+	}); err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+
+	user, _ := claims[a.userClaim].(string)
+	if user == "" {
+		return Identity{}, fmt.Errorf("%w: в токене нет claim %s", ErrUnauthorized, a.userClaim)
+	}
+	return Identity{User: user, Role: a.role(user, claims), Claims: claims}, nil
+}
+
+// role определяет роль пользователя: роль из конфигурации важнее claims токена.
+func (a *Authenticator) role(user string, claims jwt.MapClaims) Role {
+	if role, ok := a.userRoles[user]; ok {
+		return role
+	}
+	return highestRole(claimStrings(claims[a.rolesClaim]))
+}
+
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.URL.Query().Get("token")
+}
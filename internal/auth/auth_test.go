@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"mts/booking_service/internal/config"
+)
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Не удалось подписать токен: %v", err)
+	}
+	return token
+}
+
+func TestAuthenticator_HS256(t *testing.T) {
+	authenticator, err := NewAuthenticator(&config.AuthConfig{Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	valid := jwt.MapClaims{"sub": "Иванов", "exp": time.Now().Add(time.Hour).Unix()}
+
+	t.Run("токен из заголовка", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/stands", nil)
+		req.Header.Set("Authorization", "Bearer "+signHS256(t, "secret", valid))
+
+		identity, err := authenticator.Authenticate(req)
+		if err != nil || identity.User != "Иванов" {
+			t.Errorf("Ожидался пользователь Иванов, получено %+v, %v", identity, err)
+		}
+	})
+
+	t.Run("токен из параметра запроса", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/ws?token="+signHS256(t, "secret", valid), nil)
+
+		if identity, err := authenticator.Authenticate(req); err != nil || identity.User != "Иванов" {
+			t.Errorf("Ожидался пользователь Иванов, получено %+v, %v", identity, err)
+		}
+	})
+
+	t.Run("недействительные токены", func(t *testing.T) {
+		tokens := map[string]string{
+			"без токена":          "",
+			"чужой секрет":        signHS256(t, "other", valid),
+			"истекший токен":      signHS256(t, "secret", jwt.MapClaims{"sub": "Иванов", "exp": time.Now().Add(-time.Hour).Unix()}),
+			"без срока действия":  signHS256(t, "secret", jwt.MapClaims{"sub": "Иванов"}),
+			"без пользователя":    signHS256(t, "secret", jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}),
+			"некорректная строка": "not-a-jwt",
+		}
+		for name, token := range tokens {
+			req := httptest.NewRequest("GET", "/stands", nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			if _, err := authenticator.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("%s: ожидалась ошибка ErrUnauthorized, получено %v", name, err)
+			}
+		}
+	})
+}
+
+func TestAuthenticator_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Не удалось создать ключ: %v", err)
+	}
+	publicKey, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	keyFile := filepath.Join(t.TempDir(), "jwt.pub")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0o600)
+
+	authenticator, err := NewAuthenticator(&config.AuthConfig{Algorithm: "RS256", PublicKeyFile: keyFile, UserClaim: "preferred_username"})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	claims := jwt.MapClaims{"sub": "42", "preferred_username": "Петров", "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	if identity, err := authenticator.Verify(token); err != nil || identity.User != "Петров" {
+		t.Errorf("Ожидался пользователь Петров, получено %+v, %v", identity, err)
+	}
+
+	// Токен, подписанный HS256 открытым ключом, не должен приниматься.
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(publicKey)
+	if _, err := authenticator.Verify(forged); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Ожидалась ошибка ErrUnauthorized для HS256-токена, получено %v", err)
+	}
+}
+
+func TestAuthenticator_Roles(t *testing.T) {
+	authenticator, err := NewAuthenticator(&config.AuthConfig{
+		Algorithm: "HS256",
+		Secret:    "secret",
+		Roles:     map[string][]string{"admin": {"Админов"}},
+	})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	cases := map[string]struct {
+		claims jwt.MapClaims
+		role   Role
+	}{
+		"роль по умолчанию":         {jwt.MapClaims{"sub": "Иванов", "exp": exp}, RoleDeveloper},
+		"роль из claim строкой":     {jwt.MapClaims{"sub": "Петров", "exp": exp, "roles": "lead"}, RoleLead},
+		"старшая роль из списка":    {jwt.MapClaims{"sub": "Петров", "exp": exp, "roles": []string{"developer", "lead"}}, RoleLead},
+		"роль из конфигурации":      {jwt.MapClaims{"sub": "Админов", "exp": exp, "roles": "developer"}, RoleAdmin},
+		"неизвестная роль из claim": {jwt.MapClaims{"sub": "Иванов", "exp": exp, "roles": "superuser"}, RoleDeveloper},
+	}
+	for name, tc := range cases {
+		identity, err := authenticator.Verify(signHS256(t, "secret", tc.claims))
+		if err != nil || identity.Role != tc.role {
+			t.Errorf("%s: ожидалась роль %s, получено %s, %v", name, tc.role, identity.Role, err)
+		}
+	}
+
+	if _, err := NewAuthenticator(&config.AuthConfig{Algorithm: "HS256", Secret: "secret", Roles: map[string][]string{"owner": {"Иванов"}}}); err == nil {
+		t.Error("Ожидалась ошибка для неизвестной роли в конфигурации")
+	}
+}
@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+type identityKey struct{}
+
+// WithIdentity возвращает контекст с аутентифицированным пользователем.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext возвращает пользователя, аутентифицированного для запроса.
+// Второе значение false, если аутентификация выключена или запрос не прошел через нее.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
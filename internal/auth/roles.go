@@ -0,0 +1,65 @@
+package auth
+
+import "strings"
+
+// Role - роль пользователя. Каждая следующая роль включает права предыдущих.
+type Role string
+
+const (
+	// RoleDeveloper бронирует стенды и освобождает свои бронирования.
+	RoleDeveloper Role = "developer"
+	// RoleLead дополнительно продлевает и освобождает чужие бронирования.
+	RoleLead Role = "lead"
+	// RoleAdmin дополнительно создает и удаляет стенды и меняет поля развертываний.
+	RoleAdmin Role = "admin"
+)
+
+// defaultRolesClaim - claim со списком ролей, если в конфигурации не указан другой.
+const defaultRolesClaim = "roles"
+
+var roleRanks = map[Role]int{
+	RoleDeveloper: 1,
+	RoleLead:      2,
+	RoleAdmin:     3,
+}
+
+// ParseRole возвращает роль по имени без учета регистра. Второе значение false для неизвестной роли.
+func ParseRole(name string) (Role, bool) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	_, ok := roleRanks[role]
+	return role, ok
+}
+
+// AtLeast сообщает, включает ли роль r права роли other.
+func (r Role) AtLeast(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+// highestRole возвращает старшую из известных ролей в names или RoleDeveloper, если таких нет.
+func highestRole(names []string) Role {
+	highest := RoleDeveloper
+	for _, name := range names {
+		if role, ok := ParseRole(name); ok && role.AtLeast(highest) {
+			highest = role
+		}
+	}
+	return highest
+}
+
+// claimStrings читает claim, заданный строкой или массивом строк.
+func claimStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(strings.ReplaceAll(value, ",", " "))
+	case []any:
+		names := make([]string, 0, len(value))
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
@@ -3,12 +3,42 @@ package config
 import (
 	"github.com/spf13/viper"
 	"log"
+	"time"
 )
 
 // Config структура для хранения конфигурации.
 type Config struct {
-	Server   ServerConfig
-	Supabase SupabaseConfig
+	Server    ServerConfig
+	WebSocket WebSocketConfig
+	Auth      AuthConfig
+	Storage   StorageConfig
+	Supabase  SupabaseConfig
+	Postgres  PostgresConfig
+	SQLite    SQLiteConfig
+	Memory    MemoryConfig
+	Booking   BookingConfig
+	Audit     AuditConfig
+	History   HistoryConfig
+	Webhooks  WebhooksConfig
+	Reminders RemindersConfig
+	Hooks     HooksConfig
+	// Components - реестр компонентов стенда. Пустой реестр заменяется компонентами
+	// с колонками в таблице stands Supabase. С драйвером supabase другие компоненты недопустимы.
+	Components []string `mapstructure:"components"`
+}
+
+// Драйверы хранилища стендов.
+const (
+	StorageDriverSupabase = "supabase"
+	StorageDriverMemory   = "memory"
+	StorageDriverPostgres = "postgres"
+	StorageDriverSQLite   = "sqlite"
+)
+
+// StorageConfig для выбора хранилища стендов.
+type StorageConfig struct {
+	// Driver - имя драйвера хранилища: supabase, postgres, sqlite или memory.
+	Driver string `mapstructure:"driver"`
 }
 
 // ServerConfig для настроек сервера.
@@ -16,10 +46,188 @@ type ServerConfig struct {
 	Port string `mapstructure:"port"`
 }
 
+// WebSocketConfig для настроек проверки живости WebSocket-соединений.
+type WebSocketConfig struct {
+	// AllowedOrigins - разрешенные значения заголовка Origin помимо страниц самого сервиса.
+	// Пустой список разрешает только страницы сервиса, "*" - любой Origin.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// PingInterval - период отправки ping клиентам.
+	PingInterval time.Duration `mapstructure:"ping_interval"`
+	// PongTimeout - сколько ждать pong сверх PingInterval, прежде чем считать клиента отключенным.
+	PongTimeout time.Duration `mapstructure:"pong_timeout"`
+	// WriteTimeout - максимальное время записи одного сообщения клиенту.
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+}
+
+// AuthConfig для настроек аутентификации клиентов по JWT.
+type AuthConfig struct {
+	// Enabled включает проверку токенов для /ws и /stands.
+	Enabled bool `mapstructure:"enabled"`
+	// Algorithm - алгоритм подписи токенов: HS256 или RS256.
+	Algorithm string `mapstructure:"algorithm"`
+	// Secret - общий секрет для HS256.
+	Secret string `mapstructure:"secret"`
+	// PublicKeyFile - PEM-файл с открытым ключом для RS256.
+	PublicKeyFile string `mapstructure:"public_key_file"`
+	// Issuer и Audience, если заданы, должны совпадать с claims iss и aud токена.
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+	// UserClaim - claim с именем пользователя, по умолчанию sub.
+	UserClaim string `mapstructure:"user_claim"`
+	// RolesClaim - claim с ролью или списком ролей пользователя, по умолчанию roles.
+	RolesClaim string `mapstructure:"roles_claim"`
+	// Roles назначает роли пользователям: ключ - роль (developer, lead, admin),
+	// значение - имена пользователей. Назначение из конфигурации важнее claims токена.
+	Roles map[string][]string `mapstructure:"roles"`
+}
+
 // SupabaseConfig для настроек Supabase.
 type SupabaseConfig struct {
 	URL    string `mapstructure:"url"`
 	APIKey string `mapstructure:"api_key"`
+	// Realtime включает подписку на изменения таблицы stands через Supabase Realtime.
+	Realtime bool `mapstructure:"realtime"`
+}
+
+// PostgresConfig для настроек PostgreSQL.
+type PostgresConfig struct {
+	DSN string `mapstructure:"dsn"`
+}
+
+// SQLiteConfig для настроек встроенной базы SQLite.
+type SQLiteConfig struct {
+	// Path - путь к файлу базы. Каталог создается автоматически.
+	Path string `mapstructure:"path"`
+	// SeedFile - JSON-файл в формате таблицы stands для заполнения пустой базы.
+	SeedFile string `mapstructure:"seed_file"`
+}
+
+// MemoryConfig для настроек хранилища стендов в памяти.
+type MemoryConfig struct {
+	// SeedFile - JSON-файл в формате таблицы stands для начального заполнения.
+	SeedFile string `mapstructure:"seed_file"`
+}
+
+// BookingConfig для настроек бронирования стендов.
+type BookingConfig struct {
+	// ExpiryCheckInterval - период проверки истекших бронирований.
+	ExpiryCheckInterval time.Duration `mapstructure:"expiry_check_interval"`
+}
+
+// Драйверы журнала изменений стендов.
+const (
+	AuditDriverMemory   = "memory"
+	AuditDriverFile     = "file"
+	AuditDriverDatabase = "database"
+)
+
+// AuditConfig для настроек журнала изменений стендов.
+type AuditConfig struct {
+	// Driver - хранилище журнала: memory, file или database. database использует
+	// базу стендов и доступен только для хранилищ postgres и sqlite.
+	Driver string `mapstructure:"driver"`
+	// FilePath - файл журнала для драйвера file. Каталог создается автоматически.
+	FilePath string `mapstructure:"file_path"`
+}
+
+// Драйверы истории бронирований.
+const (
+	HistoryDriverMemory   = "memory"
+	HistoryDriverDatabase = "database"
+)
+
+// HistoryConfig для настроек истории бронирований, по которой строятся отчеты.
+type HistoryConfig struct {
+	// Driver - хранилище истории: memory или database. database использует базу
+	// стендов и доступен только для хранилищ postgres и sqlite.
+	Driver string `mapstructure:"driver"`
+}
+
+// WebhooksConfig для настроек исходящих уведомлений о событиях стендов.
+type WebhooksConfig struct {
+	// ExpiringBefore - за сколько до окончания бронирования отправлять событие expiring.
+	ExpiringBefore time.Duration `mapstructure:"expiring_before"`
+	// CheckInterval - период проверки истекающих бронирований.
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	// Timeout - максимальное время одного запроса к получателю.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Retries - число повторных попыток доставки после неудачной.
+	Retries int `mapstructure:"retries"`
+	// Backoff - пауза перед первой повторной попыткой, каждая следующая пауза вдвое длиннее.
+	Backoff time.Duration `mapstructure:"backoff"`
+	// DeadLetterFile - файл, в который записываются недоставленные уведомления.
+	DeadLetterFile string `mapstructure:"dead_letter_file"`
+	// Endpoints - получатели уведомлений. Пустой список выключает уведомления.
+	Endpoints []WebhookEndpointConfig `mapstructure:"endpoints"`
+}
+
+// WebhookEndpointConfig описывает одного получателя уведомлений.
+type WebhookEndpointConfig struct {
+	Name string `mapstructure:"name"`
+	URL  string `mapstructure:"url"`
+	// Format - формат тела запроса: slack, mattermost или generic.
+	Format string `mapstructure:"format"`
+	// Events - события, о которых уведомлять: created, booked, released, expiring, redeployed, reminder.
+	// Пустой список означает все события.
+	Events []string `mapstructure:"events"`
+	// Template - шаблон text/template тела запроса вместо стандартного для формата.
+	Template string `mapstructure:"template"`
+	// Channel и Username переопределяют канал и имя отправителя в Slack и Mattermost.
+	Channel  string `mapstructure:"channel"`
+	Username string `mapstructure:"username"`
+	// Headers - дополнительные заголовки запроса, например для авторизации.
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+// Каналы доставки напоминаний об окончании бронирования.
+const (
+	ReminderChannelWebSocket = "ws"
+	ReminderChannelWebhook   = "webhook"
+	ReminderChannelEmail     = "email"
+)
+
+// RemindersConfig для настроек напоминаний владельцам об окончании бронирования.
+type RemindersConfig struct {
+	// Enabled включает напоминания.
+	Enabled bool `mapstructure:"enabled"`
+	// Offsets - за сколько до окончания бронирования напоминать, например 24h и 1h.
+	Offsets []time.Duration `mapstructure:"offsets"`
+	// CheckInterval - период проверки бронирований.
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	// Channels - каналы доставки: ws, webhook (получатели из webhooks с событием reminder), email.
+	Channels []string `mapstructure:"channels"`
+	// ExtendBy - на сколько продлевает бронирование ссылка из напоминания.
+	ExtendBy time.Duration `mapstructure:"extend_by"`
+	// Secret - ключ подписи ссылок продления. Обязателен для каналов webhook и email: ссылки
+	// из писем и чатов должны действовать и после перезапуска. Для ws без него ссылки
+	// действуют до перезапуска.
+	Secret string `mapstructure:"secret"`
+	// BaseURL - внешний адрес сервиса для ссылок продления, например https://booking.example.com.
+	BaseURL string     `mapstructure:"base_url"`
+	SMTP    SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig для отправки напоминаний по электронной почте.
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// Domain - домен почты пользователей: письмо пользователю ivanov уходит на ivanov@Domain.
+	Domain string `mapstructure:"domain"`
+	// Addresses задает адреса пользователей, которые не выводятся из Domain.
+	Addresses map[string]string `mapstructure:"addresses"`
+}
+
+// HooksConfig для настроек приема событий развертывания из CI/CD на /hooks/deploy.
+type HooksConfig struct {
+	// Secret - общий секрет: значение заголовка X-Gitlab-Token или X-Deploy-Token либо
+	// ключ HMAC-SHA256 подписи тела в заголовке X-Signature-256. Пустой секрет выключает прием.
+	Secret string `mapstructure:"secret"`
+	// Components сопоставляет проекты GitLab и задачи Jenkins компонентам стенда,
+	// например shop/frontend: front. Проект с именем компонента сопоставляется без настройки.
+	Components map[string]string `mapstructure:"components"`
 }
 
 // NewConfig загружает конфигурацию из файла.
@@ -31,8 +239,49 @@ func NewConfig(configPath string) (*Config, error) {
 		log.Printf("Unable to read config file, %v", err)
 		// Попытка загрузить из переменных окружения, если файл не найден
 		viper.BindEnv("server.port", "SERVER_PORT")
+		viper.BindEnv("websocket.ping_interval", "WEBSOCKET_PING_INTERVAL")
+		viper.BindEnv("websocket.pong_timeout", "WEBSOCKET_PONG_TIMEOUT")
+		viper.BindEnv("websocket.write_timeout", "WEBSOCKET_WRITE_TIMEOUT")
+		// Список Origin задается через запятую.
+		viper.BindEnv("websocket.allowed_origins", "WEBSOCKET_ALLOWED_ORIGINS")
+		viper.BindEnv("auth.enabled", "AUTH_ENABLED")
+		viper.BindEnv("auth.algorithm", "AUTH_ALGORITHM")
+		viper.BindEnv("auth.secret", "AUTH_SECRET")
+		viper.BindEnv("auth.public_key_file", "AUTH_PUBLIC_KEY_FILE")
+		viper.BindEnv("auth.issuer", "AUTH_ISSUER")
+		viper.BindEnv("auth.audience", "AUTH_AUDIENCE")
+		viper.BindEnv("auth.user_claim", "AUTH_USER_CLAIM")
+		viper.BindEnv("auth.roles_claim", "AUTH_ROLES_CLAIM")
+		viper.BindEnv("storage.driver", "STORAGE_DRIVER")
 		viper.BindEnv("supabase.url", "SUPABASE_URL")
 		viper.BindEnv("supabase.api_key", "SUPABASE_API_KEY")
+		viper.BindEnv("supabase.realtime", "SUPABASE_REALTIME")
+		viper.BindEnv("postgres.dsn", "POSTGRES_DSN")
+		viper.BindEnv("sqlite.path", "SQLITE_PATH")
+		viper.BindEnv("sqlite.seed_file", "SQLITE_SEED_FILE")
+		viper.BindEnv("memory.seed_file", "MEMORY_SEED_FILE")
+		viper.BindEnv("booking.expiry_check_interval", "BOOKING_EXPIRY_CHECK_INTERVAL")
+		viper.BindEnv("audit.driver", "AUDIT_DRIVER")
+		viper.BindEnv("audit.file_path", "AUDIT_FILE_PATH")
+		viper.BindEnv("history.driver", "HISTORY_DRIVER")
+		viper.BindEnv("webhooks.expiring_before", "WEBHOOKS_EXPIRING_BEFORE")
+		viper.BindEnv("webhooks.check_interval", "WEBHOOKS_CHECK_INTERVAL")
+		viper.BindEnv("webhooks.timeout", "WEBHOOKS_TIMEOUT")
+		viper.BindEnv("webhooks.retries", "WEBHOOKS_RETRIES")
+		viper.BindEnv("webhooks.backoff", "WEBHOOKS_BACKOFF")
+		viper.BindEnv("webhooks.dead_letter_file", "WEBHOOKS_DEAD_LETTER_FILE")
+		viper.BindEnv("reminders.enabled", "REMINDERS_ENABLED")
+		viper.BindEnv("reminders.check_interval", "REMINDERS_CHECK_INTERVAL")
+		viper.BindEnv("reminders.extend_by", "REMINDERS_EXTEND_BY")
+		viper.BindEnv("reminders.secret", "REMINDERS_SECRET")
+		viper.BindEnv("reminders.base_url", "REMINDERS_BASE_URL")
+		viper.BindEnv("reminders.smtp.host", "REMINDERS_SMTP_HOST")
+		viper.BindEnv("reminders.smtp.port", "REMINDERS_SMTP_PORT")
+		viper.BindEnv("reminders.smtp.username", "REMINDERS_SMTP_USERNAME")
+		viper.BindEnv("reminders.smtp.password", "REMINDERS_SMTP_PASSWORD")
+		viper.BindEnv("reminders.smtp.from", "REMINDERS_SMTP_FROM")
+		viper.BindEnv("reminders.smtp.domain", "REMINDERS_SMTP_DOMAIN")
+		viper.BindEnv("hooks.secret", "HOOKS_SECRET")
 	}
 
 	var cfg Config
@@ -45,6 +294,69 @@ func NewConfig(configPath string) (*Config, error) {
 	if cfg.Server.Port == "" {
 		cfg.Server.Port = "8080"
 	}
+	if cfg.WebSocket.PingInterval <= 0 {
+		cfg.WebSocket.PingInterval = 30 * time.Second
+	}
+	if cfg.WebSocket.PongTimeout <= 0 {
+		cfg.WebSocket.PongTimeout = 10 * time.Second
+	}
+	if cfg.WebSocket.WriteTimeout <= 0 {
+		cfg.WebSocket.WriteTimeout = 10 * time.Second
+	}
+	if cfg.Auth.Algorithm == "" {
+		cfg.Auth.Algorithm = "HS256"
+	}
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = StorageDriverSupabase
+	}
+	if cfg.SQLite.Path == "" {
+		cfg.SQLite.Path = "data/booking.db"
+	}
+	if cfg.Booking.ExpiryCheckInterval <= 0 {
+		cfg.Booking.ExpiryCheckInterval = time.Minute
+	}
+	if cfg.Audit.Driver == "" {
+		cfg.Audit.Driver = AuditDriverMemory
+	}
+	if cfg.Audit.FilePath == "" {
+		cfg.Audit.FilePath = "data/audit.jsonl"
+	}
+	if cfg.History.Driver == "" {
+		cfg.History.Driver = HistoryDriverMemory
+	}
+	if cfg.Webhooks.ExpiringBefore <= 0 {
+		cfg.Webhooks.ExpiringBefore = time.Hour
+	}
+	if cfg.Webhooks.CheckInterval <= 0 {
+		cfg.Webhooks.CheckInterval = time.Minute
+	}
+	if cfg.Webhooks.Timeout <= 0 {
+		cfg.Webhooks.Timeout = 10 * time.Second
+	}
+	if cfg.Webhooks.Retries < 0 {
+		cfg.Webhooks.Retries = 0
+	}
+	if cfg.Webhooks.Backoff <= 0 {
+		cfg.Webhooks.Backoff = time.Second
+	}
+	if cfg.Webhooks.DeadLetterFile == "" {
+		cfg.Webhooks.DeadLetterFile = "data/webhooks-dead-letter.jsonl"
+	}
+	if len(cfg.Reminders.Offsets) == 0 {
+		cfg.Reminders.Offsets = []time.Duration{24 * time.Hour, time.Hour}
+	}
+	if cfg.Reminders.CheckInterval <= 0 {
+		cfg.Reminders.CheckInterval = time.Minute
+	}
+	if len(cfg.Reminders.Channels) == 0 {
+		cfg.Reminders.Channels = []string{ReminderChannelWebSocket}
+	}
+	if cfg.Reminders.ExtendBy <= 0 {
+		cfg.Reminders.ExtendBy = 24 * time.Hour
+	}
+	if cfg.Reminders.SMTP.Port == 0 {
+		cfg.Reminders.SMTP.Port = 587
+	}
 
 	return &cfg, nil
 }
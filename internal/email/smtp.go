@@ -0,0 +1,92 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+// SMTPChannel отправляет напоминания об окончании бронирования письмом через SMTP.
+type SMTPChannel struct {
+	cfg       config.SMTPConfig
+	addresses map[string]string
+	// sendMail отправляет письмо, по умолчанию smtp.SendMail.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPChannel создает новый экземпляр SMTPChannel.
+func NewSMTPChannel(cfg config.SMTPConfig) (*SMTPChannel, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, fmt.Errorf("для напоминаний по почте не заданы reminders.smtp.host и reminders.smtp.from")
+	}
+	addresses := make(map[string]string, len(cfg.Addresses))
+	for user, address := range cfg.Addresses {
+		addresses[strings.ToLower(user)] = address
+	}
+	return &SMTPChannel{cfg: cfg, addresses: addresses, sendMail: smtp.SendMail}, nil
+}
+
+// Remind реализует интерфейс standservice.ReminderChannel: отправляет письмо владельцу бронирования.
+func (c *SMTPChannel) Remind(ctx context.Context, reminder models.Reminder) error {
+	to, err := c.address(reminder.User)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+	if err := c.sendMail(addr, auth, c.cfg.From, []string{to}, c.message(to, reminder)); err != nil {
+		return fmt.Errorf("ошибка отправки письма на %s: %w", to, err)
+	}
+	return nil
+}
+
+// address возвращает адрес почты пользователя.
+func (c *SMTPChannel) address(user string) (string, error) {
+	if address, ok := c.addresses[strings.ToLower(user)]; ok {
+		return address, nil
+	}
+	if strings.Contains(user, "@") {
+		return user, nil
+	}
+	if c.cfg.Domain == "" {
+		return "", fmt.Errorf("неизвестен адрес почты пользователя %s", user)
+	}
+	return user + "@" + c.cfg.Domain, nil
+}
+
+// message формирует письмо с напоминанием и ссылкой продления.
+func (c *SMTPChannel) message(to string, reminder models.Reminder) []byte {
+	endDate := time.Unix(reminder.Stand.EndDate, 0).Format("02.01.2006 15:04 MST")
+	subject := fmt.Sprintf("Бронирование стенда %s истекает %s", reminder.Stand.Name, endDate)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Здравствуйте, %s!\r\n\r\n", reminder.User)
+	fmt.Fprintf(&body, "Ваше бронирование стенда %s истекает %s.\r\n", reminder.Stand.Name, endDate)
+	if reminder.ExtendURL != "" {
+		extendUntil := time.Unix(reminder.ExtendUntil, 0).Format("02.01.2006 15:04 MST")
+		fmt.Fprintf(&body, "Продлить бронирование до %s: %s\r\n", extendUntil, reminder.ExtendURL)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", c.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(body.String())
+	return msg.Bytes()
+}
@@ -0,0 +1,63 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+func TestSMTPChannel_Remind(t *testing.T) {
+	channel, err := NewSMTPChannel(config.SMTPConfig{
+		Host:      "smtp.example.com",
+		Port:      587,
+		From:      "booking@example.com",
+		Domain:    "example.com",
+		Addresses: map[string]string{"Петров": "petrov@corp.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	var gotAddr string
+	var gotTo []string
+	var gotMsg string
+	channel.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+
+	reminder := models.Reminder{
+		Stand:       models.Stand{ID: 41, Name: "alpha", EndDate: time.Now().Add(time.Hour).Unix()},
+		User:        "ivanov",
+		ExtendUntil: time.Now().Add(25 * time.Hour).Unix(),
+		ExtendURL:   "https://booking.example.com/reminders/extend?token=abc",
+	}
+	if err := channel.Remind(context.Background(), reminder); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || len(gotTo) != 1 || gotTo[0] != "ivanov@example.com" {
+		t.Errorf("Письмо отправлено неверно: %s %v", gotAddr, gotTo)
+	}
+	if !strings.Contains(gotMsg, "Ваше бронирование стенда alpha истекает") || !strings.Contains(gotMsg, reminder.ExtendURL) {
+		t.Errorf("Неожиданное письмо:\n%s", gotMsg)
+	}
+
+	reminder.User = "петров"
+	channel.Remind(context.Background(), reminder)
+	if gotTo[0] != "petrov@corp.example.com" {
+		t.Errorf("Ожидался адрес из конфигурации, получено %v", gotTo)
+	}
+
+	channel.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		return errors.New("connection refused")
+	}
+	if err := channel.Remind(context.Background(), reminder); err == nil {
+		t.Error("Ожидалась ошибка отправки письма")
+	}
+}
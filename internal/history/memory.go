@@ -0,0 +1,47 @@
+package history
+
+import (
+	"context"
+	"sync"
+
+	"mts/booking_service/internal/models"
+)
+
+// MemoryStore хранит историю бронирований в памяти процесса. История теряется при перезапуске.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	intervals []models.BookingInterval
+}
+
+// NewMemoryStore создает пустую историю бронирований в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// SaveInterval добавляет новый период бронирования, если его id равен 0, иначе обновляет сохраненный.
+func (s *MemoryStore) SaveInterval(ctx context.Context, interval models.BookingInterval) (models.BookingInterval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if interval.ID > 0 && interval.ID <= int64(len(s.intervals)) {
+		s.intervals[interval.ID-1] = interval
+		return interval, nil
+	}
+	interval.ID = int64(len(s.intervals)) + 1
+	s.intervals = append(s.intervals, interval)
+	return interval, nil
+}
+
+// Intervals возвращает периоды бронирования, пересекающиеся с [from, to].
+func (s *MemoryStore) Intervals(ctx context.Context, from, to int64) ([]models.BookingInterval, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	intervals := []models.BookingInterval{}
+	for _, interval := range s.intervals {
+		if interval.Start <= to && interval.End >= from {
+			intervals = append(intervals, interval)
+		}
+	}
+	return intervals, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Транспорты, через которые клиент изменил стенд.
+const (
+	TransportWS     = "ws"
+	TransportREST   = "rest"
+	TransportSystem = "system"
+	// TransportHook - изменения из CI/CD через /hooks/deploy.
+	TransportHook = "hook"
+)
+
+// ActorSystem - автор изменений, которые сервис делает сам, например при истечении бронирования.
+const ActorSystem = "system"
+
+// AuditEntry - запись журнала изменений стенда.
+type AuditEntry struct {
+	ID int64 `json:"id"`
+	// Time - время изменения, Unix-время в секундах.
+	Time    int64  `json:"time"`
+	Actor   string `json:"actor"`
+	StandID int64  `json:"standId"`
+	// Transport - ws, rest, hook или system для изменений, сделанных самим сервисом.
+	Transport string `json:"transport"`
+	// Changes - значения измененных полей до и после изменения.
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// FieldChange - значение поля стенда до и после изменения.
+type FieldChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditFilter задает условия выборки из журнала изменений. Пустые поля не ограничивают выборку.
+type AuditFilter struct {
+	StandID *int64
+	Actor   string
+	// From и To - границы времени изменения включительно, Unix-время в секундах.
+	From *int64
+	To   *int64
+}
+
+// Match сообщает, подходит ли запись под фильтр.
+func (f AuditFilter) Match(entry AuditEntry) bool {
+	switch {
+	case f.StandID != nil && entry.StandID != *f.StandID:
+		return false
+	case f.Actor != "" && entry.Actor != f.Actor:
+		return false
+	case f.From != nil && entry.Time < *f.From:
+		return false
+	case f.To != nil && entry.Time > *f.To:
+		return false
+	}
+	return true
+}
+
+// Diff возвращает поля, значения которых в after отличаются от before.
+func Diff(before, after Stand) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for _, field := range Fields() {
+		beforeValue, _ := before.Field(field)
+		afterValue, _ := after.Field(field)
+		beforeJSON, _ := json.Marshal(beforeValue)
+		afterJSON, _ := json.Marshal(afterValue)
+		if !bytes.Equal(beforeJSON, afterJSON) {
+			changes[field] = FieldChange{Before: beforeJSON, After: afterJSON}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,20 @@
+package models
+
+// ChangeType - тип изменения стенда, пришедшего из хранилища.
+type ChangeType string
+
+const (
+	ChangeInsert ChangeType = "INSERT"
+	ChangeUpdate ChangeType = "UPDATE"
+	ChangeDelete ChangeType = "DELETE"
+	// ChangeResync означает, что часть изменений могла быть пропущена
+	// (например, после переподключения) и состояние стендов нужно перечитать целиком.
+	ChangeResync ChangeType = "RESYNC"
+)
+
+// StandChange описывает изменение стенда, сделанное в обход сервиса.
+// Для ChangeDelete в Stand заполнен только ID, для ChangeResync Stand пуст.
+type StandChange struct {
+	Type  ChangeType
+	Stand Stand
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// LegacyComponents перечисляет компоненты, для которых в таблице stands Supabase
+// есть отдельные колонки, в порядке этих колонок. Это реестр по умолчанию.
+var LegacyComponents = []string{"front", "back", "om", "apigw", "cart", "payments", "reviews", "lkstorage", "lkorders"}
+
+// componentName - допустимое имя компонента: из него складываются имена полей вида frontBranch.
+var componentName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// registry - реестр компонентов стенда.
+var registry = struct {
+	sync.RWMutex
+	components []string
+}{components: LegacyComponents}
+
+// Components возвращает зарегистрированные компоненты стенда в порядке реестра.
+func Components() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	return slices.Clone(registry.components)
+}
+
+// IsComponent сообщает, зарегистрирован ли компонент name.
+func IsComponent(name string) bool {
+	registry.RLock()
+	defer registry.RUnlock()
+	return slices.Contains(registry.components, name)
+}
+
+// SetComponents заменяет реестр компонентов стенда. Вызывается при запуске сервиса
+// до загрузки стендов: поля развертываний незарегистрированных компонентов не читаются.
+func SetComponents(components []string) error {
+	if len(components) == 0 {
+		return fmt.Errorf("реестр компонентов пуст")
+	}
+	for i, name := range components {
+		if !componentName.MatchString(name) {
+			return fmt.Errorf("некорректное имя компонента %q: допустимы строчные латинские буквы и цифры", name)
+		}
+		if slices.Contains(components[:i], name) {
+			return fmt.Errorf("компонент %s указан повторно", name)
+		}
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.components = slices.Clone(components)
+	return nil
+}
+
+// IsLegacyComponent сообщает, есть ли для компонента колонки в таблице stands Supabase.
+func IsLegacyComponent(name string) bool {
+	return slices.Contains(LegacyComponents, name)
+}
+
+// deploymentFields сопоставляет поля записи развертывания в deployments с суффиксами
+// плоских полей стенда.
+var deploymentFields = map[string]string{
+	"branch": branchSuffix,
+	"date":   deploymentDateSuffix,
+	"user":   deploymentUserSuffix,
+}
+
+// expandDeployments разворачивает значение поля deployments вида {"front": {"branch": "main"}}
+// в плоские поля развертываний: frontBranch и т.д. Отсутствующие поля записи не меняются.
+func expandDeployments(value json.RawMessage) (StandPatch, error) {
+	var deployments map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(value, &deployments); err != nil {
+		return nil, &FieldError{Field: deploymentsField, Err: fmt.Errorf("%w: поле %s: %v", ErrInvalidPatch, deploymentsField, err)}
+	}
+
+	patch := make(StandPatch)
+	for component, fields := range deployments {
+		if !IsComponent(component) {
+			field := deploymentsField + "." + component
+			return nil, &FieldError{Field: field, Err: fmt.Errorf("%w: неизвестный компонент %s", ErrInvalidPatch, component)}
+		}
+		for name, fieldValue := range fields {
+			suffix, ok := deploymentFields[name]
+			if !ok {
+				field := deploymentsField + "." + component + "." + name
+				return nil, &FieldError{Field: field, Err: fmt.Errorf("%w: неизвестное поле %s", ErrInvalidPatch, field)}
+			}
+			patch[component+suffix] = fieldValue
+		}
+	}
+	return patch, nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import "encoding/json"
+
+// DeploymentEvent - развертывание компонента на стенд, полученное из CI/CD.
+type DeploymentEvent struct {
+	// Stand - имя или id стенда.
+	Stand     string
+	Component string
+	Branch    string
+	User      string
+	// Date - время развертывания, Unix-время в секундах.
+	Date int64
+}
+
+// Patch возвращает патч полей развертывания компонента: <component>Branch,
+// <component>DeploymentDate и <component>DeploymentUser.
+func (e DeploymentEvent) Patch() StandPatch {
+	branch, _ := json.Marshal(e.Branch)
+	date, _ := json.Marshal(e.Date)
+	user, _ := json.Marshal(e.User)
+	return StandPatch{
+		e.Component + branchSuffix:         branch,
+		e.Component + deploymentDateSuffix: date,
+		e.Component + deploymentUserSuffix: user,
+	}
+}
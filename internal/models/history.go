@@ -0,0 +1,29 @@
+package models
+
+// BookingInterval - период, в течение которого стенд был забронирован одним пользователем.
+type BookingInterval struct {
+	ID      int64  `json:"id"`
+	StandID int64  `json:"standId"`
+	User    string `json:"user"`
+	// Start и End - начало и окончание бронирования, Unix-время в секундах. Пока
+	// бронирование не закончилось, End - запланированная дата окончания.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// PeakQueue - наибольшая длина очереди на стенд за время бронирования.
+	PeakQueue int `json:"peakQueue"`
+}
+
+// StandReport - статистика использования стенда за период.
+type StandReport struct {
+	StandID int64  `json:"standId"`
+	Name    string `json:"name"`
+	// Utilization - доля периода, в течение которой стенд был занят, в процентах.
+	Utilization float64 `json:"utilization"`
+	// AverageBooking - средняя длительность бронирования в секундах.
+	AverageBooking int64 `json:"averageBooking"`
+	Bookings       int   `json:"bookings"`
+	DistinctUsers  int   `json:"distinctUsers"`
+	// PeakContention - наибольшее число пользователей, одновременно претендовавших
+	// на стенд: владелец бронирования и очередь.
+	PeakContention int `json:"peakContention"`
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StandStatus - состояние бронирования стенда в выборке.
+type StandStatus string
+
+const (
+	StandStatusFree   StandStatus = "free"
+	StandStatusBooked StandStatus = "booked"
+)
+
+// StandSortFields перечисляет поля, по которым можно упорядочить выборку стендов.
+var StandSortFields = []string{"id", "name", "endDate", "users"}
+
+// StandQuery описывает выборку неархивных стендов: фильтры, порядок и страницу.
+type StandQuery struct {
+	// Status оставляет только свободные или только занятые на момент Now стенды.
+	Status StandStatus
+	// BookedBy оставляет стенды, занятые пользователем, без учета регистра.
+	BookedBy string
+	// ExpiringBefore оставляет занятые стенды, бронирование которых заканчивается
+	// раньше этого момента, Unix-время в секундах.
+	ExpiringBefore *int64
+	// Branches оставляет стенды, на компонентах которых развернуты указанные ветки.
+	Branches map[string]string
+	// Sort - поле из StandSortFields, с префиксом "-" - по убыванию. По умолчанию по id.
+	Sort string
+	// Limit - размер страницы, 0 - без ограничения. Offset - сколько стендов пропустить.
+	Limit  int
+	Offset int
+	// Now - момент, на который определяется занятость стендов, Unix-время в секундах.
+	Now int64
+}
+
+// SortField возвращает поле порядка и признак порядка по убыванию.
+func (q StandQuery) SortField() (field string, desc bool) {
+	field, desc = strings.CutPrefix(q.Sort, "-")
+	if field == "" {
+		field = "id"
+	}
+	return field, desc
+}
+
+// Match сообщает, подходит ли стенд под фильтры запроса.
+func (q StandQuery) Match(stand Stand) bool {
+	now := time.Unix(q.Now, 0)
+	booked := stand.IsBooked(now)
+	switch {
+	case stand.IsArchived():
+		return false
+	case q.Status == StandStatusFree && booked, q.Status == StandStatusBooked && !booked:
+		return false
+	case q.BookedBy != "" && (!booked || !stand.IsBookedBy(q.BookedBy)):
+		return false
+	case q.ExpiringBefore != nil && (!booked || stand.EndDate >= *q.ExpiringBefore):
+		return false
+	}
+	for component, branch := range q.Branches {
+		deployed := stand.Deployments[component].Branch
+		if deployed == nil || *deployed != branch {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply отбирает из stands подходящие под запрос стенды, упорядочивает их и возвращает
+// запрошенную страницу вместе с общим числом подходящих стендов.
+func (q StandQuery) Apply(stands []Stand) ([]Stand, int) {
+	matched := make([]Stand, 0, len(stands))
+	for _, stand := range stands {
+		if q.Match(stand) {
+			matched = append(matched, stand)
+		}
+	}
+
+	field, desc := q.SortField()
+	slices.SortStableFunc(matched, func(a, b Stand) int {
+		order := compareStands(a, b, field)
+		if desc {
+			order = -order
+		}
+		if order == 0 {
+			order = cmp.Compare(a.ID, b.ID)
+		}
+		return order
+	})
+
+	total := len(matched)
+	start := min(q.Offset, total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+	return matched[start:end], total
+}
+
+// compareStands сравнивает стенды по полю порядка.
+func compareStands(a, b Stand, field string) int {
+	switch field {
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "endDate":
+		return cmp.Compare(a.EndDate, b.EndDate)
+	case "users":
+		return strings.Compare(a.Users, b.Users)
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+// Reminder - напоминание владельцу бронирования о скором окончании бронирования.
+type Reminder struct {
+	Stand Stand
+	User  string
+	// Offset - за сколько секунд до окончания бронирования отправлено напоминание.
+	Offset int64
+	// ExtendUntil - до какого момента продлит бронирование ExtendToken, Unix-время в секундах.
+	ExtendUntil int64
+	// ExtendToken - подписанный токен для продления бронирования одним действием.
+	ExtendToken string
+	// ExtendURL - ссылка для продления бронирования по ExtendToken, если задан адрес сервиса.
+	ExtendURL string
+}
@@ -0,0 +1,467 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPatch возвращается, если патч стенда содержит неизвестные поля или значения неверного типа.
+	ErrInvalidPatch = errors.New("некорректные данные для обновления стенда")
+	// ErrStandNotFound возвращается хранилищем, если стенд с указанным id не существует.
+	ErrStandNotFound = errors.New("стенд не найден")
+	// ErrVersionConflict возвращается хранилищем, если версия стенда не совпала с ожидаемой.
+	ErrVersionConflict = errors.New("стенд был изменен другим пользователем")
+	// ErrStandArchived возвращается при попытке изменить стенд, перенесенный в архив.
+	ErrStandArchived = errors.New("стенд перенесен в архив")
+	// ErrStorageUnavailable возвращается хранилищем, если оно не ответило или вернуло ошибку.
+	ErrStorageUnavailable = errors.New("хранилище стендов недоступно")
+	// ErrInvalidStandID возвращается, если id стенда не является целым числом.
+	ErrInvalidStandID = errors.New("некорректный id стенда")
+)
+
+// ParseStandID разбирает id стенда. Допускается префикс "eq." фильтра PostgREST,
+// который передают клиенты Supabase, например "eq.41"; другие операторы отклоняются.
+func ParseStandID(id string) (int64, error) {
+	standID, err := strconv.ParseInt(strings.TrimPrefix(id, "eq."), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidStandID, id)
+	}
+	return standID, nil
+}
+
+// FieldError уточняет ошибку ErrInvalidPatch полем стенда, в котором она обнаружена.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictError сообщает о конфликте версий и содержит актуальное состояние стенда,
+// чтобы клиент мог повторить изменение поверх него.
+type ConflictError struct {
+	Current *Stand
+}
+
+func (e *ConflictError) Error() string {
+	if e.Current == nil {
+		return ErrVersionConflict.Error()
+	}
+	return fmt.Sprintf("%s: актуальная версия стенда %s - %d", ErrVersionConflict, e.Current.Name, e.Current.Version)
+}
+
+// Is позволяет сравнивать ConflictError с ErrVersionConflict через errors.Is.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// Суффиксы колонок, из которых складываются поля развертывания компонента: frontBranch, frontDeploymentDate и т.д.
+const (
+	branchSuffix         = "Branch"
+	deploymentDateSuffix = "DeploymentDate"
+	deploymentUserSuffix = "DeploymentUser"
+)
+
+// deploymentsField - поле JSON-представления стенда с развертываниями всех компонентов реестра.
+const deploymentsField = "deployments"
+
+// Deployment описывает развертывание одного компонента стенда.
+type Deployment struct {
+	Branch *string `json:"branch"`
+	// Date - время развертывания, Unix-время в секундах.
+	Date *int64  `json:"date"`
+	User *string `json:"user"`
+}
+
+// Equal сообщает, совпадают ли значения развертываний.
+func (d Deployment) Equal(other Deployment) bool {
+	return equalPtr(d.Branch, other.Branch) && equalPtr(d.Date, other.Date) && equalPtr(d.User, other.User)
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Stand описывает стенд и его текущее бронирование.
+// В JSON стенд представлен плоским объектом в формате таблицы stands, дополненным
+// полем deployments с развертываниями по компонентам реестра.
+type Stand struct {
+	ID           int64
+	Name         string
+	EndDate      int64
+	Users        string
+	Reason       string
+	Comment      string
+	StandLink    *string
+	DBUpdateDate *int64
+	// Deployments - развертывания по именам компонентов из реестра.
+	Deployments map[string]Deployment
+	// ArchivedAt - время переноса стенда в архив, Unix-время в секундах. Архивные стенды
+	// не показываются на досках, но остаются в хранилище для истории.
+	ArchivedAt *int64
+	// Version увеличивается хранилищем при каждом изменении стенда и используется
+	// для оптимистичной блокировки.
+	Version int64
+	// Queue - очередь ожидающих стенд пользователей. Не хранится в таблице stands
+	// и сериализуется в JSON только если непуста.
+	Queue []QueueEntry
+}
+
+// QueueEntry описывает пользователя в очереди на стенд.
+type QueueEntry struct {
+	User   string `json:"user"`
+	Reason string `json:"reason"`
+	// Duration - желаемая длительность бронирования в секундах.
+	Duration   int64 `json:"duration"`
+	EnqueuedAt int64 `json:"enqueuedAt"`
+}
+
+// IsBooked сообщает, занят ли стенд в указанный момент времени.
+func (s Stand) IsBooked(now time.Time) bool {
+	return s.EndDate > now.Unix()
+}
+
+// IsArchived сообщает, перенесен ли стенд в архив.
+func (s Stand) IsArchived() bool {
+	return s.ArchivedAt != nil
+}
+
+// IsBookedBy сообщает, принадлежит ли бронирование стенда пользователю user.
+func (s Stand) IsBookedBy(user string) bool {
+	return SameUser(s.Users, user)
+}
+
+// SameUser сообщает, обозначают ли a и b одного пользователя: без учета регистра
+// и пробелов по краям.
+func SameUser(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// Fields возвращает имена плоских полей стенда: основные поля и поля развертываний
+// компонентов реестра в порядке колонок таблицы stands Supabase.
+func Fields() []string {
+	fields := []string{"id", "name", "endDate", "users", "reason", "comment", "standLink"}
+	placed := false
+	for _, component := range Components() {
+		fields = append(fields, ComponentFields(component)...)
+		// В таблице stands колонка dbUpdateDate исторически идет сразу после колонок om.
+		if component == "om" {
+			fields = append(fields, "dbUpdateDate")
+			placed = true
+		}
+	}
+	if !placed {
+		fields = append(fields, "dbUpdateDate")
+	}
+	return append(fields, "archivedAt", "version")
+}
+
+// BaseFields возвращает поля стенда, не относящиеся к развертываниям компонентов.
+// В нормализованной схеме это колонки таблицы stands.
+func BaseFields() []string {
+	return []string{"id", "name", "endDate", "users", "reason", "comment", "standLink", "dbUpdateDate", "archivedAt", "version"}
+}
+
+// ComponentFields возвращает плоские поля развертывания компонента: ветку, время и автора.
+func ComponentFields(component string) []string {
+	return []string{component + branchSuffix, component + deploymentDateSuffix, component + deploymentUserSuffix}
+}
+
+// ComponentOf возвращает компонент плоского поля развертывания, например front для frontBranch.
+func ComponentOf(key string) (string, bool) {
+	component, _, ok := splitComponentField(key)
+	return component, ok
+}
+
+// ChangedComponents возвращает компоненты, развертывания которых в after отличаются от before.
+func ChangedComponents(before, after Stand) []string {
+	var changed []string
+	for _, component := range Components() {
+		if !before.Deployments[component].Equal(after.Deployments[component]) {
+			changed = append(changed, component)
+		}
+	}
+	return changed
+}
+
+// splitComponentField разбирает имя поля развертывания на компонент и суффикс.
+func splitComponentField(key string) (component, suffix string, ok bool) {
+	for _, candidate := range []string{branchSuffix, deploymentDateSuffix, deploymentUserSuffix} {
+		name, found := strings.CutSuffix(key, candidate)
+		if found && IsComponent(name) {
+			return name, candidate, true
+		}
+	}
+	return "", "", false
+}
+
+// Field возвращает значение поля стенда по его имени в JSON.
+func (s Stand) Field(key string) (any, bool) {
+	switch key {
+	case "id":
+		return s.ID, true
+	case "name":
+		return s.Name, true
+	case "endDate":
+		return s.EndDate, true
+	case "users":
+		return s.Users, true
+	case "reason":
+		return s.Reason, true
+	case "comment":
+		return s.Comment, true
+	case "standLink":
+		return s.StandLink, true
+	case "dbUpdateDate":
+		return s.DBUpdateDate, true
+	case "archivedAt":
+		return s.ArchivedAt, true
+	case "version":
+		return s.Version, true
+	case deploymentsField:
+		return s.deploymentsByComponent(), true
+	}
+
+	component, suffix, ok := splitComponentField(key)
+	if !ok {
+		return nil, false
+	}
+	deployment := s.Deployments[component]
+	switch suffix {
+	case branchSuffix:
+		return deployment.Branch, true
+	case deploymentDateSuffix:
+		return deployment.Date, true
+	default:
+		return deployment.User, true
+	}
+}
+
+// SetField устанавливает значение поля стенда из JSON.
+func (s *Stand) SetField(key string, value json.RawMessage) error {
+	var target any
+	switch key {
+	case "id":
+		target = &s.ID
+	case "name":
+		target = &s.Name
+	case "endDate":
+		target = &s.EndDate
+	case "users":
+		target = &s.Users
+	case "reason":
+		target = &s.Reason
+	case "comment":
+		target = &s.Comment
+	case "standLink":
+		target = &s.StandLink
+	case "dbUpdateDate":
+		target = &s.DBUpdateDate
+	case "archivedAt":
+		target = &s.ArchivedAt
+	case "version":
+		target = &s.Version
+	case deploymentsField:
+		expanded, err := expandDeployments(value)
+		if err != nil {
+			return err
+		}
+		for field, fieldValue := range expanded {
+			if err := s.SetField(field, fieldValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if target != nil {
+		if err := json.Unmarshal(value, target); err != nil {
+			return &FieldError{Field: key, Err: fmt.Errorf("%w: поле %s: %v", ErrInvalidPatch, key, err)}
+		}
+		return nil
+	}
+
+	component, suffix, ok := splitComponentField(key)
+	if !ok {
+		return &FieldError{Field: key, Err: fmt.Errorf("%w: неизвестное поле %s", ErrInvalidPatch, key)}
+	}
+	deployment := s.Deployments[component]
+	switch suffix {
+	case branchSuffix:
+		target = &deployment.Branch
+	case deploymentDateSuffix:
+		target = &deployment.Date
+	default:
+		target = &deployment.User
+	}
+	if err := json.Unmarshal(value, target); err != nil {
+		return &FieldError{Field: key, Err: fmt.Errorf("%w: поле %s: %v", ErrInvalidPatch, key, err)}
+	}
+	if s.Deployments == nil {
+		s.Deployments = make(map[string]Deployment)
+	}
+	s.Deployments[component] = deployment
+	return nil
+}
+
+// deploymentsByComponent возвращает развертывания всех компонентов реестра,
+// включая компоненты, которые еще не развертывались.
+func (s Stand) deploymentsByComponent() map[string]Deployment {
+	deployments := make(map[string]Deployment)
+	for _, component := range Components() {
+		deployments[component] = s.Deployments[component]
+	}
+	return deployments
+}
+
+// Clone возвращает копию стенда, не разделяющую с оригиналом карту развертываний и очередь.
+func (s Stand) Clone() Stand {
+	clone := s
+	if s.Deployments != nil {
+		clone.Deployments = make(map[string]Deployment, len(s.Deployments))
+		for component, deployment := range s.Deployments {
+			clone.Deployments[component] = deployment
+		}
+	}
+	if s.Queue != nil {
+		clone.Queue = append([]QueueEntry(nil), s.Queue...)
+	}
+	return clone
+}
+
+// Apply возвращает копию стенда с примененным патчем.
+func (s Stand) Apply(patch StandPatch) (Stand, error) {
+	updated := s.Clone()
+	for key, value := range patch {
+		if key == "id" || key == "version" || key == "archivedAt" {
+			return Stand{}, &FieldError{Field: key, Err: fmt.Errorf("%w: поле %s нельзя изменять", ErrInvalidPatch, key)}
+		}
+		if key == deploymentsField {
+			continue
+		}
+		if err := updated.SetField(key, value); err != nil {
+			return Stand{}, err
+		}
+	}
+	// Как и в UnmarshalJSON, deployments применяется последним и перекрывает плоские поля
+	// развертываний независимо от порядка обхода патча.
+	if value, ok := patch[deploymentsField]; ok {
+		if err := updated.SetField(deploymentsField, value); err != nil {
+			return Stand{}, err
+		}
+	}
+	return updated, nil
+}
+
+// MarshalJSON сериализует стенд в плоский объект с колонками таблицы stands
+// и полем deployments.
+func (s Stand) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range append(Fields(), deploymentsField) {
+		value, _ := s.Field(key)
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("поле %s: %w", key, err)
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+	if len(s.Queue) > 0 {
+		queue, err := json.Marshal(s.Queue)
+		if err != nil {
+			return nil, fmt.Errorf("поле queue: %w", err)
+		}
+		buf.WriteString(`,"queue":`)
+		buf.Write(queue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON разбирает плоский объект стенда. Неизвестные поля и поля развертываний
+// незарегистрированных компонентов игнорируются. Поле deployments применяется последним
+// и имеет приоритет над плоскими полями развертываний.
+func (s *Stand) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key, value := range raw {
+		if _, known := s.Field(key); !known || key == deploymentsField {
+			continue
+		}
+		if err := s.SetField(key, value); err != nil {
+			return err
+		}
+	}
+	if value, ok := raw[deploymentsField]; ok && string(value) != "null" {
+		var deployments map[string]json.RawMessage
+		if err := json.Unmarshal(value, &deployments); err != nil {
+			return fmt.Errorf("поле %s: %w", deploymentsField, err)
+		}
+		for component := range deployments {
+			if !IsComponent(component) {
+				delete(deployments, component)
+			}
+		}
+		known, _ := json.Marshal(deployments)
+		if err := s.SetField(deploymentsField, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StandPatch описывает частичное обновление стенда: ключи совпадают с полями JSON-представления стенда.
+// Развертывания меняются плоскими полями (frontBranch) или полем deployments ({"front": {"branch": "main"}}).
+type StandPatch map[string]json.RawMessage
+
+// Flatten возвращает патч, в котором поле deployments развернуто в плоские поля развертываний.
+func (p StandPatch) Flatten() (StandPatch, error) {
+	flat := make(StandPatch, len(p))
+	for key, value := range p {
+		if key != deploymentsField {
+			flat[key] = value
+		}
+	}
+	// Поля из deployments перекрывают плоские поля развертываний, как в Stand.Apply.
+	if value, ok := p[deploymentsField]; ok {
+		expanded, err := expandDeployments(value)
+		if err != nil {
+			return nil, err
+		}
+		for field, fieldValue := range expanded {
+			flat[field] = fieldValue
+		}
+	}
+	return flat, nil
+}
+
+// Validate проверяет, что патч непустой и содержит только известные поля корректного типа.
+func (p StandPatch) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("%w: пустой патч", ErrInvalidPatch)
+	}
+	_, err := Stand{}.Apply(p)
+	return err
+}
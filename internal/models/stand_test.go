@@ -0,0 +1,260 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sampleStand - строка таблицы stands в том виде, в котором ее отдает Supabase,
+// с добавленной колонкой version.
+const sampleStand = `{"id":41,"name":"aaazovce","endDate":1764704428,"users":"тест ","reason":"тест ","comment":"","standLink":"","frontBranch":"feature/SBX-2131","frontDeploymentDate":1758552577,"frontDeploymentUser":"aaazovce","backBranch":null,"backDeploymentDate":null,"backDeploymentUser":null,"omBranch":"actual","omDeploymentDate":1751375219,"omDeploymentUser":"Овчинникова Евгения Владимировна","dbUpdateDate":null,"apigwBranch":"master","apigwDeploymentDate":1758620715,"apigwDeploymentUser":"rrisrafilo","cartBranch":null,"cartDeploymentDate":null,"cartDeploymentUser":null,"paymentsBranch":"actual","paymentsDeploymentDate":1723031295,"paymentsDeploymentUser":"vplari10","reviewsBranch":null,"reviewsDeploymentDate":null,"reviewsDeploymentUser":null,"lkstorageBranch":null,"lkstorageDeploymentDate":null,"lkstorageDeploymentUser":null,"lkordersBranch":null,"lkordersDeploymentDate":null,"lkordersDeploymentUser":null,"version":3}`
+
+// sampleDeployments - поле deployments стенда sampleStand в JSON-представлении API.
+const sampleDeployments = `{"apigw":{"branch":"master","date":1758620715,"user":"rrisrafilo"},"back":{"branch":null,"date":null,"user":null},"cart":{"branch":null,"date":null,"user":null},"front":{"branch":"feature/SBX-2131","date":1758552577,"user":"aaazovce"},"lkorders":{"branch":null,"date":null,"user":null},"lkstorage":{"branch":null,"date":null,"user":null},"om":{"branch":"actual","date":1751375219,"user":"Овчинникова Евгения Владимировна"},"payments":{"branch":"actual","date":1723031295,"user":"vplari10"},"reviews":{"branch":null,"date":null,"user":null}}`
+
+func TestStand_JSONRoundTrip(t *testing.T) {
+	var stand Stand
+	if err := json.Unmarshal([]byte(sampleStand), &stand); err != nil {
+		t.Fatalf("Не удалось разобрать стенд: %v", err)
+	}
+
+	if stand.ID != 41 || stand.Name != "aaazovce" || stand.EndDate != 1764704428 || stand.Version != 3 {
+		t.Errorf("Неожиданные основные поля стенда: %+v", stand)
+	}
+	front := stand.Deployments["front"]
+	if front.Branch == nil || *front.Branch != "feature/SBX-2131" || front.Date == nil || *front.Date != 1758552577 {
+		t.Errorf("Неожиданное развертывание front: %+v", front)
+	}
+	if back := stand.Deployments["back"]; back.Branch != nil {
+		t.Errorf("Ожидалась пустая ветка back, получено %q", *back.Branch)
+	}
+
+	encoded, err := json.Marshal(stand)
+	if err != nil {
+		t.Fatalf("Не удалось сериализовать стенд: %v", err)
+	}
+	// Колонки archivedAt в таблице Supabase может не быть: стенд из такой строки не в архиве.
+	expected := strings.Replace(sampleStand, `"version"`, `"archivedAt":null,"version"`, 1)
+	expected = strings.TrimSuffix(expected, "}") + `,"deployments":` + sampleDeployments + "}"
+	if string(encoded) != expected {
+		t.Errorf("Формат JSON изменился:\nожидалось %s\nполучено  %s", expected, encoded)
+	}
+}
+
+func TestStand_Apply(t *testing.T) {
+	var stand Stand
+	if err := json.Unmarshal([]byte(sampleStand), &stand); err != nil {
+		t.Fatalf("Не удалось разобрать стенд: %v", err)
+	}
+
+	t.Run("применение патча не меняет исходный стенд", func(t *testing.T) {
+		updated, err := stand.Apply(StandPatch{
+			"users":      json.RawMessage(`"Иванов"`),
+			"backBranch": json.RawMessage(`"release/1.0"`),
+		})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if updated.Users != "Иванов" || *updated.Deployments["back"].Branch != "release/1.0" {
+			t.Errorf("Патч применен неверно: %+v", updated)
+		}
+		if stand.Users != "тест " || stand.Deployments["back"].Branch != nil {
+			t.Errorf("Исходный стенд изменился: %+v", stand)
+		}
+	})
+
+	t.Run("некорректные патчи", func(t *testing.T) {
+		patches := map[string]StandPatch{
+			"неизвестное поле": {"status": json.RawMessage(`"occupied"`)},
+			"неверный тип":     {"endDate": json.RawMessage(`"завтра"`)},
+			"изменение id":     {"id": json.RawMessage(`42`)},
+			"изменение версии": {"version": json.RawMessage(`4`)},
+			"перенос в архив":  {"archivedAt": json.RawMessage(`1700000000`)},
+			"пустой патч":      {},
+		}
+		for name, patch := range patches {
+			if err := patch.Validate(); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("%s: ожидалась ошибка ErrInvalidPatch, получено %v", name, err)
+			}
+		}
+	})
+
+	t.Run("deployments перекрывает плоские поля развертываний", func(t *testing.T) {
+		patch := StandPatch{
+			"frontBranch": json.RawMessage(`"flat"`),
+			"deployments": json.RawMessage(`{"front": {"branch": "nested"}}`),
+		}
+		// Порядок обхода map случаен, поэтому результат проверяется несколько раз.
+		for range 20 {
+			updated, err := stand.Apply(patch)
+			if err != nil {
+				t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+			}
+			flat, err := patch.Flatten()
+			if err != nil {
+				t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+			}
+			if *updated.Deployments["front"].Branch != "nested" || string(flat["frontBranch"]) != `"nested"` {
+				t.Fatalf("Ожидалась ветка из deployments, получено %s и %s", *updated.Deployments["front"].Branch, flat["frontBranch"])
+			}
+		}
+	})
+
+	t.Run("ошибка указывает поле патча", func(t *testing.T) {
+		_, err := Stand{}.Apply(StandPatch{"frontBranch": json.RawMessage(`42`)})
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != "frontBranch" {
+			t.Errorf("Ожидалась ошибка поля frontBranch, получено %v", err)
+		}
+	})
+}
+
+func TestStand_ComponentRegistry(t *testing.T) {
+	t.Cleanup(func() { SetComponents(LegacyComponents) })
+
+	t.Run("некорректный реестр", func(t *testing.T) {
+		registries := map[string][]string{
+			"пустой реестр":       {},
+			"повтор компонента":   {"front", "front"},
+			"недопустимое имя":    {"Front"},
+			"имя с разделителями": {"front-end"},
+		}
+		for name, components := range registries {
+			if err := SetComponents(components); err == nil {
+				t.Errorf("%s: ожидалась ошибка", name)
+			}
+		}
+	})
+
+	t.Run("новый компонент без изменения схемы", func(t *testing.T) {
+		if err := SetComponents([]string{"front", "search"}); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if fields := Fields(); !slices.Contains(fields, "searchBranch") || slices.Contains(fields, "backBranch") {
+			t.Errorf("Поля стенда не соответствуют реестру: %v", fields)
+		}
+
+		var stand Stand
+		if err := json.Unmarshal([]byte(sampleStand), &stand); err != nil {
+			t.Fatalf("Не удалось разобрать стенд: %v", err)
+		}
+		if _, ok := stand.Deployments["om"]; ok {
+			t.Errorf("Развертывание незарегистрированного компонента не должно читаться: %+v", stand.Deployments)
+		}
+
+		updated, err := stand.Apply(StandPatch{
+			"deployments": json.RawMessage(`{"search": {"branch": "feature/fts", "user": "Иванов"}}`),
+		})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		search := updated.Deployments["search"]
+		if search.Branch == nil || *search.Branch != "feature/fts" || search.User == nil || *search.User != "Иванов" || search.Date != nil {
+			t.Errorf("Неожиданное развертывание search: %+v", search)
+		}
+		if changed := ChangedComponents(stand, updated); !slices.Equal(changed, []string{"search"}) {
+			t.Errorf("Ожидалось изменение только search, получено %v", changed)
+		}
+	})
+
+	t.Run("развертывания в патче", func(t *testing.T) {
+		SetComponents(LegacyComponents)
+		flat, err := StandPatch{
+			"users":       json.RawMessage(`"Иванов"`),
+			"deployments": json.RawMessage(`{"back": {"branch": "main", "date": 1700000000}}`),
+		}.Flatten()
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if len(flat) != 3 || string(flat["backBranch"]) != `"main"` || string(flat["backDeploymentDate"]) != "1700000000" {
+			t.Errorf("Неожиданный плоский патч: %v", flat)
+		}
+
+		patches := map[string]StandPatch{
+			"неизвестный компонент": {"deployments": json.RawMessage(`{"search": {"branch": "main"}}`)},
+			"неизвестное поле":      {"deployments": json.RawMessage(`{"back": {"status": "ok"}}`)},
+			"неверный тип":          {"deployments": json.RawMessage(`{"back": {"date": "вчера"}}`)},
+		}
+		for name, patch := range patches {
+			if err := patch.Validate(); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("%s: ожидалась ошибка ErrInvalidPatch, получено %v", name, err)
+			}
+		}
+	})
+}
+
+func TestStandQuery_Apply(t *testing.T) {
+	SetComponents(LegacyComponents)
+	const now = 1_700_000_000
+	mainBranch, featureBranch := "main", "feature/login"
+	archivedAt := int64(now - 3600)
+	stands := []Stand{
+		{ID: 1, Name: "alpha", Users: "Иванов", EndDate: now + 7200, Deployments: map[string]Deployment{"front": {Branch: &mainBranch}}},
+		{ID: 2, Name: "beta", Users: "Петров", EndDate: now + 600, Deployments: map[string]Deployment{"front": {Branch: &featureBranch}}},
+		{ID: 3, Name: "gamma", Users: "Иванов", EndDate: now - 60},
+		{ID: 4, Name: "delta", EndDate: now + 60, ArchivedAt: &archivedAt},
+		{ID: 5, Name: "epsilon", Users: "иванов ", EndDate: now + 300, Deployments: map[string]Deployment{"front": {Branch: &mainBranch}}},
+	}
+	expiringBefore := int64(now + 3600)
+
+	cases := map[string]struct {
+		query StandQuery
+		ids   []int64
+		total int
+	}{
+		"без фильтров":                  {StandQuery{}, []int64{1, 2, 3, 5}, 4},
+		"свободные":                     {StandQuery{Status: StandStatusFree}, []int64{3}, 1},
+		"занятые":                       {StandQuery{Status: StandStatusBooked}, []int64{1, 2, 5}, 3},
+		"занятые пользователем":         {StandQuery{BookedBy: "ИВАНОВ"}, []int64{1, 5}, 2},
+		"бронирование скоро закончится": {StandQuery{ExpiringBefore: &expiringBefore}, []int64{2, 5}, 2},
+		"ветка компонента":              {StandQuery{Branches: map[string]string{"front": "main"}}, []int64{1, 5}, 2},
+		"по окончанию бронирования":     {StandQuery{Sort: "-endDate"}, []int64{1, 2, 5, 3}, 4},
+		"по имени":                      {StandQuery{Sort: "name"}, []int64{1, 2, 5, 3}, 4},
+		"страница":                      {StandQuery{Sort: "name", Limit: 2, Offset: 1}, []int64{2, 5}, 4},
+		"страница за концом выборки":    {StandQuery{Limit: 2, Offset: 10}, []int64{}, 4},
+	}
+	for name, tc := range cases {
+		tc.query.Now = now
+		page, total := tc.query.Apply(stands)
+		ids := make([]int64, 0, len(page))
+		for _, stand := range page {
+			ids = append(ids, stand.ID)
+		}
+		if !slices.Equal(ids, tc.ids) || total != tc.total {
+			t.Errorf("%s: ожидались стенды %v из %d, получено %v из %d", name, tc.ids, tc.total, ids, total)
+		}
+	}
+}
+
+func TestParseStandID(t *testing.T) {
+	cases := map[string]struct {
+		id       string
+		expected int64
+		valid    bool
+	}{
+		"число":       {id: "41", expected: 41, valid: true},
+		"префикс eq.": {id: "eq.41", expected: 41, valid: true},
+		"другой оператор PostgREST": {id: "gt.0"},
+		"список значений":           {id: "in.(41,42)"},
+		"дополнительные фильтры":    {id: "41&version=eq.2"},
+		"пустой id":                 {id: ""},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			id, err := ParseStandID(tc.id)
+			if !tc.valid {
+				if !errors.Is(err, ErrInvalidStandID) {
+					t.Errorf("Ожидалась ошибка ErrInvalidStandID, получено %v", err)
+				}
+				return
+			}
+			if err != nil || id != tc.expected {
+				t.Errorf("Ожидался id %d, получено %d, %v", tc.expected, id, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// StandsRepository хранит стенды в памяти процесса. Используется для локальной
+// разработки и тестов без доступа к Supabase.
+type StandsRepository struct {
+	mu     sync.RWMutex
+	stands map[int64]models.Stand
+}
+
+// NewStandsRepository создает репозиторий, заполненный переданными стендами.
+func NewStandsRepository(stands []models.Stand) *StandsRepository {
+	r := &StandsRepository{stands: make(map[int64]models.Stand, len(stands))}
+	for _, stand := range stands {
+		r.stands[stand.ID] = stand.Clone()
+	}
+	return r
+}
+
+// LoadStandsRepository создает репозиторий, заполненный стендами из JSON-файла
+// в формате таблицы stands. Пустой путь означает пустой репозиторий.
+func LoadStandsRepository(path string) (*StandsRepository, error) {
+	if path == "" {
+		return NewStandsRepository(nil), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла со стендами: %w", err)
+	}
+
+	var stands []models.Stand
+	if err := json.Unmarshal(data, &stands); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла со стендами %s: %w", path, err)
+	}
+
+	return NewStandsRepository(stands), nil
+}
+
+// GetStands возвращает все стенды, кроме архивных, упорядоченные по id.
+func (r *StandsRepository) GetStands(ctx context.Context) ([]models.Stand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stands := make([]models.Stand, 0, len(r.stands))
+	for _, stand := range r.stands {
+		if stand.IsArchived() {
+			continue
+		}
+		stands = append(stands, stand.Clone())
+	}
+	sort.Slice(stands, func(i, j int) bool { return stands[i].ID < stands[j].ID })
+	return stands, nil
+}
+
+// GetStand возвращает стенд по id, в том числе архивный.
+func (r *StandsRepository) GetStand(ctx context.Context, id int64) (*models.Stand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stand, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	clone := stand.Clone()
+	return &clone, nil
+}
+
+// Patch применяет патч к стенду, увеличивает его версию и возвращает сохраненный стенд.
+func (r *StandsRepository) Patch(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+	return r.update(id, version, func(stand models.Stand) (models.Stand, error) {
+		return stand.Apply(patch)
+	})
+}
+
+// Create добавляет стенд с id, следующим за максимальным, в том числе среди архивных.
+func (r *StandsRepository) Create(ctx context.Context, stand models.Stand) (*models.Stand, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := stand.Clone()
+	created.ID, created.Version, created.ArchivedAt, created.Queue = 1, 0, nil, nil
+	for id := range r.stands {
+		created.ID = max(created.ID, id+1)
+	}
+	r.stands[created.ID] = created
+	clone := created.Clone()
+	return &clone, nil
+}
+
+// Archive переносит стенд в архив, увеличивает его версию и возвращает сохраненный стенд.
+func (r *StandsRepository) Archive(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error) {
+	return r.update(id, version, func(stand models.Stand) (models.Stand, error) {
+		archivedAt := at.Unix()
+		stand.ArchivedAt = &archivedAt
+		return stand, nil
+	})
+}
+
+// update заменяет неархивный стенд результатом change с проверкой версии, увеличивает версию
+// и возвращает копию сохраненного стенда.
+func (r *StandsRepository) update(id int64, version *int64, change func(models.Stand) (models.Stand, error)) (*models.Stand, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stand, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if stand.IsArchived() {
+		return nil, fmt.Errorf("%w: id %d", models.ErrStandArchived, id)
+	}
+	if version != nil && *version != stand.Version {
+		return nil, fmt.Errorf("%w: id %d, ожидаемая версия %d, текущая %d", models.ErrVersionConflict, id, *version, stand.Version)
+	}
+
+	updated, err := change(stand.Clone())
+	if err != nil {
+		return nil, err
+	}
+	updated.Version++
+	r.stands[updated.ID] = updated
+	clone := updated.Clone()
+	return &clone, nil
+}
+
+// find ищет стенд по id. Вызывающий должен удерживать r.mu.
+func (r *StandsRepository) find(id int64) (models.Stand, error) {
+	stand, ok := r.stands[id]
+	if !ok {
+		return models.Stand{}, fmt.Errorf("%w: id %d", models.ErrStandNotFound, id)
+	}
+	return stand, nil
+}
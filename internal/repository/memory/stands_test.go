@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+func TestLoadStandsRepository(t *testing.T) {
+	repo, err := LoadStandsRepository("../../../configs/stands.sample.json")
+	if err != nil {
+		t.Fatalf("Не удалось загрузить стенды: %v", err)
+	}
+
+	stands, err := repo.GetStands(context.Background())
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if len(stands) == 0 {
+		t.Fatal("Ожидались стенды из файла")
+	}
+	for i := 1; i < len(stands); i++ {
+		if stands[i-1].ID >= stands[i].ID {
+			t.Fatalf("Стенды должны быть упорядочены по id: %d, %d", stands[i-1].ID, stands[i].ID)
+		}
+	}
+
+	stand, err := repo.GetStand(context.Background(), 41)
+	if err != nil || stand.Name != "aaazovce" {
+		t.Errorf("Ожидался стенд aaazovce, получено %+v, ошибка %v", stand, err)
+	}
+}
+
+func TestStandsRepository_Patch(t *testing.T) {
+	repo := NewStandsRepository([]models.Stand{{ID: 1, Name: "alpha"}})
+	ctx := context.Background()
+	patch := models.StandPatch{"users": json.RawMessage(`"Иванов"`)}
+
+	version := int64(0)
+	if _, err := repo.Patch(ctx, 1, &version, patch); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if _, err := repo.Patch(ctx, 1, &version, patch); !errors.Is(err, models.ErrVersionConflict) {
+		t.Errorf("Ожидалась ошибка ErrVersionConflict, получено %v", err)
+	}
+	if _, err := repo.Patch(ctx, 1, nil, models.StandPatch{"reason": json.RawMessage(`"релиз"`)}); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	stand, _ := repo.GetStand(ctx, 1)
+	if stand.Users != "Иванов" || stand.Reason != "релиз" || stand.Version != 2 {
+		t.Errorf("Неожиданное состояние стенда: %+v", stand)
+	}
+
+	if _, err := repo.Patch(ctx, 2, nil, patch); !errors.Is(err, models.ErrStandNotFound) {
+		t.Errorf("Ожидалась ошибка ErrStandNotFound, получено %v", err)
+	}
+	if _, err := repo.Patch(ctx, 1, nil, models.StandPatch{"status": json.RawMessage(`"x"`)}); !errors.Is(err, models.ErrInvalidPatch) {
+		t.Errorf("Ожидалась ошибка ErrInvalidPatch, получено %v", err)
+	}
+}
+
+func TestStandsRepository_CreateArchive(t *testing.T) {
+	repo := NewStandsRepository([]models.Stand{{ID: 1, Name: "alpha"}, {ID: 5, Name: "beta"}})
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, models.Stand{ID: 1, Name: "gamma", Version: 7})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if created.ID != 6 || created.Name != "gamma" || created.Version != 0 {
+		t.Errorf("Ожидался стенд gamma с id 6 и версией 0, получено %+v", created)
+	}
+
+	version := int64(0)
+	if _, err := repo.Archive(ctx, 5, &version, time.Unix(1_700_000_000, 0)); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	stands, _ := repo.GetStands(ctx)
+	if len(stands) != 2 || stands[0].Name != "alpha" || stands[1].Name != "gamma" {
+		t.Errorf("Архивный стенд не должен возвращаться в списке: %+v", stands)
+	}
+	archived, err := repo.GetStand(ctx, 5)
+	if err != nil || !archived.IsArchived() || *archived.ArchivedAt != 1_700_000_000 || archived.Version != 1 {
+		t.Errorf("Архивный стенд должен быть доступен по id, получено %+v, ошибка %v", archived, err)
+	}
+	if _, err := repo.Patch(ctx, 5, nil, models.StandPatch{"users": json.RawMessage(`"Иванов"`)}); !errors.Is(err, models.ErrStandArchived) {
+		t.Errorf("Ожидалась ошибка ErrStandArchived, получено %v", err)
+	}
+
+	// id архивных стендов не переиспользуются.
+	next, _ := repo.Create(ctx, models.Stand{Name: "delta"})
+	if next.ID != 7 {
+		t.Errorf("Ожидался id 7, получено %d", next.ID)
+	}
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/repository/sqlstore"
+)
+
+// NewStandsRepository подключается к PostgreSQL и применяет миграции.
+func NewStandsRepository(ctx context.Context, cfg *config.PostgresConfig) (*sqlstore.StandsRepository, error) {
+	db, err := Open(ctx, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := sqlstore.NewStandsRepository(ctx, db, sqlstore.PostgreSQL)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Open подключается к PostgreSQL по dsn и проверяет подключение.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к PostgreSQL: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка подключения к PostgreSQL: %w", err)
+	}
+	return db, nil
+}
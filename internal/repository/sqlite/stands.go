@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/repository/sqlstore"
+)
+
+// NewStandsRepository открывает файл базы SQLite, создает схему и применяет миграции.
+// Если задан cfg.SeedFile и таблица стендов пуста, она заполняется стендами из файла.
+// Подходит для развертывания в один экземпляр с локальным файлом данных.
+func NewStandsRepository(ctx context.Context, cfg *config.SQLiteConfig) (*sqlstore.StandsRepository, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("ошибка создания каталога базы SQLite: %w", err)
+		}
+	}
+
+	db, err := Open(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := sqlstore.NewStandsRepository(ctx, db, sqlstore.SQLite)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if cfg.SeedFile != "" {
+		if err := repo.Seed(ctx, cfg.SeedFile); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+
+	return repo, nil
+}
+
+// Open открывает файл базы SQLite с настройками, которые ожидает sqlstore.StandsRepository.
+func Open(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия базы SQLite: %w", err)
+	}
+	// SQLite допускает одного писателя: единственное соединение сериализует транзакции
+	// и исключает ошибки SQLITE_BUSY при параллельных бронированиях.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
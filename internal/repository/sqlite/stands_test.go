@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+func TestStandsRepository_Reopen(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.SQLiteConfig{
+		Path:     filepath.Join(t.TempDir(), "data", "booking.db"),
+		SeedFile: "../../../configs/stands.sample.json",
+	}
+
+	repo, err := NewStandsRepository(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Не удалось открыть базу: %v", err)
+	}
+	seeded, err := repo.GetStands(ctx)
+	if err != nil || len(seeded) == 0 {
+		t.Fatalf("Ожидались стенды из файла, получено %d, ошибка %v", len(seeded), err)
+	}
+	patch := models.StandPatch{"users": json.RawMessage(`"Иванов"`), "backBranch": json.RawMessage(`"release"`)}
+	if _, err := repo.Patch(ctx, 41, nil, patch); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	repo.Close()
+
+	// После перезапуска данные сохраняются, а повторное заполнение из файла не выполняется.
+	reopened, err := NewStandsRepository(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Не удалось повторно открыть базу: %v", err)
+	}
+	defer reopened.Close()
+
+	stands, _ := reopened.GetStands(ctx)
+	if len(stands) != len(seeded) {
+		t.Errorf("Ожидалось %d стендов после перезапуска, получено %d", len(seeded), len(stands))
+	}
+	stand, err := reopened.GetStand(ctx, 41)
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if stand.Users != "Иванов" || *stand.Deployments["back"].Branch != "release" {
+		t.Errorf("Неожиданное состояние стенда после перезапуска: %+v", stand)
+	}
+}
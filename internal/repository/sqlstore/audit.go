@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mts/booking_service/internal/models"
+)
+
+// AuditStore хранит журнал изменений стендов в таблице audit_log той же базы.
+type AuditStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// AuditStore возвращает журнал изменений, использующий подключение репозитория.
+func (r *StandsRepository) AuditStore() *AuditStore {
+	return &AuditStore{db: r.db, dialect: r.dialect}
+}
+
+// Append добавляет запись в журнал.
+func (s *AuditStore) Append(ctx context.Context, entry models.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации записи журнала изменений: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx,
+		s.dialect.bind(`INSERT INTO audit_log ("time", "actor", "standId", "transport", "changes") VALUES (?, ?, ?, ?, ?`+s.dialect.jsonParam+`)`),
+		entry.Time, entry.Actor, entry.StandID, entry.Transport, string(changes),
+	); err != nil {
+		return fmt.Errorf("%w: ошибка записи в журнал изменений %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+	}
+	return nil
+}
+
+// Query возвращает записи журнала, подходящие под фильтр, в порядке добавления.
+func (s *AuditStore) Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	if filter.StandID != nil {
+		conditions = append(conditions, `"standId" = ?`)
+		args = append(args, *filter.StandID)
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, `"actor" = ?`)
+		args = append(args, filter.Actor)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, `"time" >= ?`)
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, `"time" <= ?`)
+		args = append(args, *filter.To)
+	}
+
+	query := `SELECT "id", "time", "actor", "standId", "transport", "changes"` + s.dialect.jsonText + ` FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.bind(query+` ORDER BY "id"`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка чтения журнала изменений %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var changes string
+		if err := rows.Scan(&entry.ID, &entry.Time, &entry.Actor, &entry.StandID, &entry.Transport, &changes); err != nil {
+			return nil, fmt.Errorf("%w: ошибка чтения журнала изменений %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+		}
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, fmt.Errorf("ошибка разбора записи журнала изменений %d: %w", entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: ошибка чтения журнала изменений %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,41 @@
+package sqlstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/repository/sqlstore"
+)
+
+func TestAuditStore(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo *sqlstore.StandsRepository) {
+		ctx := context.Background()
+		store := repo.AuditStore()
+
+		changes := map[string]models.FieldChange{"users": {Before: json.RawMessage(`""`), After: json.RawMessage(`"Иванов"`)}}
+		for _, entry := range []models.AuditEntry{
+			{Time: 100, Actor: "Иванов", StandID: 41, Transport: models.TransportWS, Changes: changes},
+			{Time: 200, Actor: "Петров", StandID: 42, Transport: models.TransportREST, Changes: changes},
+		} {
+			if err := store.Append(ctx, entry); err != nil {
+				t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+			}
+		}
+
+		standID, from := int64(41), int64(50)
+		entries, err := store.Query(ctx, models.AuditFilter{StandID: &standID, Actor: "Иванов", From: &from})
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("Ожидалась одна запись, получено %+v, %v", entries, err)
+		}
+		if entries[0].ID != 1 || entries[0].Transport != models.TransportWS || string(entries[0].Changes["users"].After) != `"Иванов"` {
+			t.Errorf("Запись журнала прочитана неверно: %+v", entries[0])
+		}
+
+		to := int64(150)
+		if entries, _ := store.Query(ctx, models.AuditFilter{To: &to}); len(entries) != 1 || entries[0].Actor != "Иванов" {
+			t.Errorf("Ожидалась одна запись до момента 150, получено %+v", entries)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mts/booking_service/internal/models"
+)
+
+// Dialect описывает различия SQL-баз, с которыми работает StandsRepository. Запросы
+// пишутся с параметрами "?", а диалект переводит их в синтаксис своей базы.
+type Dialect struct {
+	// Name - название базы для сообщений об ошибках.
+	Name string
+	// numbered означает параметры вида $1, $2 вместо "?".
+	numbered bool
+	// jsonObject - функция, собирающая JSON-объект из пар ключ-значение.
+	jsonObject string
+	// lockRow дописывается к выборке стенда, который изменяется в той же транзакции.
+	lockRow string
+	// lockTable исключает одинаковые id у стендов, создаваемых параллельно. Пустая
+	// строка - база и так выполняет транзакции по одной.
+	lockTable string
+	// jsonParam и jsonText приводят значение к JSON-колонке и JSON-колонку к тексту.
+	jsonParam string
+	jsonText  string
+	// migrations - каталог миграций диалекта, migrationsTable - таблица примененных миграций,
+	// lockMigrations - блокировка от одновременного применения миграций несколькими экземплярами.
+	migrations      string
+	migrationsTable string
+	lockMigrations  string
+}
+
+// SQLite - диалект встроенной базы SQLite.
+var SQLite = Dialect{
+	Name:       "SQLite",
+	jsonObject: "json_object",
+	migrations: "migrations/sqlite",
+	migrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+// PostgreSQL - диалект PostgreSQL.
+var PostgreSQL = Dialect{
+	Name:       "PostgreSQL",
+	numbered:   true,
+	jsonObject: "json_build_object",
+	lockRow:    " FOR UPDATE",
+	lockTable:  `LOCK TABLE stands IN SHARE ROW EXCLUSIVE MODE`,
+	jsonParam:  "::jsonb",
+	jsonText:   "::text",
+	migrations: "migrations/postgres",
+	migrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version text PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`,
+	// Ключ advisory-блокировки, не дающей нескольким экземплярам сервиса применять миграции одновременно.
+	lockMigrations: `SELECT pg_advisory_xact_lock(7256091)`,
+}
+
+// bind переводит параметры "?" запроса в синтаксис диалекта.
+func (d Dialect) bind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// standJSON возвращает выражение, собирающее строку таблицы stands в JSON-объект стенда
+// без развертываний, которые хранятся в таблице stand_deployments.
+func (d Dialect) standJSON() string {
+	var pairs []string
+	for _, field := range models.BaseFields() {
+		pairs = append(pairs, fmt.Sprintf(`'%s', %q`, field, field))
+	}
+	expr := d.jsonObject + `(` + strings.Join(pairs, ", ") + `)`
+	if d.jsonText != "" {
+		expr = `(` + expr + `)` + d.jsonText
+	}
+	return expr
+}
@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"mts/booking_service/internal/models"
+)
+
+// HistoryStore хранит историю бронирований в таблице booking_history той же базы.
+type HistoryStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// HistoryStore возвращает историю бронирований, использующую подключение репозитория.
+func (r *StandsRepository) HistoryStore() *HistoryStore {
+	return &HistoryStore{db: r.db, dialect: r.dialect}
+}
+
+// SaveInterval добавляет новый период бронирования, если его id равен 0, иначе обновляет сохраненный.
+func (s *HistoryStore) SaveInterval(ctx context.Context, interval models.BookingInterval) (models.BookingInterval, error) {
+	if interval.ID != 0 {
+		if _, err := s.db.ExecContext(ctx,
+			s.dialect.bind(`UPDATE booking_history SET "user" = ?, "start" = ?, "end" = ?, "peakQueue" = ? WHERE "id" = ?`),
+			interval.User, interval.Start, interval.End, interval.PeakQueue, interval.ID,
+		); err != nil {
+			return interval, fmt.Errorf("%w: ошибка обновления истории бронирований %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+		}
+		return interval, nil
+	}
+
+	if err := s.db.QueryRowContext(ctx,
+		s.dialect.bind(`INSERT INTO booking_history ("standId", "user", "start", "end", "peakQueue") VALUES (?, ?, ?, ?, ?) RETURNING "id"`),
+		interval.StandID, interval.User, interval.Start, interval.End, interval.PeakQueue,
+	).Scan(&interval.ID); err != nil {
+		return interval, fmt.Errorf("%w: ошибка записи истории бронирований %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+	}
+	return interval, nil
+}
+
+// Intervals возвращает периоды бронирования, пересекающиеся с [from, to].
+func (s *HistoryStore) Intervals(ctx context.Context, from, to int64) ([]models.BookingInterval, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.bind(
+		`SELECT "id", "standId", "user", "start", "end", "peakQueue" FROM booking_history
+		WHERE "start" <= ? AND "end" >= ? ORDER BY "id"`), to, from)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка чтения истории бронирований %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+	}
+	defer rows.Close()
+
+	intervals := []models.BookingInterval{}
+	for rows.Next() {
+		var interval models.BookingInterval
+		if err := rows.Scan(&interval.ID, &interval.StandID, &interval.User, &interval.Start, &interval.End, &interval.PeakQueue); err != nil {
+			return nil, fmt.Errorf("%w: ошибка чтения истории бронирований %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+		}
+		intervals = append(intervals, interval)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: ошибка чтения истории бронирований %s: %w", models.ErrStorageUnavailable, s.dialect.Name, err)
+	}
+	return intervals, nil
+}
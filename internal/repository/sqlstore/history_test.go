@@ -0,0 +1,39 @@
+package sqlstore_test
+
+import (
+	"context"
+	"testing"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/repository/sqlstore"
+)
+
+func TestHistoryStore(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo *sqlstore.StandsRepository) {
+		ctx := context.Background()
+		store := repo.HistoryStore()
+
+		interval, err := store.SaveInterval(ctx, models.BookingInterval{StandID: 41, User: "Иванов", Start: 100, End: 500})
+		if err != nil || interval.ID == 0 {
+			t.Fatalf("Ожидался сохраненный период с id, получено %+v, %v", interval, err)
+		}
+		interval.End, interval.PeakQueue = 300, 2
+		if _, err := store.SaveInterval(ctx, interval); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if _, err := store.SaveInterval(ctx, models.BookingInterval{StandID: 42, User: "Петров", Start: 1000, End: 2000}); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		intervals, err := store.Intervals(ctx, 200, 900)
+		if err != nil || len(intervals) != 1 {
+			t.Fatalf("Ожидался один период, получено %+v, %v", intervals, err)
+		}
+		if intervals[0] != interval {
+			t.Errorf("Ожидался период %+v, получено %+v", interval, intervals[0])
+		}
+		if intervals, _ := store.Intervals(ctx, 400, 900); len(intervals) != 0 {
+			t.Errorf("Ожидалось отсутствие периодов после обновления, получено %+v", intervals)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Миграции каждого диалекта лежат в своем каталоге, но набор версий у них общий:
+// схема всех баз одинакова, отличаются только типы колонок.
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+// Migrate создает схему и применяет к базе еще не примененные миграции диалекта.
+// Миграции применяются по порядку имен файлов в одной транзакции.
+func Migrate(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	names, err := fs.Glob(migrationsFS, dialect.migrations+"/*.sql")
+	if err != nil {
+		return fmt.Errorf("ошибка чтения списка миграций: %w", err)
+	}
+	sort.Strings(names)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции миграций: %w", err)
+	}
+	defer tx.Rollback()
+
+	if dialect.lockMigrations != "" {
+		if _, err := tx.ExecContext(ctx, dialect.lockMigrations); err != nil {
+			return fmt.Errorf("ошибка блокировки миграций: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, dialect.migrationsTable); err != nil {
+		return fmt.Errorf("ошибка создания таблицы миграций: %w", err)
+	}
+
+	for _, name := range names {
+		version := strings.TrimSuffix(path.Base(name), ".sql")
+
+		var applied bool
+		if err := tx.QueryRowContext(ctx, dialect.bind(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`), version).Scan(&applied); err != nil {
+			return fmt.Errorf("ошибка проверки миграции %s: %w", version, err)
+		}
+		if applied {
+			continue
+		}
+
+		script, err := migrationsFS.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения миграции %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+			return fmt.Errorf("ошибка применения миграции %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, dialect.bind(`INSERT INTO schema_migrations (version) VALUES (?)`), version); err != nil {
+			return fmt.Errorf("ошибка записи миграции %s: %w", version, err)
+		}
+		log.Printf("Применена миграция %s", version)
+	}
+
+	return tx.Commit()
+}
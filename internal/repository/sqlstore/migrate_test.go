@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMigrate_SameVersions(t *testing.T) {
+	versions := func(dialect Dialect) []string {
+		names, err := fs.Glob(migrationsFS, dialect.migrations+"/*.sql")
+		if err != nil || len(names) == 0 {
+			t.Fatalf("Не найдены миграции %s: %v", dialect.Name, err)
+		}
+		for i, name := range names {
+			names[i] = path.Base(name)
+		}
+		return names
+	}
+
+	// Схема всех баз одинакова: миграция, добавленная только для одной базы, - ошибка.
+	if sqlite, postgres := versions(SQLite), versions(PostgreSQL); !slices.Equal(sqlite, postgres) {
+		t.Errorf("Миграции SQLite %v не совпадают с миграциями PostgreSQL %v", sqlite, postgres)
+	}
+}
+
+func TestMigrate_StandDeployments(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatalf("Не удалось открыть базу: %v", err)
+	}
+	defer db.Close()
+
+	// База в прежней схеме: развертывания хранятся в колонках таблицы stands.
+	if _, err := db.ExecContext(ctx, SQLite.migrationsTable); err != nil {
+		t.Fatalf("Не удалось создать таблицу миграций: %v", err)
+	}
+	for _, version := range []string{"0001_create_stands", "0002_create_audit_log", "0003_create_booking_history"} {
+		script, err := migrationsFS.ReadFile(SQLite.migrations + "/" + version + ".sql")
+		if err != nil {
+			t.Fatalf("Не удалось прочитать миграцию %s: %v", version, err)
+		}
+		if _, err := db.ExecContext(ctx, string(script)); err != nil {
+			t.Fatalf("Не удалось применить миграцию %s: %v", version, err)
+		}
+		db.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO stands ("id", "name", "frontBranch", "frontDeploymentDate", "omDeploymentUser")
+		VALUES (41, 'alpha', 'feature/login', 1700000000, 'Иванов')`); err != nil {
+		t.Fatalf("Не удалось добавить стенд: %v", err)
+	}
+
+	repo, err := NewStandsRepository(ctx, db, SQLite)
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	stand, err := repo.GetStand(ctx, 41)
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	front, om := stand.Deployments["front"], stand.Deployments["om"]
+	if front.Branch == nil || *front.Branch != "feature/login" || front.Date == nil || *front.Date != 1_700_000_000 || front.User != nil {
+		t.Errorf("Неожиданное развертывание front: %+v", front)
+	}
+	if om.User == nil || *om.User != "Иванов" || om.Branch != nil {
+		t.Errorf("Неожиданное развертывание om: %+v", om)
+	}
+	if _, ok := stand.Deployments["back"]; ok {
+		t.Errorf("Пустое развертывание back не должно переноситься: %+v", stand.Deployments)
+	}
+}
+
+func TestDialect_Bind(t *testing.T) {
+	query := `UPDATE stands SET "users" = ? WHERE "id" = ?`
+	if bound := SQLite.bind(query); bound != query {
+		t.Errorf("SQLite: ожидался запрос без изменений, получено %s", bound)
+	}
+	if bound := PostgreSQL.bind(query); bound != `UPDATE stands SET "users" = $1 WHERE "id" = $2` {
+		t.Errorf("PostgreSQL: неожиданный запрос %s", bound)
+	}
+}
@@ -0,0 +1,39 @@
+-- Таблица стендов с тем же набором колонок, что и в Supabase.
+CREATE TABLE IF NOT EXISTS stands (
+    "id" bigserial PRIMARY KEY,
+    "name" text NOT NULL,
+    "endDate" bigint NOT NULL DEFAULT 0,
+    "users" text NOT NULL DEFAULT '',
+    "reason" text NOT NULL DEFAULT '',
+    "comment" text NOT NULL DEFAULT '',
+    "standLink" text,
+    "frontBranch" text,
+    "frontDeploymentDate" bigint,
+    "frontDeploymentUser" text,
+    "backBranch" text,
+    "backDeploymentDate" bigint,
+    "backDeploymentUser" text,
+    "omBranch" text,
+    "omDeploymentDate" bigint,
+    "omDeploymentUser" text,
+    "dbUpdateDate" bigint,
+    "apigwBranch" text,
+    "apigwDeploymentDate" bigint,
+    "apigwDeploymentUser" text,
+    "cartBranch" text,
+    "cartDeploymentDate" bigint,
+    "cartDeploymentUser" text,
+    "paymentsBranch" text,
+    "paymentsDeploymentDate" bigint,
+    "paymentsDeploymentUser" text,
+    "reviewsBranch" text,
+    "reviewsDeploymentDate" bigint,
+    "reviewsDeploymentUser" text,
+    "lkstorageBranch" text,
+    "lkstorageDeploymentDate" bigint,
+    "lkstorageDeploymentUser" text,
+    "lkordersBranch" text,
+    "lkordersDeploymentDate" bigint,
+    "lkordersDeploymentUser" text,
+    "version" bigint NOT NULL DEFAULT 0
+);
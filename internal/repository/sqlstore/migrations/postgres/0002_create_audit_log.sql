@@ -0,0 +1,12 @@
+-- Журнал изменений стендов. Записи только добавляются.
+CREATE TABLE IF NOT EXISTS audit_log (
+    "id" bigserial PRIMARY KEY,
+    "time" bigint NOT NULL,
+    "actor" text NOT NULL DEFAULT '',
+    "standId" bigint NOT NULL,
+    "transport" text NOT NULL,
+    "changes" jsonb NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_stand_time ON audit_log ("standId", "time");
+CREATE INDEX IF NOT EXISTS audit_log_actor_time ON audit_log ("actor", "time");
@@ -0,0 +1,11 @@
+-- История бронирований стендов для отчетов об использовании.
+CREATE TABLE IF NOT EXISTS booking_history (
+    "id" bigserial PRIMARY KEY,
+    "standId" bigint NOT NULL,
+    "user" text NOT NULL,
+    "start" bigint NOT NULL,
+    "end" bigint NOT NULL,
+    "peakQueue" integer NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS booking_history_period ON booking_history ("start", "end");
@@ -0,0 +1,68 @@
+-- Развертывания компонентов стендов в нормализованном виде: компоненты задаются
+-- реестром в конфигурации, и новый компонент не требует новых колонок в stands.
+CREATE TABLE IF NOT EXISTS stand_deployments (
+    "standId" bigint NOT NULL REFERENCES stands ("id") ON DELETE CASCADE,
+    "component" text NOT NULL,
+    "branch" text,
+    "date" bigint,
+    "user" text,
+    PRIMARY KEY ("standId", "component")
+);
+
+-- Перенос развертываний из колонок прежней схемы, совпадающей со схемой Supabase.
+INSERT INTO stand_deployments ("standId", "component", "branch", "date", "user")
+SELECT "id", 'front', "frontBranch", "frontDeploymentDate", "frontDeploymentUser" FROM stands
+    WHERE "frontBranch" IS NOT NULL OR "frontDeploymentDate" IS NOT NULL OR "frontDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'back', "backBranch", "backDeploymentDate", "backDeploymentUser" FROM stands
+    WHERE "backBranch" IS NOT NULL OR "backDeploymentDate" IS NOT NULL OR "backDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'om', "omBranch", "omDeploymentDate", "omDeploymentUser" FROM stands
+    WHERE "omBranch" IS NOT NULL OR "omDeploymentDate" IS NOT NULL OR "omDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'apigw', "apigwBranch", "apigwDeploymentDate", "apigwDeploymentUser" FROM stands
+    WHERE "apigwBranch" IS NOT NULL OR "apigwDeploymentDate" IS NOT NULL OR "apigwDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'cart', "cartBranch", "cartDeploymentDate", "cartDeploymentUser" FROM stands
+    WHERE "cartBranch" IS NOT NULL OR "cartDeploymentDate" IS NOT NULL OR "cartDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'payments', "paymentsBranch", "paymentsDeploymentDate", "paymentsDeploymentUser" FROM stands
+    WHERE "paymentsBranch" IS NOT NULL OR "paymentsDeploymentDate" IS NOT NULL OR "paymentsDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'reviews', "reviewsBranch", "reviewsDeploymentDate", "reviewsDeploymentUser" FROM stands
+    WHERE "reviewsBranch" IS NOT NULL OR "reviewsDeploymentDate" IS NOT NULL OR "reviewsDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'lkstorage', "lkstorageBranch", "lkstorageDeploymentDate", "lkstorageDeploymentUser" FROM stands
+    WHERE "lkstorageBranch" IS NOT NULL OR "lkstorageDeploymentDate" IS NOT NULL OR "lkstorageDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'lkorders', "lkordersBranch", "lkordersDeploymentDate", "lkordersDeploymentUser" FROM stands
+    WHERE "lkordersBranch" IS NOT NULL OR "lkordersDeploymentDate" IS NOT NULL OR "lkordersDeploymentUser" IS NOT NULL;
+
+ALTER TABLE stands
+    DROP COLUMN IF EXISTS "frontBranch",
+    DROP COLUMN IF EXISTS "frontDeploymentDate",
+    DROP COLUMN IF EXISTS "frontDeploymentUser",
+    DROP COLUMN IF EXISTS "backBranch",
+    DROP COLUMN IF EXISTS "backDeploymentDate",
+    DROP COLUMN IF EXISTS "backDeploymentUser",
+    DROP COLUMN IF EXISTS "omBranch",
+    DROP COLUMN IF EXISTS "omDeploymentDate",
+    DROP COLUMN IF EXISTS "omDeploymentUser",
+    DROP COLUMN IF EXISTS "apigwBranch",
+    DROP COLUMN IF EXISTS "apigwDeploymentDate",
+    DROP COLUMN IF EXISTS "apigwDeploymentUser",
+    DROP COLUMN IF EXISTS "cartBranch",
+    DROP COLUMN IF EXISTS "cartDeploymentDate",
+    DROP COLUMN IF EXISTS "cartDeploymentUser",
+    DROP COLUMN IF EXISTS "paymentsBranch",
+    DROP COLUMN IF EXISTS "paymentsDeploymentDate",
+    DROP COLUMN IF EXISTS "paymentsDeploymentUser",
+    DROP COLUMN IF EXISTS "reviewsBranch",
+    DROP COLUMN IF EXISTS "reviewsDeploymentDate",
+    DROP COLUMN IF EXISTS "reviewsDeploymentUser",
+    DROP COLUMN IF EXISTS "lkstorageBranch",
+    DROP COLUMN IF EXISTS "lkstorageDeploymentDate",
+    DROP COLUMN IF EXISTS "lkstorageDeploymentUser",
+    DROP COLUMN IF EXISTS "lkordersBranch",
+    DROP COLUMN IF EXISTS "lkordersDeploymentDate",
+    DROP COLUMN IF EXISTS "lkordersDeploymentUser";
@@ -0,0 +1,2 @@
+-- Время переноса стенда в архив. Архивные стенды скрыты с досок, но остаются для истории.
+ALTER TABLE stands ADD COLUMN IF NOT EXISTS "archivedAt" bigint;
@@ -0,0 +1,39 @@
+-- Таблица стендов с тем же набором колонок, что и в Supabase.
+CREATE TABLE IF NOT EXISTS stands (
+    "id" INTEGER PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "endDate" INTEGER NOT NULL DEFAULT 0,
+    "users" TEXT NOT NULL DEFAULT '',
+    "reason" TEXT NOT NULL DEFAULT '',
+    "comment" TEXT NOT NULL DEFAULT '',
+    "standLink" TEXT,
+    "frontBranch" TEXT,
+    "frontDeploymentDate" INTEGER,
+    "frontDeploymentUser" TEXT,
+    "backBranch" TEXT,
+    "backDeploymentDate" INTEGER,
+    "backDeploymentUser" TEXT,
+    "omBranch" TEXT,
+    "omDeploymentDate" INTEGER,
+    "omDeploymentUser" TEXT,
+    "dbUpdateDate" INTEGER,
+    "apigwBranch" TEXT,
+    "apigwDeploymentDate" INTEGER,
+    "apigwDeploymentUser" TEXT,
+    "cartBranch" TEXT,
+    "cartDeploymentDate" INTEGER,
+    "cartDeploymentUser" TEXT,
+    "paymentsBranch" TEXT,
+    "paymentsDeploymentDate" INTEGER,
+    "paymentsDeploymentUser" TEXT,
+    "reviewsBranch" TEXT,
+    "reviewsDeploymentDate" INTEGER,
+    "reviewsDeploymentUser" TEXT,
+    "lkstorageBranch" TEXT,
+    "lkstorageDeploymentDate" INTEGER,
+    "lkstorageDeploymentUser" TEXT,
+    "lkordersBranch" TEXT,
+    "lkordersDeploymentDate" INTEGER,
+    "lkordersDeploymentUser" TEXT,
+    "version" INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,12 @@
+-- Журнал изменений стендов. Записи только добавляются.
+CREATE TABLE IF NOT EXISTS audit_log (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "time" INTEGER NOT NULL,
+    "actor" TEXT NOT NULL DEFAULT '',
+    "standId" INTEGER NOT NULL,
+    "transport" TEXT NOT NULL,
+    "changes" TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_stand_time ON audit_log ("standId", "time");
+CREATE INDEX IF NOT EXISTS audit_log_actor_time ON audit_log ("actor", "time");
@@ -0,0 +1,11 @@
+-- История бронирований стендов для отчетов об использовании.
+CREATE TABLE IF NOT EXISTS booking_history (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "standId" INTEGER NOT NULL,
+    "user" TEXT NOT NULL,
+    "start" INTEGER NOT NULL,
+    "end" INTEGER NOT NULL,
+    "peakQueue" INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS booking_history_period ON booking_history ("start", "end");
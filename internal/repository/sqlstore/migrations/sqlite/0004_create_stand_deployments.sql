@@ -0,0 +1,67 @@
+-- Развертывания компонентов стендов в нормализованном виде: компоненты задаются
+-- реестром в конфигурации, и новый компонент не требует новых колонок в stands.
+CREATE TABLE IF NOT EXISTS stand_deployments (
+    "standId" INTEGER NOT NULL REFERENCES stands ("id") ON DELETE CASCADE,
+    "component" TEXT NOT NULL,
+    "branch" TEXT,
+    "date" INTEGER,
+    "user" TEXT,
+    PRIMARY KEY ("standId", "component")
+);
+
+-- Перенос развертываний из колонок прежней схемы, совпадающей со схемой Supabase.
+INSERT INTO stand_deployments ("standId", "component", "branch", "date", "user")
+SELECT "id", 'front', "frontBranch", "frontDeploymentDate", "frontDeploymentUser" FROM stands
+    WHERE "frontBranch" IS NOT NULL OR "frontDeploymentDate" IS NOT NULL OR "frontDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'back', "backBranch", "backDeploymentDate", "backDeploymentUser" FROM stands
+    WHERE "backBranch" IS NOT NULL OR "backDeploymentDate" IS NOT NULL OR "backDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'om', "omBranch", "omDeploymentDate", "omDeploymentUser" FROM stands
+    WHERE "omBranch" IS NOT NULL OR "omDeploymentDate" IS NOT NULL OR "omDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'apigw', "apigwBranch", "apigwDeploymentDate", "apigwDeploymentUser" FROM stands
+    WHERE "apigwBranch" IS NOT NULL OR "apigwDeploymentDate" IS NOT NULL OR "apigwDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'cart', "cartBranch", "cartDeploymentDate", "cartDeploymentUser" FROM stands
+    WHERE "cartBranch" IS NOT NULL OR "cartDeploymentDate" IS NOT NULL OR "cartDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'payments', "paymentsBranch", "paymentsDeploymentDate", "paymentsDeploymentUser" FROM stands
+    WHERE "paymentsBranch" IS NOT NULL OR "paymentsDeploymentDate" IS NOT NULL OR "paymentsDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'reviews', "reviewsBranch", "reviewsDeploymentDate", "reviewsDeploymentUser" FROM stands
+    WHERE "reviewsBranch" IS NOT NULL OR "reviewsDeploymentDate" IS NOT NULL OR "reviewsDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'lkstorage', "lkstorageBranch", "lkstorageDeploymentDate", "lkstorageDeploymentUser" FROM stands
+    WHERE "lkstorageBranch" IS NOT NULL OR "lkstorageDeploymentDate" IS NOT NULL OR "lkstorageDeploymentUser" IS NOT NULL
+UNION ALL
+SELECT "id", 'lkorders', "lkordersBranch", "lkordersDeploymentDate", "lkordersDeploymentUser" FROM stands
+    WHERE "lkordersBranch" IS NOT NULL OR "lkordersDeploymentDate" IS NOT NULL OR "lkordersDeploymentUser" IS NOT NULL;
+
+ALTER TABLE stands DROP COLUMN "frontBranch";
+ALTER TABLE stands DROP COLUMN "frontDeploymentDate";
+ALTER TABLE stands DROP COLUMN "frontDeploymentUser";
+ALTER TABLE stands DROP COLUMN "backBranch";
+ALTER TABLE stands DROP COLUMN "backDeploymentDate";
+ALTER TABLE stands DROP COLUMN "backDeploymentUser";
+ALTER TABLE stands DROP COLUMN "omBranch";
+ALTER TABLE stands DROP COLUMN "omDeploymentDate";
+ALTER TABLE stands DROP COLUMN "omDeploymentUser";
+ALTER TABLE stands DROP COLUMN "apigwBranch";
+ALTER TABLE stands DROP COLUMN "apigwDeploymentDate";
+ALTER TABLE stands DROP COLUMN "apigwDeploymentUser";
+ALTER TABLE stands DROP COLUMN "cartBranch";
+ALTER TABLE stands DROP COLUMN "cartDeploymentDate";
+ALTER TABLE stands DROP COLUMN "cartDeploymentUser";
+ALTER TABLE stands DROP COLUMN "paymentsBranch";
+ALTER TABLE stands DROP COLUMN "paymentsDeploymentDate";
+ALTER TABLE stands DROP COLUMN "paymentsDeploymentUser";
+ALTER TABLE stands DROP COLUMN "reviewsBranch";
+ALTER TABLE stands DROP COLUMN "reviewsDeploymentDate";
+ALTER TABLE stands DROP COLUMN "reviewsDeploymentUser";
+ALTER TABLE stands DROP COLUMN "lkstorageBranch";
+ALTER TABLE stands DROP COLUMN "lkstorageDeploymentDate";
+ALTER TABLE stands DROP COLUMN "lkstorageDeploymentUser";
+ALTER TABLE stands DROP COLUMN "lkordersBranch";
+ALTER TABLE stands DROP COLUMN "lkordersDeploymentDate";
+ALTER TABLE stands DROP COLUMN "lkordersDeploymentUser";
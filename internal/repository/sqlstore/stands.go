@@ -0,0 +1,315 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// StandsRepository хранит стенды в SQL-базе через database/sql. Основные колонки таблицы
+// stands совпадают с Supabase, развертывания компонентов хранятся отдельно, в таблице
+// stand_deployments. Различия баз описывает Dialect.
+type StandsRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewStandsRepository применяет к базе db миграции диалекта и создает репозиторий.
+// При ошибке закрыть db должен вызывающий.
+func NewStandsRepository(ctx context.Context, db *sql.DB, dialect Dialect) (*StandsRepository, error) {
+	if err := Migrate(ctx, db, dialect); err != nil {
+		return nil, err
+	}
+	return &StandsRepository{db: db, dialect: dialect}, nil
+}
+
+// Close закрывает подключение к базе.
+func (r *StandsRepository) Close() error {
+	return r.db.Close()
+}
+
+// GetStands возвращает все стенды, кроме архивных, упорядоченные по id.
+func (r *StandsRepository) GetStands(ctx context.Context) ([]models.Stand, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+r.dialect.standJSON()+` FROM stands WHERE "archivedAt" IS NULL ORDER BY "id"`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка получения стендов из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+	defer rows.Close()
+
+	stands := []models.Stand{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("%w: ошибка чтения стенда из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+		}
+		var stand models.Stand
+		if err := json.Unmarshal([]byte(data), &stand); err != nil {
+			return nil, fmt.Errorf("ошибка разбора стенда из %s: %w", r.dialect.Name, err)
+		}
+		stands = append(stands, stand)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: ошибка получения стендов из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+	if err := r.loadDeployments(ctx, r.db, stands, ""); err != nil {
+		return nil, err
+	}
+
+	return stands, nil
+}
+
+// GetStand возвращает стенд по id, в том числе архивный.
+func (r *StandsRepository) GetStand(ctx context.Context, id int64) (*models.Stand, error) {
+	return r.getStand(ctx, r.db, id, "")
+}
+
+// Patch применяет патч к стенду в транзакции: строка блокируется на время проверки
+// версии и записи, поэтому параллельные бронирования одного стенда не теряются.
+// Возвращает стенд, прочитанный после записи в той же транзакции.
+func (r *StandsRepository) Patch(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+	return r.update(ctx, id, version, func(stand models.Stand) (models.Stand, error) {
+		return stand.Apply(patch)
+	})
+}
+
+// Archive переносит стенд в архив в транзакции с проверкой версии.
+func (r *StandsRepository) Archive(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error) {
+	return r.update(ctx, id, version, func(stand models.Stand) (models.Stand, error) {
+		archivedAt := at.Unix()
+		stand.ArchivedAt = &archivedAt
+		return stand, nil
+	})
+}
+
+// Create добавляет стенд с id, следующим за максимальным, версия нового стенда - 0. Стенды
+// переносятся из Supabase с явными id, поэтому последовательность таблицы stands не используется.
+func (r *StandsRepository) Create(ctx context.Context, stand models.Stand) (*models.Stand, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка начала транзакции: %w", models.ErrStorageUnavailable, err)
+	}
+	defer tx.Rollback()
+
+	if r.dialect.lockTable != "" {
+		if _, err := tx.ExecContext(ctx, r.dialect.lockTable); err != nil {
+			return nil, fmt.Errorf("%w: ошибка блокировки таблицы стендов: %w", models.ErrStorageUnavailable, err)
+		}
+	}
+	stand.Version, stand.ArchivedAt = 0, nil
+	id, err := r.insertStand(ctx, tx, stand, false)
+	if err != nil {
+		return nil, err
+	}
+	created, err := r.getStand(ctx, tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: ошибка фиксации транзакции: %w", models.ErrStorageUnavailable, err)
+	}
+	return created, nil
+}
+
+// Seed заполняет пустую таблицу стендов из JSON-файла в формате таблицы stands с их id.
+func (r *StandsRepository) Seed(ctx context.Context, path string) error {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM stands`).Scan(&count); err != nil {
+		return fmt.Errorf("ошибка проверки таблицы стендов: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла со стендами: %w", err)
+	}
+	var stands []models.Stand
+	if err := json.Unmarshal(data, &stands); err != nil {
+		return fmt.Errorf("ошибка разбора файла со стендами %s: %w", path, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: ошибка начала транзакции: %w", models.ErrStorageUnavailable, err)
+	}
+	defer tx.Rollback()
+
+	for _, stand := range stands {
+		if _, err := r.insertStand(ctx, tx, stand, true); err != nil {
+			return fmt.Errorf("ошибка добавления стенда %s: %w", stand.Name, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// update заменяет неархивный стенд результатом change в транзакции: строка блокируется
+// на время проверки версии и записи. Возвращает стенд, прочитанный после записи.
+func (r *StandsRepository) update(ctx context.Context, id int64, version *int64, change func(models.Stand) (models.Stand, error)) (*models.Stand, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка начала транзакции: %w", models.ErrStorageUnavailable, err)
+	}
+	defer tx.Rollback()
+
+	stand, err := r.getStand(ctx, tx, id, r.dialect.lockRow)
+	if err != nil {
+		return nil, err
+	}
+	if stand.IsArchived() {
+		return nil, fmt.Errorf("%w: id %d", models.ErrStandArchived, id)
+	}
+	if version != nil && *version != stand.Version {
+		return nil, fmt.Errorf("%w: id %d, ожидаемая версия %d, текущая %d", models.ErrVersionConflict, id, *version, stand.Version)
+	}
+
+	updated, err := change(stand.Clone())
+	if err != nil {
+		return nil, err
+	}
+	updated.Version++
+
+	var assignments []string
+	var args []any
+	for _, field := range models.BaseFields() {
+		if field == "id" {
+			continue
+		}
+		value, _ := updated.Field(field)
+		assignments = append(assignments, fmt.Sprintf(`%q = ?`, field))
+		args = append(args, value)
+	}
+	args = append(args, updated.ID)
+
+	query := `UPDATE stands SET ` + strings.Join(assignments, ", ") + ` WHERE "id" = ?`
+	if _, err := tx.ExecContext(ctx, r.dialect.bind(query), args...); err != nil {
+		return nil, fmt.Errorf("%w: ошибка обновления стенда в %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+	if err := r.saveDeployments(ctx, tx, *stand, updated); err != nil {
+		return nil, err
+	}
+	saved, err := r.getStand(ctx, tx, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: ошибка фиксации транзакции: %w", models.ErrStorageUnavailable, err)
+	}
+	return saved, nil
+}
+
+// insertStand добавляет стенд с развертываниями и возвращает его id.
+// Если keepID равен false, стенду назначается id, следующий за максимальным.
+func (r *StandsRepository) insertStand(ctx context.Context, tx *sql.Tx, stand models.Stand, keepID bool) (int64, error) {
+	columns := []string{`"id"`}
+	values := []string{`(SELECT COALESCE(MAX("id"), 0) + 1 FROM stands)`}
+	var args []any
+	if keepID {
+		values[0] = "?"
+		args = append(args, stand.ID)
+	}
+	for _, field := range models.BaseFields() {
+		if field == "id" {
+			continue
+		}
+		value, _ := stand.Field(field)
+		columns = append(columns, fmt.Sprintf("%q", field))
+		values = append(values, "?")
+		args = append(args, value)
+	}
+
+	query := `INSERT INTO stands (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(values, ", ") + `) RETURNING "id"`
+	if err := tx.QueryRowContext(ctx, r.dialect.bind(query), args...).Scan(&stand.ID); err != nil {
+		return 0, fmt.Errorf("%w: ошибка добавления стенда в %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+	if err := r.saveDeployments(ctx, tx, models.Stand{}, stand); err != nil {
+		return 0, err
+	}
+	return stand.ID, nil
+}
+
+// queryer - общий интерфейс *sql.DB и *sql.Tx для чтения.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getStand читает стенд с развертываниями. lock дописывается к выборке строки стенда.
+func (r *StandsRepository) getStand(ctx context.Context, q queryer, id int64, lock string) (*models.Stand, error) {
+	var data string
+	err := q.QueryRowContext(ctx, r.dialect.bind(`SELECT `+r.dialect.standJSON()+` FROM stands WHERE "id" = ?`+lock), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: id %d", models.ErrStandNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка получения стенда из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+
+	stands := make([]models.Stand, 1)
+	if err := json.Unmarshal([]byte(data), &stands[0]); err != nil {
+		return nil, fmt.Errorf("ошибка разбора стенда из %s: %w", r.dialect.Name, err)
+	}
+	if err := r.loadDeployments(ctx, q, stands, ` WHERE "standId" = ?`, id); err != nil {
+		return nil, err
+	}
+	return &stands[0], nil
+}
+
+// loadDeployments заполняет развертывания стендов из таблицы stand_deployments по условию where.
+// Развертывания компонентов, которых нет в реестре, не читаются, но остаются в базе.
+func (r *StandsRepository) loadDeployments(ctx context.Context, q queryer, stands []models.Stand, where string, args ...any) error {
+	index := make(map[int64]*models.Stand, len(stands))
+	for i := range stands {
+		index[stands[i].ID] = &stands[i]
+	}
+
+	query := `SELECT "standId", "component", "branch", "date", "user" FROM stand_deployments` + where
+	rows, err := q.QueryContext(ctx, r.dialect.bind(query), args...)
+	if err != nil {
+		return fmt.Errorf("%w: ошибка получения развертываний из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var standID int64
+		var component string
+		var deployment models.Deployment
+		if err := rows.Scan(&standID, &component, &deployment.Branch, &deployment.Date, &deployment.User); err != nil {
+			return fmt.Errorf("%w: ошибка чтения развертывания из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+		}
+		stand, ok := index[standID]
+		if !ok || !models.IsComponent(component) {
+			continue
+		}
+		if stand.Deployments == nil {
+			stand.Deployments = make(map[string]models.Deployment)
+		}
+		stand.Deployments[component] = deployment
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%w: ошибка получения развертываний из %s: %w", models.ErrStorageUnavailable, r.dialect.Name, err)
+	}
+	return nil
+}
+
+// saveDeployments записывает развертывания компонентов, которые в after отличаются от before.
+func (r *StandsRepository) saveDeployments(ctx context.Context, tx *sql.Tx, before, after models.Stand) error {
+	query := r.dialect.bind(`INSERT INTO stand_deployments ("standId", "component", "branch", "date", "user")
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT ("standId", "component") DO UPDATE
+		SET "branch" = excluded."branch", "date" = excluded."date", "user" = excluded."user"`)
+	for _, component := range models.ChangedComponents(before, after) {
+		deployment := after.Deployments[component]
+		if _, err := tx.ExecContext(ctx, query, after.ID, component, deployment.Branch, deployment.Date, deployment.User); err != nil {
+			return fmt.Errorf("%w: ошибка записи развертывания %s в %s: %w", models.ErrStorageUnavailable, component, r.dialect.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/repository/postgres"
+	"mts/booking_service/internal/repository/sqlite"
+	"mts/booking_service/internal/repository/sqlstore"
+)
+
+// seedFile - стенды, которыми заполняется база каждого теста.
+const seedFile = "../../../configs/stands.sample.json"
+
+// forEachDialect запускает test на репозитории каждого доступного диалекта, заполненном
+// стендами из seedFile. SQLite проверяется всегда, PostgreSQL - если задан POSTGRES_TEST_DSN,
+// например для локального контейнера: docker run -e POSTGRES_PASSWORD=booking -p 5432:5432 postgres.
+func forEachDialect(t *testing.T, test func(t *testing.T, repo *sqlstore.StandsRepository)) {
+	t.Run("SQLite", func(t *testing.T) {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "booking.db"))
+		if err != nil {
+			t.Fatalf("Не удалось открыть базу: %v", err)
+		}
+		test(t, newTestRepository(t, db, sqlstore.SQLite))
+	})
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		dsn := os.Getenv("POSTGRES_TEST_DSN")
+		if dsn == "" {
+			t.Skip("POSTGRES_TEST_DSN не задан, тест PostgreSQL пропущен")
+		}
+		db, err := postgres.Open(context.Background(), dsn)
+		if err != nil {
+			t.Fatalf("Не удалось подключиться к PostgreSQL: %v", err)
+		}
+		test(t, newTestRepository(t, db, sqlstore.PostgreSQL))
+	})
+}
+
+// newTestRepository создает репозиторий на пустой базе db, заполненной стендами из seedFile.
+func newTestRepository(t *testing.T, db *sql.DB, dialect sqlstore.Dialect) *sqlstore.StandsRepository {
+	t.Helper()
+	ctx := context.Background()
+	repo, err := sqlstore.NewStandsRepository(ctx, db, dialect)
+	if err != nil {
+		db.Close()
+		t.Fatalf("Не удалось применить миграции: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	// База PostgreSQL общая для всех тестов, поэтому перед каждым тестом очищается.
+	if dialect.Name == sqlstore.PostgreSQL.Name {
+		if _, err := db.ExecContext(ctx, `TRUNCATE stands, audit_log, booking_history RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("Не удалось очистить базу: %v", err)
+		}
+	}
+	if err := repo.Seed(ctx, seedFile); err != nil {
+		t.Fatalf("Не удалось заполнить базу: %v", err)
+	}
+	return repo
+}
+
+func TestStandsRepository_Patch(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo *sqlstore.StandsRepository) {
+		ctx := context.Background()
+
+		seeded, err := repo.GetStands(ctx)
+		if err != nil || len(seeded) == 0 {
+			t.Fatalf("Ожидались стенды из файла, получено %d, ошибка %v", len(seeded), err)
+		}
+		stand, err := repo.GetStand(ctx, 41)
+		if err != nil || stand.Name != "aaazovce" || *stand.Deployments["front"].Branch != "feature/SBX-2131" {
+			t.Fatalf("Стенд загружен неверно: %+v, ошибка %v", stand, err)
+		}
+
+		version := stand.Version
+		patch := models.StandPatch{"users": json.RawMessage(`"Иванов"`), "backBranch": json.RawMessage(`"release"`)}
+		saved, err := repo.Patch(ctx, 41, &version, patch)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if saved.Users != "Иванов" || saved.Version != version+1 || *saved.Deployments["back"].Branch != "release" {
+			t.Errorf("Patch должен вернуть сохраненный стенд, получено %+v", saved)
+		}
+		if _, err := repo.Patch(ctx, 41, &version, patch); !errors.Is(err, models.ErrVersionConflict) {
+			t.Errorf("Ожидалась ошибка ErrVersionConflict, получено %v", err)
+		}
+		if _, err := repo.Patch(ctx, 404, nil, patch); !errors.Is(err, models.ErrStandNotFound) {
+			t.Errorf("Ожидалась ошибка ErrStandNotFound, получено %v", err)
+		}
+
+		stand, err = repo.GetStand(ctx, 41)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if stand.Users != "Иванов" || stand.Version != version+1 || *stand.Deployments["back"].Branch != "release" || *stand.Deployments["front"].Branch != "feature/SBX-2131" {
+			t.Errorf("Неожиданное состояние стенда: %+v", stand)
+		}
+	})
+}
+
+func TestStandsRepository_ConcurrentPatch(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo *sqlstore.StandsRepository) {
+		ctx := context.Background()
+
+		const writers = 10
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := repo.Patch(ctx, 41, nil, models.StandPatch{"comment": json.RawMessage(`"x"`)}); err != nil {
+					t.Errorf("Ожидалась ошибка nil, получено %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		stand, err := repo.GetStand(ctx, 41)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if stand.Version != writers {
+			t.Errorf("Ни одно изменение не должно потеряться: версия %d, ожидалась %d", stand.Version, writers)
+		}
+	})
+}
+
+func TestStandsRepository_RegisteredComponent(t *testing.T) {
+	t.Cleanup(func() { models.SetComponents(models.LegacyComponents) })
+	if err := models.SetComponents(append(models.Components(), "search")); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	forEachDialect(t, func(t *testing.T, repo *sqlstore.StandsRepository) {
+		ctx := context.Background()
+		patch := models.StandPatch{"deployments": json.RawMessage(`{"search": {"branch": "feature/fts", "date": 1700000000}}`)}
+		if _, err := repo.Patch(ctx, 41, nil, patch); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		stand, err := repo.GetStand(ctx, 41)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		search := stand.Deployments["search"]
+		if search.Branch == nil || *search.Branch != "feature/fts" || *stand.Deployments["front"].Branch != "feature/SBX-2131" {
+			t.Errorf("Неожиданные развертывания стенда: %+v", stand.Deployments)
+		}
+	})
+}
+
+func TestStandsRepository_CreateArchive(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, repo *sqlstore.StandsRepository) {
+		ctx := context.Background()
+		seeded, _ := repo.GetStands(ctx)
+
+		branch := "feature/login"
+		created, err := repo.Create(ctx, models.Stand{ID: 7, Name: "new-stand", Deployments: map[string]models.Deployment{"front": {Branch: &branch}}})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if created.ID <= seeded[len(seeded)-1].ID || created.Version != 0 || *created.Deployments["front"].Branch != branch {
+			t.Errorf("Неожиданный созданный стенд: %+v", created)
+		}
+
+		id := created.ID
+		if _, err := repo.Archive(ctx, id, &created.Version, time.Unix(1_700_000_000, 0)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if stands, _ := repo.GetStands(ctx); len(stands) != len(seeded) {
+			t.Errorf("Архивный стенд не должен возвращаться в списке: %d стендов, ожидалось %d", len(stands), len(seeded))
+		}
+		archived, err := repo.GetStand(ctx, id)
+		if err != nil || archived.ArchivedAt == nil || *archived.ArchivedAt != 1_700_000_000 {
+			t.Errorf("Архивный стенд должен быть доступен по id, получено %+v, ошибка %v", archived, err)
+		}
+		if _, err := repo.Archive(ctx, id, nil, time.Now()); !errors.Is(err, models.ErrStandArchived) {
+			t.Errorf("Ожидалась ошибка ErrStandArchived, получено %v", err)
+		}
+		if _, err := repo.Patch(ctx, id, nil, models.StandPatch{"users": json.RawMessage(`"Иванов"`)}); !errors.Is(err, models.ErrStandArchived) {
+			t.Errorf("Ожидалась ошибка ErrStandArchived, получено %v", err)
+		}
+	})
+}
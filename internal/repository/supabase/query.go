@@ -0,0 +1,93 @@
+package supabase
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"mts/booking_service/internal/models"
+)
+
+// QueryStands выбирает стенды по запросу фильтрами, порядком и страницей PostgREST.
+// Если запрос нельзя выразить фильтрами PostgREST, стенды отбираются после загрузки.
+func (r *StandsRepository) QueryStands(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error) {
+	params, ok := queryParams(query)
+	if !ok {
+		stands, err := r.GetStands(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		page, total := query.Apply(stands)
+		return page, total, nil
+	}
+
+	stands, header, err := r.fetch(ctx, params.Encode(), "count=exact")
+	if err != nil {
+		return nil, 0, err
+	}
+	return stands, contentRangeTotal(header.Get("Content-Range"), query.Offset+len(stands)), nil
+}
+
+// queryParams переводит запрос стендов в параметры PostgREST. Второе значение false,
+// если какой-то фильтр не выражается через колонки таблицы stands.
+func queryParams(query models.StandQuery) (url.Values, bool) {
+	params := url.Values{"select": {"*"}, "archivedAt": {"is.null"}}
+	now := strconv.FormatInt(query.Now, 10)
+
+	// Фильтры одной колонки PostgREST объединяет через AND.
+	booked := query.Status == models.StandStatusBooked || query.BookedBy != "" || query.ExpiringBefore != nil
+	if query.Status == models.StandStatusFree {
+		if booked {
+			// Противоречивые фильтры занятости проще проверить после загрузки.
+			return nil, false
+		}
+		params.Add("endDate", "lte."+now)
+	}
+	if booked {
+		params.Add("endDate", "gt."+now)
+	}
+	if query.ExpiringBefore != nil {
+		params.Add("endDate", "lt."+strconv.FormatInt(*query.ExpiringBefore, 10))
+	}
+	if query.BookedBy != "" {
+		// Пользователь сравнивается как в models.StandQuery: без учета пробелов по краям
+		// и регистра по правилам Go. ilike PostgreSQL так сравнить не может, а в колонке
+		// users встречаются значения с пробелами, поэтому стенды отбираются после загрузки.
+		return nil, false
+	}
+	for component, branch := range query.Branches {
+		params.Add(component+"Branch", "eq."+branch)
+	}
+
+	field, desc := query.SortField()
+	order := field + ".asc"
+	if desc {
+		order = field + ".desc"
+	}
+	if field != "id" {
+		order += ",id.asc"
+	}
+	params.Set("order", order)
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Offset > 0 {
+		params.Set("offset", strconv.Itoa(query.Offset))
+	}
+	return params, true
+}
+
+// contentRangeTotal возвращает общее число строк из заголовка Content-Range вида "0-9/42".
+// Если PostgREST не сообщил число строк, возвращается fallback.
+func contentRangeTotal(contentRange string, fallback int) int {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found {
+		return fallback
+	}
+	count, err := strconv.Atoi(total)
+	if err != nil {
+		return fallback
+	}
+	return count
+}
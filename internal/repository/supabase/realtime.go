@@ -0,0 +1,225 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"mts/booking_service/internal/models"
+)
+
+const (
+	// realtimeTopic - канал Supabase Realtime с изменениями таблицы stands.
+	realtimeTopic = "realtime:public:stands"
+	// defaultRealtimeHeartbeat - период heartbeat, после которого Phoenix закрывает молчащее соединение (60 с) с запасом.
+	defaultRealtimeHeartbeat = 30 * time.Second
+	// Границы паузы перед переподключением к Realtime.
+	realtimeMinBackoff = time.Second
+	realtimeMaxBackoff = 30 * time.Second
+)
+
+// phoenixMessage - сообщение протокола каналов Phoenix, на котором работает Supabase Realtime.
+type phoenixMessage struct {
+	Topic   string          `json:"topic"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Ref     *string         `json:"ref"`
+	JoinRef *string         `json:"join_ref,omitempty"`
+}
+
+// phoenixReply - payload ответа phx_reply.
+type phoenixReply struct {
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// postgresChange - payload события postgres_changes.
+type postgresChange struct {
+	Data struct {
+		Type      string       `json:"type"`
+		Table     string       `json:"table"`
+		Record    models.Stand `json:"record"`
+		OldRecord models.Stand `json:"old_record"`
+	} `json:"data"`
+}
+
+// Subscribe подписывается на изменения таблицы stands через Supabase Realtime и вызывает
+// onChange для каждого изменения. Соединение восстанавливается автоматически, после
+// переподключения onChange получает models.ChangeResync. Возвращается после отмены ctx.
+func (r *StandsRepository) Subscribe(ctx context.Context, onChange func(models.StandChange)) error {
+	backoff := realtimeMinBackoff
+	connected := false
+	for {
+		err := r.subscribeOnce(ctx, func() {
+			if connected {
+				onChange(models.StandChange{Type: models.ChangeResync})
+			}
+			connected = true
+			backoff = realtimeMinBackoff
+		}, onChange)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("Соединение с Supabase Realtime потеряно: %v. Повтор через %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, realtimeMaxBackoff)
+	}
+}
+
+// subscribeOnce подключается к Realtime, подписывается на канал и читает события до ошибки.
+// onJoined вызывается после успешной подписки.
+func (r *StandsRepository) subscribeOnce(ctx context.Context, onJoined func(), onChange func(models.StandChange)) error {
+	realtimeURL, err := r.realtimeURL()
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, realtimeURL, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к Supabase Realtime: %w", err)
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	var (
+		writeMu sync.Mutex
+		ref     int
+	)
+	send := func(topic, event string, payload any) (string, error) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+
+		ref++
+		msgRef := strconv.Itoa(ref)
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return "", err
+		}
+		msg := phoenixMessage{Topic: topic, Event: event, Payload: data, Ref: &msgRef}
+		if topic == realtimeTopic {
+			joinRef := "1"
+			msg.JoinRef = &joinRef
+		}
+		return msgRef, conn.WriteJSON(msg)
+	}
+
+	joinRef, err := send(realtimeTopic, "phx_join", map[string]any{
+		"config": map[string]any{
+			"postgres_changes": []map[string]string{
+				{"event": "*", "schema": "public", "table": "stands"},
+			},
+		},
+		"access_token": r.cfg.APIKey,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка подписки на Supabase Realtime: %w", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.realtimeHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if _, err := send("phoenix", "heartbeat", map[string]any{}); err != nil {
+					log.Printf("Ошибка отправки heartbeat в Supabase Realtime: %v", err)
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var msg phoenixMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return fmt.Errorf("ошибка чтения из Supabase Realtime: %w", err)
+		}
+
+		switch msg.Event {
+		case "phx_reply":
+			if msg.Ref == nil || *msg.Ref != joinRef {
+				continue
+			}
+			var reply phoenixReply
+			if err := json.Unmarshal(msg.Payload, &reply); err != nil {
+				return fmt.Errorf("некорректный ответ Supabase Realtime: %w", err)
+			}
+			if reply.Status != "ok" {
+				return fmt.Errorf("Supabase Realtime отклонил подписку: %s", string(reply.Response))
+			}
+			log.Println("Подписка на изменения стендов в Supabase Realtime установлена.")
+			onJoined()
+
+		case "postgres_changes":
+			change, err := parsePostgresChange(msg.Payload)
+			if err != nil {
+				log.Printf("Пропущено некорректное событие Supabase Realtime: %v", err)
+				continue
+			}
+			onChange(change)
+
+		case "phx_error", "phx_close":
+			if msg.Topic == realtimeTopic {
+				return fmt.Errorf("канал Supabase Realtime закрыт: %s %s", msg.Event, string(msg.Payload))
+			}
+		}
+	}
+}
+
+func parsePostgresChange(payload json.RawMessage) (models.StandChange, error) {
+	var change postgresChange
+	if err := json.Unmarshal(payload, &change); err != nil {
+		return models.StandChange{}, err
+	}
+
+	switch changeType := models.ChangeType(change.Data.Type); changeType {
+	case models.ChangeInsert, models.ChangeUpdate:
+		return models.StandChange{Type: changeType, Stand: change.Data.Record}, nil
+	case models.ChangeDelete:
+		return models.StandChange{Type: changeType, Stand: change.Data.OldRecord}, nil
+	default:
+		return models.StandChange{}, errors.New("неизвестный тип изменения: " + change.Data.Type)
+	}
+}
+
+// realtimeURL строит адрес WebSocket Supabase Realtime из URL проекта.
+func (r *StandsRepository) realtimeURL() (string, error) {
+	u, err := url.Parse(r.cfg.URL)
+	if err != nil {
+		return "", fmt.Errorf("некорректный URL Supabase: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/realtime/v1/websocket"
+	u.RawQuery = url.Values{"apikey": {r.cfg.APIKey}, "vsn": {"1.0.0"}}.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,122 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+// fakeRealtime эмулирует сервер Supabase Realtime: подтверждает подписку на канал stands,
+// отправляет одно изменение и закрывает первое соединение, чтобы проверить переподключение.
+type fakeRealtime struct {
+	t           *testing.T
+	connections int
+	heartbeats  chan struct{}
+}
+
+func (f *fakeRealtime) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/realtime/v1/websocket" || r.URL.Query().Get("apikey") != "key" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		f.t.Errorf("Ошибка обновления до WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	f.connections++
+
+	var join phoenixMessage
+	if err := conn.ReadJSON(&join); err != nil || join.Event != "phx_join" || join.Topic != realtimeTopic {
+		f.t.Errorf("Ожидалась подписка на %s, получено %+v, ошибка %v", realtimeTopic, join, err)
+		return
+	}
+	conn.WriteJSON(phoenixMessage{
+		Topic:   realtimeTopic,
+		Event:   "phx_reply",
+		Payload: json.RawMessage(`{"status":"ok","response":{}}`),
+		Ref:     join.Ref,
+	})
+
+	if f.connections == 1 {
+		conn.WriteJSON(phoenixMessage{
+			Topic:   realtimeTopic,
+			Event:   "postgres_changes",
+			Payload: json.RawMessage(`{"data":{"type":"UPDATE","table":"stands","record":{"id":41,"name":"alpha","users":"Иванов"}}}`),
+		})
+		// Ждем heartbeat и обрываем соединение.
+		var heartbeat phoenixMessage
+		if err := conn.ReadJSON(&heartbeat); err == nil && heartbeat.Event == "heartbeat" {
+			f.heartbeats <- struct{}{}
+		}
+		return
+	}
+
+	// Второе соединение держим открытым до закрытия клиентом.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestStandsRepository_Subscribe(t *testing.T) {
+	fake := &fakeRealtime{t: t, heartbeats: make(chan struct{}, 1)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := NewStandsRepository(&config.SupabaseConfig{URL: server.URL, APIKey: "key"})
+	repo.realtimeHeartbeat = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan models.StandChange, 10)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		repo.Subscribe(ctx, func(change models.StandChange) { changes <- change })
+	}()
+
+	expect := func(changeType models.ChangeType) models.StandChange {
+		t.Helper()
+		select {
+		case change := <-changes:
+			if change.Type != changeType {
+				t.Fatalf("Ожидалось изменение %s, получено %+v", changeType, change)
+			}
+			return change
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Не дождались изменения %s", changeType)
+		}
+		return models.StandChange{}
+	}
+
+	change := expect(models.ChangeUpdate)
+	if change.Stand.ID != 41 || change.Stand.Users != "Иванов" {
+		t.Errorf("Неожиданный стенд в изменении: %+v", change.Stand)
+	}
+
+	select {
+	case <-fake.heartbeats:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Не дождались heartbeat")
+	}
+
+	// После обрыва соединения подписка восстанавливается и просит перечитать стенды.
+	expect(models.ChangeResync)
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Subscribe не завершился после отмены контекста")
+	}
+}
@@ -3,82 +3,241 @@ package supabase
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
 )
 
 // StandsRepository представляет собой репозиторий для работы со стендами в Supabase.
 type StandsRepository struct {
-	client *http.Client
-	cfg    *config.SupabaseConfig
+	client            *http.Client
+	cfg               *config.SupabaseConfig
+	realtimeHeartbeat time.Duration
 }
 
 // NewStandsRepository создает новый экземпляр репозитория.
 func NewStandsRepository(cfg *config.SupabaseConfig) *StandsRepository {
 	return &StandsRepository{
-		client: &http.Client{},
-		cfg:    cfg,
+		client:            &http.Client{},
+		cfg:               cfg,
+		realtimeHeartbeat: defaultRealtimeHeartbeat,
 	}
 }
 
-// Patch обновляет данные о стенде в Supabase.
-func (r *StandsRepository) Patch(ctx context.Context, id string, data []byte) error {
-	reqURL := fmt.Sprintf("%s/rest/v1/stands?id=%s", r.cfg.URL, id)
+// CheckComponents проверяет, что для всех компонентов реестра в таблице stands есть колонки:
+// развертывания остальных компонентов Supabase сохранить не может.
+func CheckComponents(components []string) error {
+	var unsupported []string
+	for _, component := range components {
+		if !models.IsLegacyComponent(component) {
+			unsupported = append(unsupported, component)
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("в таблице stands Supabase нет колонок для компонентов %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, reqURL, bytes.NewBuffer(data))
+// maxPatchAttempts ограничивает число повторов Patch без ожидаемой версии при гонке с другими изменениями.
+const maxPatchAttempts = 3
+
+// Patch обновляет данные о стенде в Supabase. Если version не nil, обновление применяется
+// только к стенду с этой версией, иначе - поверх текущей версии стенда.
+func (r *StandsRepository) Patch(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+	// Поле deployments разворачивается в колонки развертываний вида frontBranch.
+	flat, err := patch.Flatten()
 	if err != nil {
-		return fmt.Errorf("ошибка создания запроса: %w", err)
+		return nil, err
 	}
+	return r.update(ctx, id, version, flat)
+}
+
+// Archive переносит стенд в архив, записывая время в колонку archivedAt.
+func (r *StandsRepository) Archive(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error) {
+	archivedAt, _ := json.Marshal(at.Unix())
+	return r.update(ctx, id, version, models.StandPatch{"archivedAt": archivedAt})
+}
 
+// Create добавляет стенд в Supabase. id назначает таблица stands, версия нового стенда - 0.
+func (r *StandsRepository) Create(ctx context.Context, stand models.Stand) (*models.Stand, error) {
+	stand.Version, stand.ArchivedAt = 0, nil
+	body := make(map[string]any)
+	for _, field := range models.Fields() {
+		if field == "id" || field == "archivedAt" {
+			continue
+		}
+		value, _ := stand.Field(field)
+		body[field] = value
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации стенда: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL+"/rest/v1/stands", bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
 	req.Header.Set("Apikey", r.cfg.APIKey)
 	req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Prefer", "resolution=merge-duplicates")
+	req.Header.Set("Prefer", "return=representation")
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("ошибка при выполнении запроса к Supabase: %w", err)
+		return nil, fmt.Errorf("%w: ошибка при выполнении запроса к Supabase: %w", models.ErrStorageUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Supabase вернул ошибку: статус %d, тело %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("%w: Supabase вернул ошибку: статус %d, тело %s", models.ErrStorageUnavailable, resp.StatusCode, string(body))
 	}
 
-	return nil
+	var created []models.Stand
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || len(created) == 0 {
+		return nil, fmt.Errorf("%w: ошибка чтения ответа от Supabase: %v", models.ErrStorageUnavailable, err)
+	}
+	return &created[0], nil
 }
 
-// GetStands получает актуальное состояние стендов из Supabase.
-func (r *StandsRepository) GetStands(ctx context.Context) ([]byte, error) {
-	reqURL := fmt.Sprintf("%s/rest/v1/stands?select=*", r.cfg.URL)
+// update применяет патч в колонках Supabase к неархивному стенду с проверкой версии
+// и возвращает обновленную строку.
+func (r *StandsRepository) update(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+	if version != nil {
+		return r.patchVersion(ctx, id, *version, patch)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	for attempt := 0; attempt < maxPatchAttempts; attempt++ {
+		stand, err := r.GetStand(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := r.patchVersion(ctx, id, stand.Version, patch)
+		if !errors.Is(err, models.ErrVersionConflict) {
+			return updated, err
+		}
+	}
+	return nil, models.ErrVersionConflict
+}
+
+// patchVersion применяет патч, если стенд не в архиве и его версия в Supabase равна version,
+// увеличивает версию и возвращает строку, которую Supabase вернул после обновления.
+func (r *StandsRepository) patchVersion(ctx context.Context, id int64, version int64, patch models.StandPatch) (*models.Stand, error) {
+	body := make(map[string]any, len(patch)+1)
+	for key, value := range patch {
+		body[key] = value
+	}
+	body["version"] = version + 1
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации патча: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/rest/v1/stands?id=eq.%d&version=eq.%d&archivedAt=is.null", r.cfg.URL, id, version)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, reqURL, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
 	req.Header.Set("Apikey", r.cfg.APIKey)
 	req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "return=representation")
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при выполнении запроса к Supabase: %w", err)
+		return nil, fmt.Errorf("%w: ошибка при выполнении запроса к Supabase: %w", models.ErrStorageUnavailable, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Supabase вернул ошибку: статус %d, тело %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("%w: Supabase вернул ошибку: статус %d, тело %s", models.ErrStorageUnavailable, resp.StatusCode, string(body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	var updated []models.Stand
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return nil, fmt.Errorf("%w: ошибка чтения ответа от Supabase: %w", models.ErrStorageUnavailable, err)
+	}
+	if len(updated) > 0 {
+		return &updated[0], nil
+	}
+
+	// Ни одна строка не обновлена: стенда нет, он в архиве или его версия уже изменилась.
+	stand, err := r.GetStand(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if stand.IsArchived() {
+		return nil, fmt.Errorf("%w: id %d", models.ErrStandArchived, id)
+	}
+	return nil, fmt.Errorf("%w: id %d, ожидаемая версия %d", models.ErrVersionConflict, id, version)
+}
+
+// GetStands получает актуальное состояние стендов из Supabase, кроме архивных.
+func (r *StandsRepository) GetStands(ctx context.Context) ([]models.Stand, error) {
+	return r.fetchStands(ctx, "select=*&archivedAt=is.null")
+}
+
+// GetStand получает стенд по id из Supabase, в том числе архивный.
+func (r *StandsRepository) GetStand(ctx context.Context, id int64) (*models.Stand, error) {
+	stands, err := r.fetchStands(ctx, fmt.Sprintf("select=*&id=eq.%d", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(stands) == 0 {
+		return nil, fmt.Errorf("%w: id %d", models.ErrStandNotFound, id)
+	}
+	return &stands[0], nil
+}
+
+func (r *StandsRepository) fetchStands(ctx context.Context, query string) ([]models.Stand, error) {
+	stands, _, err := r.fetch(ctx, query, "")
+	return stands, err
+}
+
+// fetch выполняет запрос стендов к PostgREST с заголовком Prefer, если он задан.
+func (r *StandsRepository) fetch(ctx context.Context, query, prefer string) ([]models.Stand, http.Header, error) {
+	reqURL := fmt.Sprintf("%s/rest/v1/stands?%s", r.cfg.URL, query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+
+	req.Header.Set("Apikey", r.cfg.APIKey)
+	req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+
+	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка чтения ответа от Supabase: %w", err)
+		return nil, nil, fmt.Errorf("%w: ошибка при выполнении запроса к Supabase: %w", models.ErrStorageUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	// С подсчетом строк PostgREST отвечает на запрос страницы статусом 206.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, fmt.Errorf("%w: Supabase вернул ошибку: статус %d, тело %s", models.ErrStorageUnavailable, resp.StatusCode, string(body))
+	}
+
+	var stands []models.Stand
+	if err := json.NewDecoder(resp.Body).Decode(&stands); err != nil {
+		return nil, nil, fmt.Errorf("%w: ошибка чтения ответа от Supabase: %w", models.ErrStorageUnavailable, err)
 	}
 
-	return body, nil
+	return stands, resp.Header, nil
 }
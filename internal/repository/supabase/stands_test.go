@@ -0,0 +1,299 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+// fakePostgREST эмулирует таблицу stands из одной строки с поддержкой фильтров id и version.
+type fakePostgREST struct {
+	mu    sync.Mutex
+	stand map[string]any
+}
+
+func (f *fakePostgREST) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	matches := query.Get("id") == "" || query.Get("id") == fmt.Sprintf("eq.%v", f.stand["id"])
+	if version := query.Get("version"); version != "" && version != fmt.Sprintf("eq.%v", f.stand["version"]) {
+		matches = false
+	}
+
+	rows := []map[string]any{}
+	if r.Method == http.MethodPatch && matches {
+		var patch map[string]any
+		json.NewDecoder(r.Body).Decode(&patch)
+		for key, value := range patch {
+			f.stand[key] = value
+		}
+	}
+	if matches {
+		rows = append(rows, f.stand)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
+
+func TestStandsRepository_Patch(t *testing.T) {
+	fake := &fakePostgREST{stand: map[string]any{"id": 41, "name": "alpha", "users": "", "version": 2}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := NewStandsRepository(&config.SupabaseConfig{URL: server.URL, APIKey: "key"})
+	ctx := context.Background()
+	patch := models.StandPatch{"users": json.RawMessage(`"Иванов"`)}
+
+	t.Run("обновление с актуальной версией", func(t *testing.T) {
+		version := int64(2)
+		saved, err := repo.Patch(ctx, 41, &version, patch)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if saved.Users != "Иванов" || saved.Version != 3 {
+			t.Errorf("Patch должен вернуть обновленную строку версии 3, получено %+v", saved)
+		}
+		stand, err := repo.GetStand(ctx, 41)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if stand.Users != "Иванов" || stand.Version != 3 {
+			t.Errorf("Ожидался стенд версии 3, получено %+v", stand)
+		}
+	})
+
+	t.Run("обновление с устаревшей версией", func(t *testing.T) {
+		version := int64(2)
+		_, err := repo.Patch(ctx, 41, &version, patch)
+		if !errors.Is(err, models.ErrVersionConflict) {
+			t.Errorf("Ожидалась ошибка ErrVersionConflict, получено %v", err)
+		}
+	})
+
+	t.Run("обновление без версии применяется к текущей", func(t *testing.T) {
+		if _, err := repo.Patch(ctx, 41, nil, patch); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		stand, _ := repo.GetStand(ctx, 41)
+		if stand.Version != 4 {
+			t.Errorf("Ожидалась версия 4, получено %d", stand.Version)
+		}
+	})
+
+	t.Run("развертывания пишутся в колонки прежней схемы", func(t *testing.T) {
+		deployment := models.StandPatch{"deployments": json.RawMessage(`{"front": {"branch": "feature/login", "user": "Иванов"}}`)}
+		if _, err := repo.Patch(ctx, 41, nil, deployment); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if fake.stand["frontBranch"] != "feature/login" || fake.stand["frontDeploymentUser"] != "Иванов" {
+			t.Errorf("Ожидались колонки frontBranch и frontDeploymentUser, получено %v", fake.stand)
+		}
+		if _, ok := fake.stand["deployments"]; ok {
+			t.Error("Поле deployments не должно передаваться в Supabase")
+		}
+		stand, _ := repo.GetStand(ctx, 41)
+		if front := stand.Deployments["front"]; front.Branch == nil || *front.Branch != "feature/login" {
+			t.Errorf("Неожиданное развертывание front: %+v", front)
+		}
+	})
+
+	t.Run("несуществующий стенд", func(t *testing.T) {
+		_, err := repo.Patch(ctx, 404, nil, patch)
+		if !errors.Is(err, models.ErrStandNotFound) {
+			t.Errorf("Ожидалась ошибка ErrStandNotFound, получено %v", err)
+		}
+	})
+
+}
+
+func TestStandsRepository_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream error", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	repo := NewStandsRepository(&config.SupabaseConfig{URL: server.URL, APIKey: "key"})
+	if _, err := repo.GetStands(context.Background()); !errors.Is(err, models.ErrStorageUnavailable) {
+		t.Errorf("Ожидалась ошибка ErrStorageUnavailable, получено %v", err)
+	}
+}
+
+func TestStandsRepository_CreateArchive(t *testing.T) {
+	var created map[string]any
+	var sentID bool
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.Method+" "+r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&created)
+			_, sentID = created["id"]
+			created["id"] = 42
+			json.NewEncoder(w).Encode([]map[string]any{created})
+		case http.MethodPatch:
+			json.NewEncoder(w).Encode([]map[string]any{{"id": 41, "archivedAt": 1_700_000_000}})
+		default:
+			fmt.Fprint(w, `[{"id": 41, "name": "alpha", "version": 2}]`)
+		}
+	}))
+	defer server.Close()
+
+	repo := NewStandsRepository(&config.SupabaseConfig{URL: server.URL, APIKey: "key"})
+	ctx := context.Background()
+
+	t.Run("создание стенда", func(t *testing.T) {
+		branch := "feature/login"
+		stand, err := repo.Create(ctx, models.Stand{ID: 7, Name: "beta", Deployments: map[string]models.Deployment{"front": {Branch: &branch}}})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if stand.ID != 42 || stand.Name != "beta" || *stand.Deployments["front"].Branch != branch {
+			t.Errorf("Неожиданный созданный стенд: %+v", stand)
+		}
+		if sentID || created["frontBranch"] != branch {
+			t.Errorf("Ожидались колонки прежней схемы без id, получено %v", created)
+		}
+		if _, ok := created["deployments"]; ok {
+			t.Error("Поле deployments не должно передаваться в Supabase")
+		}
+	})
+
+	t.Run("архивирование и список без архивных", func(t *testing.T) {
+		queries = nil
+		version := int64(2)
+		if _, err := repo.Archive(ctx, 41, &version, time.Unix(1_700_000_000, 0)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		repo.GetStands(ctx)
+		expected := []string{
+			"PATCH id=eq.41&version=eq.2&archivedAt=is.null",
+			"GET select=*&archivedAt=is.null",
+		}
+		if len(queries) != len(expected) || queries[0] != expected[0] || queries[1] != expected[1] {
+			t.Errorf("Ожидались запросы %v, получено %v", expected, queries)
+		}
+	})
+}
+
+func TestStandsRepository_QueryStands(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Prefer") == "count=exact" {
+			w.Header().Set("Content-Range", "10-10/12")
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		fmt.Fprint(w, `[{"id": 41, "name": "alpha", "users": "Иванов", "endDate": 1700003600, "frontBranch": "main"}]`)
+	}))
+	defer server.Close()
+
+	repo := NewStandsRepository(&config.SupabaseConfig{URL: server.URL, APIKey: "key"})
+	ctx := context.Background()
+
+	t.Run("фильтры, порядок и страница передаются в PostgREST", func(t *testing.T) {
+		queries = nil
+		expiringBefore := int64(1_700_007_200)
+		stands, total, err := repo.QueryStands(ctx, models.StandQuery{
+			Status:         models.StandStatusBooked,
+			ExpiringBefore: &expiringBefore,
+			Branches:       map[string]string{"front": "main"},
+			Sort:           "-endDate",
+			Limit:          1,
+			Offset:         10,
+			Now:            1_700_000_000,
+		})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if len(stands) != 1 || stands[0].ID != 41 || total != 12 {
+			t.Errorf("Ожидался 1 стенд из 12, получено %d из %d", len(stands), total)
+		}
+		expected := url.Values{
+			"select":      {"*"},
+			"archivedAt":  {"is.null"},
+			"endDate":     {"gt.1700000000", "lt.1700007200"},
+			"frontBranch": {"eq.main"},
+			"order":       {"endDate.desc,id.asc"},
+			"limit":       {"1"},
+			"offset":      {"10"},
+		}
+		if len(queries) != 1 || queries[0].Encode() != expected.Encode() {
+			t.Errorf("Ожидался запрос %v, получено %v", expected, queries)
+		}
+	})
+}
+
+func TestStandsRepository_QueryStandsBookedBy(t *testing.T) {
+	const rows = `[
+		{"id": 41, "name": "alpha", "users": "тест ", "endDate": 1700003600},
+		{"id": 42, "name": "beta", "users": "ТЕСТ", "endDate": 1700003600},
+		{"id": 43, "name": "gamma", "users": "тестер", "endDate": 1700003600},
+		{"id": 44, "name": "delta", "users": "te_t", "endDate": 1700003600},
+		{"id": 45, "name": "epsilon", "users": "тест", "endDate": 1699990000}
+	]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, rows)
+	}))
+	defer server.Close()
+
+	var all []models.Stand
+	if err := json.Unmarshal([]byte(rows), &all); err != nil {
+		t.Fatalf("Не удалось разобрать стенды: %v", err)
+	}
+	repo := NewStandsRepository(&config.SupabaseConfig{URL: server.URL, APIKey: "key"})
+
+	for _, user := range []string{"Тест", " тест ", "te_t", "te%t", "тест*"} {
+		t.Run(user, func(t *testing.T) {
+			query := models.StandQuery{BookedBy: user, Now: 1_700_000_000}
+			stands, total, err := repo.QueryStands(context.Background(), query)
+			if err != nil {
+				t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+			}
+			// Supabase и хранилища без своего отбора должны находить одни и те же стенды.
+			expected, expectedTotal := query.Apply(slices.Clone(all))
+			if total != expectedTotal || !slices.EqualFunc(stands, expected, func(a, b models.Stand) bool { return a.ID == b.ID }) {
+				t.Errorf("Ожидались стенды %v (%d), получено %v (%d)", ids(expected), expectedTotal, ids(stands), total)
+			}
+		})
+	}
+}
+
+// ids возвращает id стендов для сообщений об ошибках.
+func ids(stands []models.Stand) []int64 {
+	result := make([]int64, 0, len(stands))
+	for _, stand := range stands {
+		result = append(result, stand.ID)
+	}
+	return result
+}
+
+func TestCheckComponents(t *testing.T) {
+	t.Run("компоненты с колонками в таблице", func(t *testing.T) {
+		if err := CheckComponents(models.LegacyComponents); err != nil {
+			t.Errorf("Ожидалась ошибка nil, получено %v", err)
+		}
+	})
+
+	t.Run("компонент без колонок в таблице", func(t *testing.T) {
+		if err := CheckComponents([]string{"front", "search"}); err == nil {
+			t.Error("Ожидалась ошибка для компонента search")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package standservice
+
+import (
+	"context"
+	"log"
+
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/models"
+)
+
+// auditSnapshot возвращает состояние стенда до изменения, если журнал изменений включен.
+func (s *StandService) auditSnapshot(ctx context.Context, id int64) *models.Stand {
+	if s.audit == nil {
+		return nil
+	}
+	stand, err := s.repo.GetStand(ctx, id)
+	if err != nil {
+		log.Printf("Ошибка получения состояния стенда %d для журнала изменений: %v", id, err)
+		return nil
+	}
+	return stand
+}
+
+// recordChanges записывает в журнал поля стенда, которыми after отличается от before.
+// При создании стенда before - пустой стенд.
+func (s *StandService) recordChanges(ctx context.Context, before, after models.Stand, actor string) {
+	if s.audit == nil {
+		return
+	}
+	changes := models.Diff(before, after)
+	if len(changes) == 0 {
+		return
+	}
+
+	entry := models.AuditEntry{
+		Time:      s.now().Unix(),
+		Actor:     actor,
+		StandID:   after.ID,
+		Transport: audit.TransportFromContext(ctx),
+		Changes:   changes,
+	}
+	if err := s.audit.Append(ctx, entry); err != nil {
+		log.Printf("Ошибка записи изменения стенда %s в журнал: %v", after.Name, err)
+	}
+}
+
+// QueryAudit возвращает записи журнала изменений, подходящие под фильтр.
+// Если журнал не включен, возвращается пустой список.
+func (s *StandService) QueryAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if s.audit == nil {
+		return []models.AuditEntry{}, nil
+	}
+	return s.audit.Query(ctx, filter)
+}
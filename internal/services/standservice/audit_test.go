@@ -0,0 +1,116 @@
+package standservice
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
+)
+
+func TestStandService_Audit(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("бронирование записывается в журнал", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "", EndDate: now.Unix() - 60, Version: 7}, now)
+		store := audit.NewMemoryStore()
+		service.SetAuditStore(store)
+
+		ctx := audit.WithTransport(auth.WithIdentity(context.Background(), auth.Identity{User: "Иванов", Role: auth.RoleDeveloper}), models.TransportWS)
+		if err := service.Book(ctx, "41", "", "релиз", now.Add(time.Hour)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		entries, err := service.QueryAudit(context.Background(), models.AuditFilter{})
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("Ожидалась одна запись журнала, получено %+v, %v", entries, err)
+		}
+		entry := entries[0]
+		if entry.Actor != "Иванов" || entry.StandID != 41 || entry.Transport != models.TransportWS || entry.Time != now.Unix() {
+			t.Errorf("Неожиданная запись журнала: %+v", entry)
+		}
+		users := entry.Changes["users"]
+		if string(users.Before) != `""` || string(users.After) != `"Иванов"` {
+			t.Errorf("Неожиданное изменение поля users: %s -> %s", users.Before, users.After)
+		}
+		if _, ok := entry.Changes["name"]; ok {
+			t.Errorf("Неизмененное поле name не должно попадать в журнал: %+v", entry.Changes)
+		}
+	})
+
+	t.Run("истечение бронирования записывается от имени сервиса", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Unix() - 60, Version: 7}, now)
+		store := audit.NewMemoryStore()
+		service.SetAuditStore(store)
+
+		if _, err := service.ExpireBookings(context.Background()); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		entries, _ := store.Query(context.Background(), models.AuditFilter{})
+		if len(entries) != 1 || entries[0].Actor != models.ActorSystem || entries[0].Transport != models.TransportSystem {
+			t.Errorf("Ожидалась запись от имени сервиса, получено %+v", entries)
+		}
+	})
+
+	t.Run("неудачное изменение не записывается", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Петров", EndDate: now.Unix() + 60, Version: 7}, now)
+		store := audit.NewMemoryStore()
+		service.SetAuditStore(store)
+
+		if err := service.Book(context.Background(), "41", "Иванов", "", now.Add(time.Hour)); err == nil {
+			t.Fatal("Ожидалась ошибка бронирования занятого стенда")
+		}
+
+		if entries, _ := store.Query(context.Background(), models.AuditFilter{}); len(entries) != 0 {
+			t.Errorf("Ожидался пустой журнал, получено %+v", entries)
+		}
+	})
+	t.Run("состояние до изменения берется из версии, на которую записан патч", func(t *testing.T) {
+		stand := models.Stand{ID: 41, Name: "alpha", Comment: "исходный", Version: 7}
+		concurrent := true
+		repo := &MockRepository{
+			GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+				current := stand
+				return &current, nil
+			},
+			PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+				// Между чтением состояния и записью стенд успевает изменить другой клиент.
+				if concurrent {
+					concurrent = false
+					stand.Comment, stand.Version = "параллельный", stand.Version+1
+				}
+				if version == nil || *version != stand.Version {
+					return nil, models.ErrVersionConflict
+				}
+				updated, err := stand.Apply(patch)
+				updated.Version++
+				stand = updated
+				return &updated, err
+			},
+		}
+		service := NewStandService(repo, &MockNotifier{})
+		service.now = func() time.Time { return now }
+		store := audit.NewMemoryStore()
+		service.SetAuditStore(store)
+
+		if err := service.UpdateStand(context.Background(), "41", nil, models.StandPatch{"comment": json.RawMessage(`"новый"`)}); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		entries, _ := store.Query(context.Background(), models.AuditFilter{})
+		if len(entries) != 1 {
+			t.Fatalf("Ожидалась одна запись журнала, получено %+v", entries)
+		}
+		comment := entries[0].Changes["comment"]
+		if string(comment.Before) != `"параллельный"` || string(comment.After) != `"новый"` {
+			t.Errorf("Неожиданное изменение поля comment: %s -> %s", comment.Before, comment.After)
+		}
+		if version := entries[0].Changes["version"]; string(version.Before) != "8" || string(version.After) != "9" {
+			t.Errorf("Неожиданное изменение версии: %s -> %s", version.Before, version.After)
+		}
+	})
+}
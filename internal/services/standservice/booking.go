@@ -0,0 +1,151 @@
+package standservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+var (
+	// ErrStandOccupied возвращается при попытке забронировать занятый стенд.
+	ErrStandOccupied = errors.New("стенд уже занят")
+	// ErrStandNotBooked возвращается при попытке продлить или освободить свободный стенд.
+	ErrStandNotBooked = errors.New("стенд не забронирован")
+	// ErrNotBookingOwner возвращается, если пользователь пытается изменить чужое бронирование.
+	ErrNotBookingOwner = errors.New("бронирование принадлежит другому пользователю")
+	// ErrInvalidBooking возвращается при некорректных параметрах бронирования.
+	ErrInvalidBooking = errors.New("некорректные параметры бронирования")
+)
+
+// Book бронирует свободный стенд за пользователем user до момента until. Если на стенд есть
+// очередь, забронировать его может только первый в ней; после бронирования он покидает очередь.
+func (s *StandService) Book(ctx context.Context, id, user, reason string, until time.Time) error {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return err
+	}
+	user = actingUser(ctx, user)
+	now := s.now()
+	if strings.TrimSpace(user) == "" {
+		return fmt.Errorf("%w: не указан пользователь", ErrInvalidBooking)
+	}
+	if !until.After(now) {
+		return fmt.Errorf("%w: дата окончания бронирования уже прошла", ErrInvalidBooking)
+	}
+
+	stand, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return err
+	}
+	if stand.IsBooked(now) {
+		return fmt.Errorf("%w: %s занят пользователем %s", ErrStandOccupied, stand.Name, stand.Users)
+	}
+	// До передачи стенда очереди обработчиком истечения бронирований стенд принадлежит первому в очереди.
+	if next, ok := s.waitlist.peek(stand.ID); ok && !models.SameUser(next.User, user) {
+		return fmt.Errorf("%w: %s ожидает пользователь %s из очереди", ErrStandOccupied, stand.Name, next.User)
+	}
+
+	if err := s.patch(ctx, standID, user, &stand.Version, bookingPatch(user, reason, until.Unix(), now.Unix())); err != nil {
+		return err
+	}
+	log.Printf("Стенд %s забронирован пользователем %s до %s", stand.Name, user, until.Format(time.RFC3339))
+	if err := s.waitlist.remove(stand.ID, user); err == nil {
+		log.Printf("Пользователь %s забронировал стенд %s и убран из очереди", user, stand.Name)
+	}
+
+	return s.broadcastStand(ctx, standID)
+}
+
+// Extend продлевает бронирование стенда его владельцем до момента until.
+func (s *StandService) Extend(ctx context.Context, id, user string, until time.Time) error {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return err
+	}
+	return s.extend(ctx, standID, actingUser(ctx, user), until)
+}
+
+// extend продлевает бронирование стенда id пользователем user до момента until.
+func (s *StandService) extend(ctx context.Context, id int64, user string, until time.Time) error {
+	stand, err := s.ownedBooking(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	if until.Unix() <= stand.EndDate {
+		return fmt.Errorf("%w: новая дата окончания должна быть позже текущей", ErrInvalidBooking)
+	}
+
+	endDate, _ := json.Marshal(until.Unix())
+	if err := s.applyPatch(ctx, id, user, &stand.Version, models.StandPatch{"endDate": endDate}); err != nil {
+		return err
+	}
+	log.Printf("Бронирование стенда %s продлено пользователем %s до %s", stand.Name, user, until.Format(time.RFC3339))
+	return nil
+}
+
+// Release досрочно освобождает стенд, забронированный пользователем user.
+func (s *StandService) Release(ctx context.Context, id, user string) error {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return err
+	}
+	user = actingUser(ctx, user)
+	stand, err := s.ownedBooking(ctx, standID, user)
+	if err != nil {
+		return err
+	}
+
+	patch, next := s.handoverPatch(stand.ID, s.now().Unix())
+	if err := s.patch(ctx, standID, user, &stand.Version, patch); err != nil {
+		return err
+	}
+	log.Printf("Стенд %s освобожден пользователем %s", stand.Name, user)
+	s.completeHandover(*stand, next)
+
+	return s.broadcastStand(ctx, standID)
+}
+
+// ownedBooking возвращает стенд, если он сейчас забронирован пользователем user.
+// Руководители и администраторы могут управлять и чужими бронированиями.
+func (s *StandService) ownedBooking(ctx context.Context, id int64, user string) (*models.Stand, error) {
+	if strings.TrimSpace(user) == "" {
+		return nil, fmt.Errorf("%w: не указан пользователь", ErrInvalidBooking)
+	}
+
+	stand, err := s.repo.GetStand(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !stand.IsBooked(s.now()) {
+		return nil, fmt.Errorf("%w: %s", ErrStandNotBooked, stand.Name)
+	}
+	if !stand.IsBookedBy(user) {
+		if !canManageOthers(ctx) {
+			return nil, fmt.Errorf("%w: %s занят пользователем %s", ErrNotBookingOwner, stand.Name, stand.Users)
+		}
+		log.Printf("Пользователь %s управляет чужим бронированием стенда %s пользователя %s", user, stand.Name, stand.Users)
+	}
+	return stand, nil
+}
+
+// bookingPatch формирует патч полей бронирования стенда. В dbUpdateDate записывается время
+// at, когда стенд перешел к пользователю или освободился: от него история бронирований
+// отсчитывает начало периода.
+func bookingPatch(user, reason string, endDate, at int64) models.StandPatch {
+	users, _ := json.Marshal(user)
+	reasonValue, _ := json.Marshal(reason)
+	endDateValue, _ := json.Marshal(endDate)
+	atValue, _ := json.Marshal(at)
+	return models.StandPatch{
+		"users":        users,
+		"reason":       reasonValue,
+		"endDate":      endDateValue,
+		"dbUpdateDate": atValue,
+	}
+}
@@ -0,0 +1,187 @@
+package standservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
+)
+
+func newBookingTestService(stand models.Stand, now time.Time) (*StandService, *models.StandPatch, *MockNotifier) {
+	var saved models.StandPatch
+	repo := &MockRepository{
+		GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+			if id != 41 {
+				return nil, models.ErrStandNotFound
+			}
+			return &stand, nil
+		},
+		GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+			return []models.Stand{stand}, nil
+		},
+		PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+			// Операции бронирования обязаны проверять версию, на основе которой принято решение.
+			if version == nil || *version != stand.Version {
+				return nil, models.ErrVersionConflict
+			}
+			saved = patch
+			updated, err := stand.Apply(patch)
+			updated.Version++
+			return &updated, err
+		},
+	}
+	notifier := &MockNotifier{}
+	service := NewStandService(repo, notifier)
+	service.now = func() time.Time { return now }
+	return service, &saved, notifier
+}
+
+func TestStandService_Book(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("бронирование свободного стенда", func(t *testing.T) {
+		service, saved, notifier := newBookingTestService(models.Stand{ID: 41, Name: "alpha", EndDate: now.Unix() - 60, Version: 7}, now)
+
+		err := service.Book(context.Background(), "41", "Иванов", "релиз", now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		updated, _ := models.Stand{}.Apply(*saved)
+		if updated.Users != "Иванов" || updated.Reason != "релиз" || updated.EndDate != now.Add(time.Hour).Unix() {
+			t.Errorf("Сохранено неожиданное бронирование: %+v", updated)
+		}
+		if len(notifier.changed) != 1 || notifier.changed[0].ID != 41 {
+			t.Errorf("Ожидалась рассылка забронированного стенда, получено %+v", notifier.changed)
+		}
+	})
+
+	t.Run("аутентифицированный пользователь бронирует от своего имени", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(models.Stand{ID: 41, EndDate: now.Unix() - 60}, now)
+		ctx := auth.WithIdentity(context.Background(), auth.Identity{User: "Петров"})
+
+		if err := service.Book(ctx, "41", "Иванов", "", now.Add(time.Hour)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if updated, _ := (models.Stand{}).Apply(*saved); updated.Users != "Петров" {
+			t.Errorf("Ожидалось бронирование на пользователя из токена, получено %s", updated.Users)
+		}
+	})
+
+	t.Run("занятый стенд нельзя забронировать", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(models.Stand{ID: 41, Users: "Петров", EndDate: now.Unix() + 60}, now)
+
+		err := service.Book(context.Background(), "41", "Иванов", "", now.Add(time.Hour))
+		if !errors.Is(err, ErrStandOccupied) {
+			t.Errorf("Ожидалась ошибка ErrStandOccupied, получено %v", err)
+		}
+		if *saved != nil {
+			t.Error("Занятый стенд не должен обновляться")
+		}
+	})
+
+	t.Run("истекший стенд с очередью ожидает первого в очереди", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Сидоров", EndDate: now.Unix() - 60}, now)
+		service.waitlist.add(41, models.QueueEntry{User: "Петров", Duration: 3600})
+
+		err := service.Book(context.Background(), "41", "Иванов", "", now.Add(time.Hour))
+		if !errors.Is(err, ErrStandOccupied) {
+			t.Errorf("Ожидалась ошибка ErrStandOccupied, получено %v", err)
+		}
+		if *saved != nil {
+			t.Error("Стенд не должен бронироваться в обход очереди")
+		}
+	})
+
+	t.Run("первый в очереди бронирует стенд и покидает очередь", func(t *testing.T) {
+		service, _, notifier := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Сидоров", EndDate: now.Unix() - 60}, now)
+		service.waitlist.add(41, models.QueueEntry{User: "Петров", Duration: 3600})
+		service.waitlist.add(41, models.QueueEntry{User: "Иванов", Duration: 3600})
+
+		if err := service.Book(context.Background(), "41", " петров", "", now.Add(time.Hour)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if queue := service.waitlist.entries(41); len(queue) != 1 || queue[0].User != "Иванов" {
+			t.Errorf("Ожидалось удаление забронировавшего из очереди, получено %+v", queue)
+		}
+		if len(notifier.changed) != 1 || len(notifier.changed[0].Queue) != 1 {
+			t.Errorf("Ожидалась рассылка стенда с обновленной очередью, получено %+v", notifier.changed)
+		}
+	})
+
+	t.Run("дата окончания в прошлом", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41}, now)
+
+		err := service.Book(context.Background(), "41", "Иванов", "", now.Add(-time.Hour))
+		if !errors.Is(err, ErrInvalidBooking) {
+			t.Errorf("Ожидалась ошибка ErrInvalidBooking, получено %v", err)
+		}
+	})
+
+	t.Run("несуществующий стенд", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41}, now)
+
+		err := service.Book(context.Background(), "404", "Иванов", "", now.Add(time.Hour))
+		if !errors.Is(err, models.ErrStandNotFound) {
+			t.Errorf("Ожидалась ошибка ErrStandNotFound, получено %v", err)
+		}
+	})
+}
+
+func TestStandService_ExtendAndRelease(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	booked := models.Stand{ID: 41, Name: "alpha", Users: "Иванов", Reason: "релиз", EndDate: now.Unix() + 3600}
+
+	t.Run("владелец продлевает бронирование", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(booked, now)
+
+		if err := service.Extend(context.Background(), "41", " иванов", now.Add(2*time.Hour)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if string((*saved)["endDate"]) != "1700007200" || len(*saved) != 1 {
+			t.Errorf("Сохранен неожиданный патч: %v", *saved)
+		}
+	})
+
+	t.Run("чужое бронирование нельзя продлить", func(t *testing.T) {
+		service, _, _ := newBookingTestService(booked, now)
+
+		err := service.Extend(context.Background(), "41", "Петров", now.Add(2*time.Hour))
+		if !errors.Is(err, ErrNotBookingOwner) {
+			t.Errorf("Ожидалась ошибка ErrNotBookingOwner, получено %v", err)
+		}
+	})
+
+	t.Run("продление не может сократить бронирование", func(t *testing.T) {
+		service, _, _ := newBookingTestService(booked, now)
+
+		err := service.Extend(context.Background(), "41", "Иванов", now.Add(time.Minute))
+		if !errors.Is(err, ErrInvalidBooking) {
+			t.Errorf("Ожидалась ошибка ErrInvalidBooking, получено %v", err)
+		}
+	})
+
+	t.Run("владелец освобождает стенд", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(booked, now)
+
+		if err := service.Release(context.Background(), "41", "Иванов"); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		released, _ := booked.Apply(*saved)
+		if released.Users != "" || released.Reason != "" || released.IsBooked(now) {
+			t.Errorf("Стенд не освобожден: %+v", released)
+		}
+	})
+
+	t.Run("свободный стенд нельзя освободить", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Users: "Иванов", EndDate: now.Unix() - 1}, now)
+
+		err := service.Release(context.Background(), "41", "Иванов")
+		if !errors.Is(err, ErrStandNotBooked) {
+			t.Errorf("Ожидалась ошибка ErrStandNotBooked, получено %v", err)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package standservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"mts/booking_service/internal/models"
+)
+
+// RecordDeployment записывает в поля развертывания компонента стенда ветку, время
+// и автора развертывания из CI/CD и рассылает клиентам новое состояние стенда.
+// Стенд ищется по id или, без учета регистра, по имени.
+func (s *StandService) RecordDeployment(ctx context.Context, event models.DeploymentEvent) error {
+	patch := event.Patch()
+	if err := patch.Validate(); err != nil {
+		return err
+	}
+
+	stand, err := s.findStand(ctx, event.Stand)
+	if err != nil {
+		return err
+	}
+	if err := s.applyPatch(ctx, stand.ID, event.User, nil, patch); err != nil {
+		return err
+	}
+	log.Printf("На стенд %s развернут компонент %s из ветки %s пользователем %s", stand.Name, event.Component, event.Branch, event.User)
+	return nil
+}
+
+// findStand возвращает стенд по id или имени.
+func (s *StandService) findStand(ctx context.Context, nameOrID string) (*models.Stand, error) {
+	if id, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		return s.repo.GetStand(ctx, id)
+	}
+	stands, err := s.repo.GetStands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, stand := range stands {
+		if strings.EqualFold(stand.Name, strings.TrimSpace(nameOrID)) {
+			return &stand, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", models.ErrStandNotFound, nameOrID)
+}
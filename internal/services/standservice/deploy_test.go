@@ -0,0 +1,75 @@
+package standservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mts/booking_service/internal/models"
+)
+
+func TestStandService_RecordDeployment(t *testing.T) {
+	stand := models.Stand{ID: 41, Name: "Alpha", Version: 7}
+	var savedID int64
+	var saved models.StandPatch
+	repo := &MockRepository{
+		GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+			if id != 41 {
+				return nil, models.ErrStandNotFound
+			}
+			return &stand, nil
+		},
+		GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+			return []models.Stand{stand}, nil
+		},
+		PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+			if version != nil {
+				t.Errorf("Развертывание не должно проверять версию, получено %d", *version)
+			}
+			savedID, saved = id, patch
+			return &models.Stand{ID: id}, nil
+		},
+	}
+	notifier := &MockNotifier{}
+	service := NewStandService(repo, notifier)
+
+	t.Run("поиск стенда по имени", func(t *testing.T) {
+		err := service.RecordDeployment(context.Background(), models.DeploymentEvent{
+			Stand: "alpha", Component: "front", Branch: "feature/login", User: "Иванов", Date: 1_700_000_000,
+		})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if savedID != 41 {
+			t.Errorf("Ожидалось обновление стенда 41, получено %d", savedID)
+		}
+		if string(saved["frontBranch"]) != `"feature/login"` || string(saved["frontDeploymentDate"]) != "1700000000" || string(saved["frontDeploymentUser"]) != `"Иванов"` {
+			t.Errorf("Неожиданный патч: %v", saved)
+		}
+		if len(notifier.changed) == 0 {
+			t.Error("Ожидалась рассылка нового состояния стенда")
+		}
+	})
+
+	t.Run("поиск стенда по id", func(t *testing.T) {
+		savedID = 0
+		err := service.RecordDeployment(context.Background(), models.DeploymentEvent{Stand: "41", Component: "back", Branch: "main", User: "ci"})
+		if err != nil || savedID != 41 {
+			t.Errorf("Ожидалось обновление стенда 41, получено %d, %v", savedID, err)
+		}
+	})
+
+	t.Run("неизвестный стенд", func(t *testing.T) {
+		err := service.RecordDeployment(context.Background(), models.DeploymentEvent{Stand: "beta", Component: "front", Branch: "main"})
+		if !errors.Is(err, models.ErrStandNotFound) {
+			t.Errorf("Ожидалась ошибка ErrStandNotFound, получено %v", err)
+		}
+	})
+
+	t.Run("неизвестный компонент", func(t *testing.T) {
+		err := service.RecordDeployment(context.Background(), models.DeploymentEvent{Stand: "alpha", Component: "mobile", Branch: "main"})
+		if !errors.Is(err, models.ErrInvalidPatch) {
+			t.Errorf("Ожидалась ошибка ErrInvalidPatch, получено %v", err)
+		}
+	})
+}
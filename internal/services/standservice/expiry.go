@@ -0,0 +1,80 @@
+package standservice
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// ExpireBookings очищает поля бронирования у стендов, чей endDate уже прошел,
+// и рассылает клиентам новое состояние освобожденных стендов. Возвращает число освобожденных стендов.
+func (s *StandService) ExpireBookings(ctx context.Context) (int, error) {
+	stands, err := s.repo.GetStands(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	expired := 0
+	for _, stand := range stands {
+		if stand.IsBooked(now) {
+			continue
+		}
+		// Свободный стенд без очереди и без следов бронирования трогать не нужно.
+		_, queued := s.waitlist.peek(stand.ID)
+		if stand.Users == "" && !queued {
+			continue
+		}
+		// endDate не трогаем: он остается отметкой о том, когда закончилось бронирование.
+		patch, next := s.handoverPatch(stand.ID, stand.EndDate)
+		if err := s.patch(ctx, stand.ID, models.ActorSystem, &stand.Version, patch); err != nil {
+			log.Printf("Ошибка освобождения стенда %s с истекшим бронированием: %v", stand.Name, err)
+			continue
+		}
+		if stand.Users != "" {
+			log.Printf("Бронирование стенда %s пользователем %s истекло", stand.Name, stand.Users)
+		}
+		s.completeHandover(stand, next)
+		expired++
+
+		if err := s.broadcastStand(ctx, stand.ID); err != nil {
+			log.Printf("Ошибка рассылки состояния стенда %s: %v", stand.Name, err)
+		}
+	}
+
+	return expired, nil
+}
+
+// ExpiryWorker периодически освобождает стенды с истекшим бронированием.
+type ExpiryWorker struct {
+	service  *StandService
+	interval time.Duration
+}
+
+// NewExpiryWorker создает новый экземпляр ExpiryWorker.
+func NewExpiryWorker(service *StandService, interval time.Duration) *ExpiryWorker {
+	return &ExpiryWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run запускает проверку истекших бронирований и выполняет ее до отмены ctx.
+func (w *ExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.service.ExpireBookings(ctx); err != nil {
+			log.Printf("Ошибка проверки истекших бронирований: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
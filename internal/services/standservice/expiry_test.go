@@ -0,0 +1,54 @@
+package standservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+func TestStandService_ExpireBookings(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	stands := []models.Stand{
+		{ID: 1, Name: "alpha", Users: "Иванов", EndDate: now.Unix() - 60},
+		{ID: 2, Name: "beta", Users: "Петров", EndDate: now.Unix() + 60},
+		{ID: 3, Name: "gamma", EndDate: now.Unix() - 60},
+	}
+
+	patched := map[int64]models.StandPatch{}
+	repo := &MockRepository{
+		GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+			return stands, nil
+		},
+		GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+			return &models.Stand{ID: 1, Name: "alpha"}, nil
+		},
+		PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+			patched[id] = patch
+			return &models.Stand{ID: id}, nil
+		},
+	}
+	notifier := &MockNotifier{}
+	service := NewStandService(repo, notifier)
+	service.now = func() time.Time { return now }
+
+	expired, err := service.ExpireBookings(context.Background())
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	if expired != 1 || len(patched) != 1 {
+		t.Fatalf("Ожидалось освобождение одного стенда, освобождено %d: %v", expired, patched)
+	}
+	released, _ := stands[0].Apply(patched[1])
+	if released.Users != "" || released.Reason != "" || released.EndDate != stands[0].EndDate {
+		t.Errorf("Поля бронирования очищены неверно: %+v", released)
+	}
+	if len(notifier.changed) != 1 || notifier.changed[0].ID != 1 {
+		t.Errorf("Ожидалась рассылка только освобожденного стенда, получено %+v", notifier.changed)
+	}
+	if notifier.broadcastCalled {
+		t.Error("Полное состояние не должно рассылаться при освобождении стендов")
+	}
+}
@@ -0,0 +1,205 @@
+package standservice
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// HistoryStore определяет хранилище истории бронирований стендов.
+type HistoryStore interface {
+	// SaveInterval добавляет период бронирования с id 0 и возвращает его с присвоенным id,
+	// а период с известным id обновляет.
+	SaveInterval(ctx context.Context, interval models.BookingInterval) (models.BookingInterval, error)
+	// Intervals возвращает периоды бронирования, пересекающиеся с [from, to].
+	Intervals(ctx context.Context, from, to int64) ([]models.BookingInterval, error)
+}
+
+// bookingHistory отслеживает текущие бронирования стендов, чтобы по обновлениям стендов
+// восстанавливать периоды бронирования.
+type bookingHistory struct {
+	mu    sync.Mutex
+	store HistoryStore
+	// open - незакрытые периоды по id стендов. До первого обновления стенда nil: периоды
+	// загружаются из store, чтобы после перезапуска сервиса продолжать их, а не дублировать.
+	open map[int64]models.BookingInterval
+}
+
+// SetHistoryStore включает запись истории бронирований в store.
+func (s *StandService) SetHistoryStore(store HistoryStore) {
+	s.history = &bookingHistory{store: store}
+}
+
+// recordHistory обновляет историю бронирований по новому состоянию стенда: закрывает
+// закончившееся бронирование, открывает новое и запоминает наибольшую длину очереди.
+func (s *StandService) recordHistory(ctx context.Context, stand models.Stand) {
+	if s.history == nil {
+		return
+	}
+	h := s.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := s.now()
+	if !h.load(ctx, now.Unix()) {
+		return
+	}
+	queued := len(s.waitlist.entries(stand.ID))
+	booked := stand.IsBooked(now)
+
+	current, ok := h.open[stand.ID]
+	if ok && (!booked || !stand.IsBookedBy(current.User)) {
+		current.End = min(current.End, now.Unix())
+		h.save(ctx, current)
+		delete(h.open, stand.ID)
+		ok = false
+	}
+	if !booked {
+		return
+	}
+
+	if !ok {
+		current = models.BookingInterval{StandID: stand.ID, User: stand.Users, Start: bookedAt(stand, now.Unix())}
+	} else if current.End == stand.EndDate && current.PeakQueue >= queued {
+		return
+	}
+	current.End = stand.EndDate
+	current.PeakQueue = max(current.PeakQueue, queued)
+	if saved, ok := h.save(ctx, current); ok {
+		h.open[stand.ID] = saved
+	}
+}
+
+// load загружает из хранилища периоды бронирования, которые не закончились к моменту now,
+// и сообщает, известны ли незакрытые периоды. Вызывающий должен удерживать h.mu.
+func (h *bookingHistory) load(ctx context.Context, now int64) bool {
+	if h.open != nil {
+		return true
+	}
+	intervals, err := h.store.Intervals(ctx, now, now)
+	if err != nil {
+		log.Printf("Ошибка загрузки незакрытых периодов бронирования: %v", err)
+		return false
+	}
+
+	h.open = make(map[int64]models.BookingInterval, len(intervals))
+	for _, interval := range intervals {
+		if current, ok := h.open[interval.StandID]; !ok || interval.Start > current.Start {
+			h.open[interval.StandID] = interval
+		}
+	}
+	return true
+}
+
+// bookedAt возвращает время, когда стенд перешел к текущему пользователю: dbUpdateDate
+// из патча бронирования. Если оно не задано, началом считается now - момент, когда
+// сервис узнал о бронировании.
+func bookedAt(stand models.Stand, now int64) int64 {
+	if stand.DBUpdateDate != nil && *stand.DBUpdateDate <= now {
+		return *stand.DBUpdateDate
+	}
+	return now
+}
+
+// save сохраняет период бронирования. Ошибка хранилища не мешает работе со стендами и только логируется.
+func (h *bookingHistory) save(ctx context.Context, interval models.BookingInterval) (models.BookingInterval, bool) {
+	saved, err := h.store.SaveInterval(ctx, interval)
+	if err != nil {
+		log.Printf("Ошибка сохранения истории бронирования стенда %d: %v", interval.StandID, err)
+		return interval, false
+	}
+	return saved, true
+}
+
+// Reports возвращает статистику использования всех стендов за период [from, to].
+// Будущая часть периода в статистику не входит.
+func (s *StandService) Reports(ctx context.Context, from, to time.Time) ([]models.StandReport, error) {
+	stands, err := s.repo.GetStands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var intervals []models.BookingInterval
+	if s.history != nil {
+		intervals, err = s.history.store.Intervals(ctx, from.Unix(), to.Unix())
+		if err != nil {
+			return nil, err
+		}
+	}
+	stands = s.withArchived(ctx, stands, intervals)
+	return buildReports(stands, intervals, from.Unix(), min(to.Unix(), s.now().Unix())), nil
+}
+
+// withArchived дополняет стенды архивными стендами, у которых есть периоды бронирования
+// в intervals, чтобы их история попадала в отчеты.
+func (s *StandService) withArchived(ctx context.Context, stands []models.Stand, intervals []models.BookingInterval) []models.Stand {
+	known := make(map[int64]bool, len(stands))
+	for _, stand := range stands {
+		known[stand.ID] = true
+	}
+	for _, interval := range intervals {
+		if known[interval.StandID] {
+			continue
+		}
+		known[interval.StandID] = true
+		stand, err := s.repo.GetStand(ctx, interval.StandID)
+		if err != nil {
+			log.Printf("Ошибка получения стенда %d для отчета: %v", interval.StandID, err)
+			continue
+		}
+		stands = append(stands, *stand)
+	}
+	return stands
+}
+
+// buildReports считает статистику стендов по периодам бронирования в пределах [from, to].
+func buildReports(stands []models.Stand, intervals []models.BookingInterval, from, to int64) []models.StandReport {
+	byStand := make(map[int64][]models.BookingInterval)
+	for _, interval := range intervals {
+		byStand[interval.StandID] = append(byStand[interval.StandID], interval)
+	}
+
+	reports := make([]models.StandReport, 0, len(stands))
+	for _, stand := range stands {
+		report := models.StandReport{StandID: stand.ID, Name: stand.Name}
+		standIntervals := byStand[stand.ID]
+		sort.Slice(standIntervals, func(i, j int) bool { return standIntervals[i].Start < standIntervals[j].Start })
+
+		users := make(map[string]bool)
+		var busy, total int64
+		coveredUntil := from
+		for _, interval := range standIntervals {
+			// В отчет входит только часть периода внутри [from, to].
+			start, end := max(interval.Start, from), min(interval.End, to)
+			if end < interval.Start || end < from || interval.Start > to {
+				continue
+			}
+			report.Bookings++
+			total += end - start
+			users[strings.ToLower(strings.TrimSpace(interval.User))] = true
+			report.PeakContention = max(report.PeakContention, interval.PeakQueue+1)
+
+			// Периоды одного стенда не должны пересекаться, но при сбоях записи
+			// занятость считается по объединению периодов.
+			if uncovered := max(start, coveredUntil); end > uncovered {
+				busy += end - uncovered
+				coveredUntil = end
+			}
+		}
+
+		report.DistinctUsers = len(users)
+		if report.Bookings > 0 {
+			report.AverageBooking = total / int64(report.Bookings)
+		}
+		if to > from {
+			report.Utilization = math.Round(float64(busy)*10000/float64(to-from)) / 100
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
@@ -0,0 +1,132 @@
+package standservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/history"
+	"mts/booking_service/internal/models"
+)
+
+func TestStandService_Reports(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	now := start
+
+	stand := models.Stand{ID: 41, Name: "alpha"}
+	repo := &MockRepository{
+		GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+			current := stand
+			return &current, nil
+		},
+		GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+			return []models.Stand{stand, {ID: 42, Name: "beta"}}, nil
+		},
+		PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+			updated, err := stand.Apply(patch)
+			if err != nil {
+				return nil, err
+			}
+			stand = updated
+			stand.Version++
+			saved := stand
+			return &saved, nil
+		},
+	}
+	service := NewStandService(repo, &MockNotifier{})
+	service.now = func() time.Time { return now }
+	service.SetHistoryStore(history.NewMemoryStore())
+	ctx := context.Background()
+
+	if err := service.Book(ctx, "41", "Иванов", "релиз", start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	now = start.Add(10 * time.Minute)
+	if _, err := service.Enqueue(ctx, "41", "Петров", "", time.Hour); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	// Иванов освобождает стенд через час, и он переходит к Петрову на час.
+	now = start.Add(time.Hour)
+	if err := service.Release(ctx, "41", "Иванов"); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	now = start.Add(2 * time.Hour)
+	if _, err := service.ExpireBookings(ctx); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	now = start.Add(4 * time.Hour)
+	reports, err := service.Reports(ctx, start, start.Add(8*time.Hour))
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Ожидалось 2 отчета, получено %+v", reports)
+	}
+
+	alpha := reports[0]
+	// Период после now в отчет не входит: стенд занят 2 часа из 4.
+	if alpha.Utilization != 50 || alpha.Bookings != 2 || alpha.AverageBooking != int64(time.Hour/time.Second) ||
+		alpha.DistinctUsers != 2 || alpha.PeakContention != 2 {
+		t.Errorf("Неожиданный отчет по стенду alpha: %+v", alpha)
+	}
+	if beta := reports[1]; beta.Name != "beta" || beta.Utilization != 0 || beta.Bookings != 0 || beta.PeakContention != 0 {
+		t.Errorf("Ожидался пустой отчет по простаивающему стенду beta, получено %+v", beta)
+	}
+}
+
+func TestStandService_HistoryRestart(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	store := history.NewMemoryStore()
+	bookedAt := start.Unix()
+	stand := models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: start.Add(2 * time.Hour).Unix(), DBUpdateDate: &bookedAt}
+	repo := &MockRepository{
+		GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+			return []models.Stand{stand}, nil
+		},
+	}
+	newService := func(now time.Time) *StandService {
+		service := NewStandService(repo, &MockNotifier{})
+		service.now = func() time.Time { return now }
+		service.SetHistoryStore(store)
+		return service
+	}
+	ctx := context.Background()
+
+	// Сервис узнает о бронировании через 10 минут, а затем перезапускается.
+	if err := newService(start.Add(10 * time.Minute)).broadcastStands(ctx); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	if err := newService(start.Add(30 * time.Minute)).broadcastStands(ctx); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	intervals, _ := store.Intervals(ctx, start.Unix(), start.Add(8*time.Hour).Unix())
+	if len(intervals) != 1 {
+		t.Fatalf("После перезапуска бронирование не должно учитываться повторно, получено %+v", intervals)
+	}
+	if intervals[0].Start != start.Unix() || intervals[0].End != stand.EndDate {
+		t.Errorf("Период должен начинаться с момента бронирования, получено %+v", intervals[0])
+	}
+}
+
+func TestBuildReports(t *testing.T) {
+	stands := []models.Stand{{ID: 1, Name: "alpha"}}
+	intervals := []models.BookingInterval{
+		// Бронирование началось до периода отчета.
+		{StandID: 1, User: "Иванов", Start: 0, End: 150},
+		// Пересекающиеся периоды считаются один раз.
+		{StandID: 1, User: "иванов", Start: 300, End: 500},
+		{StandID: 1, User: "Петров", Start: 400, End: 600, PeakQueue: 3},
+	}
+
+	reports := buildReports(stands, intervals, 100, 500)
+	report := reports[0]
+	if report.Utilization != 62.5 || report.Bookings != 3 || report.DistinctUsers != 2 || report.PeakContention != 4 {
+		t.Errorf("Неожиданный отчет: %+v", report)
+	}
+	// Бронирования учитываются только внутри периода: (50 + 200 + 100) / 3.
+	if report.AverageBooking != 116 {
+		t.Errorf("Ожидалась средняя длительность 116, получено %d", report.AverageBooking)
+	}
+}
@@ -0,0 +1,155 @@
+package standservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"mts/booking_service/internal/models"
+)
+
+// ErrStandExists возвращается при создании стенда с именем уже существующего стенда.
+var ErrStandExists = errors.New("стенд с таким именем уже существует")
+
+// newStandBookingFields - поля бронирования, которые нельзя задать при создании стенда:
+// стенд бронируется только через Book, с его проверками, историей и очередью.
+var newStandBookingFields = []string{"users", "endDate", "reason"}
+
+// checkNewStandFields отклоняет поля бронирования в полях нового стенда.
+func checkNewStandFields(fields models.StandPatch) error {
+	for _, field := range newStandBookingFields {
+		if _, ok := fields[field]; ok {
+			return &models.FieldError{Field: field, Err: fmt.Errorf("%w: новый стенд создается свободным", models.ErrInvalidPatch)}
+		}
+	}
+	return nil
+}
+
+// CreateStand создает свободный стенд из полей fields в формате патча стенда, например
+// {"name": "alpha", "deployments": {...}}. Имя обязательно и уникально среди неархивных стендов.
+func (s *StandService) CreateStand(ctx context.Context, fields models.StandPatch) (*models.Stand, error) {
+	if err := s.authorizeAdmin(ctx, "создание стенда"); err != nil {
+		log.Printf("Отклонено создание стенда: %v", err)
+		return nil, err
+	}
+	if err := checkNewStandFields(fields); err != nil {
+		return nil, err
+	}
+	stand, err := models.Stand{}.Apply(fields)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, stand)
+}
+
+// CloneStand создает свободный стенд с теми же ветками компонентов, что у стенда id.
+// Поля fields применяются поверх копии и должны содержать имя нового стенда.
+func (s *StandService) CloneStand(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error) {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeAdmin(ctx, "клонирование стенда"); err != nil {
+		log.Printf("Отклонено клонирование стенда %d: %v", standID, err)
+		return nil, err
+	}
+	if err := checkNewStandFields(fields); err != nil {
+		return nil, err
+	}
+	source, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Копируются только ветки: на новый стенд компоненты еще не развертывались.
+	clone := models.Stand{Deployments: make(map[string]models.Deployment)}
+	for component, deployment := range source.Deployments {
+		if deployment.Branch != nil {
+			branch := *deployment.Branch
+			clone.Deployments[component] = models.Deployment{Branch: &branch}
+		}
+	}
+	stand, err := clone.Apply(fields)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, stand)
+}
+
+// create проверяет имя стенда, сохраняет его, записывает в журнал и рассылает клиентам.
+func (s *StandService) create(ctx context.Context, stand models.Stand) (*models.Stand, error) {
+	stand.Name = strings.TrimSpace(stand.Name)
+	if stand.Name == "" {
+		return nil, &models.FieldError{Field: "name", Err: fmt.Errorf("%w: не задано имя стенда", models.ErrInvalidPatch)}
+	}
+	stands, err := s.repo.GetStands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range stands {
+		if strings.EqualFold(existing.Name, stand.Name) {
+			return nil, fmt.Errorf("%w: %s", ErrStandExists, stand.Name)
+		}
+	}
+
+	created, err := s.repo.Create(ctx, stand)
+	if err != nil {
+		log.Printf("Ошибка создания стенда %s: %v", stand.Name, err)
+		return nil, err
+	}
+	s.known.observe(*created)
+	s.recordChanges(ctx, models.Stand{}, *created, actingUser(ctx, ""))
+	s.notifyStandChanged(*created)
+	log.Printf("Создан стенд %s с id %d", created.Name, created.ID)
+	return created, nil
+}
+
+// ArchiveStand переносит стенд в архив: он пропадает с досок клиентов, но остается
+// в хранилище для журнала изменений и отчетов. Занятый стенд сначала нужно освободить.
+func (s *StandService) ArchiveStand(ctx context.Context, id string, version *int64) error {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeAdmin(ctx, "архивирование стенда"); err != nil {
+		log.Printf("Отклонено архивирование стенда %d: %v", standID, err)
+		return err
+	}
+	stand, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return err
+	}
+	if stand.IsArchived() {
+		return fmt.Errorf("%w: %s", models.ErrStandArchived, stand.Name)
+	}
+	now := s.now()
+	if stand.IsBooked(now) {
+		return fmt.Errorf("%w: %s занят пользователем %s", ErrStandOccupied, stand.Name, stand.Users)
+	}
+
+	// Без ожидаемой версии архивируется та версия стенда, которая проверена выше: она же
+	// записывается в журнал как состояние до архивирования.
+	if version == nil {
+		version = &stand.Version
+	}
+	archived, err := s.repo.Archive(ctx, standID, version, now)
+	if err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			log.Printf("Конфликт версий при архивировании стенда %d: %v", standID, err)
+			return s.conflictError(ctx, standID)
+		}
+		log.Printf("Ошибка архивирования стенда %d: %v", standID, err)
+		return err
+	}
+
+	s.known.observe(*archived)
+	s.recordChanges(ctx, *stand, *archived, actingUser(ctx, ""))
+	s.waitlist.clear(stand.ID)
+	if s.notifier != nil {
+		s.notifier.StandRemoved(stand.ID)
+	}
+	log.Printf("Стенд %s перенесен в архив", stand.Name)
+	return nil
+}
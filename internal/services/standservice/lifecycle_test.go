@@ -0,0 +1,184 @@
+package standservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
+)
+
+func TestStandService_CreateStand(t *testing.T) {
+	branch := "feature/login"
+	source := models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: 1_800_000_000, Deployments: map[string]models.Deployment{
+		"front": {Branch: &branch, User: &branch},
+		"back":  {},
+	}}
+	var created []models.Stand
+	repo := &MockRepository{
+		GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+			return []models.Stand{source}, nil
+		},
+		GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+			if id != 41 {
+				return nil, models.ErrStandNotFound
+			}
+			return &source, nil
+		},
+		CreateFunc: func(ctx context.Context, stand models.Stand) (*models.Stand, error) {
+			stand.ID = 42
+			created = append(created, stand)
+			return &stand, nil
+		},
+	}
+	notifier := &MockNotifier{}
+	service := NewStandService(repo, notifier)
+	store := audit.NewMemoryStore()
+	service.SetAuditStore(store)
+
+	t.Run("создание стенда", func(t *testing.T) {
+		stand, err := service.CreateStand(context.Background(), models.StandPatch{"name": json.RawMessage(`" beta "`), "standLink": json.RawMessage(`"https://beta"`)})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if stand.ID != 42 || stand.Name != "beta" || *stand.StandLink != "https://beta" {
+			t.Errorf("Неожиданный стенд: %+v", stand)
+		}
+		if len(notifier.changed) != 1 || notifier.changed[0].ID != 42 {
+			t.Errorf("Ожидалась рассылка нового стенда, получено %+v", notifier.changed)
+		}
+		entries, _ := store.Query(context.Background(), models.AuditFilter{})
+		if len(entries) != 1 || entries[0].StandID != 42 || string(entries[0].Changes["name"].After) != `"beta"` {
+			t.Errorf("Ожидалась запись о создании стенда в журнале, получено %+v", entries)
+		}
+	})
+
+	t.Run("клонирование копирует только ветки", func(t *testing.T) {
+		created = nil
+		stand, err := service.CloneStand(context.Background(), "41", models.StandPatch{"name": json.RawMessage(`"alpha-2"`)})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		front := stand.Deployments["front"]
+		if front.Branch == nil || *front.Branch != branch || front.User != nil {
+			t.Errorf("Ожидалась ветка front без автора развертывания, получено %+v", front)
+		}
+		if _, ok := stand.Deployments["back"]; ok || stand.Users != "" || stand.EndDate != 0 {
+			t.Errorf("Клон должен быть свободен и без пустых развертываний: %+v", stand)
+		}
+	})
+
+	t.Run("ошибки создания", func(t *testing.T) {
+		cases := map[string]struct {
+			id     string
+			fields models.StandPatch
+			err    error
+		}{
+			"без имени":             {"", models.StandPatch{"comment": json.RawMessage(`"x"`)}, models.ErrInvalidPatch},
+			"занятое имя":           {"", models.StandPatch{"name": json.RawMessage(`"ALPHA"`)}, ErrStandExists},
+			"неизвестное поле":      {"", models.StandPatch{"name": json.RawMessage(`"gamma"`), "status": json.RawMessage(`1`)}, models.ErrInvalidPatch},
+			"клон несуществующего":  {"404", models.StandPatch{"name": json.RawMessage(`"gamma"`)}, models.ErrStandNotFound},
+			"клон с занятым именем": {"41", models.StandPatch{"name": json.RawMessage(`"alpha"`)}, ErrStandExists},
+			"занятый стенд":         {"", models.StandPatch{"name": json.RawMessage(`"gamma"`), "users": json.RawMessage(`"Иванов"`)}, models.ErrInvalidPatch},
+			"клон с бронированием":  {"41", models.StandPatch{"name": json.RawMessage(`"gamma"`), "endDate": json.RawMessage(`1800000000`)}, models.ErrInvalidPatch},
+		}
+		for name, tc := range cases {
+			var err error
+			if tc.id == "" {
+				_, err = service.CreateStand(context.Background(), tc.fields)
+			} else {
+				_, err = service.CloneStand(context.Background(), tc.id, tc.fields)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Errorf("%s: ожидалась ошибка %v, получено %v", name, tc.err, err)
+			}
+		}
+	})
+
+	t.Run("создание доступно только администраторам", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), auth.Identity{User: "Петров", Role: auth.RoleLead})
+		if _, err := service.CreateStand(ctx, models.StandPatch{"name": json.RawMessage(`"gamma"`)}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("Ожидалась ошибка ErrForbidden, получено %v", err)
+		}
+	})
+}
+
+func TestStandService_ArchiveStand(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	newService := func(stand models.Stand) (*StandService, *MockNotifier, *time.Time) {
+		var archivedAt time.Time
+		repo := &MockRepository{
+			GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+				return &stand, nil
+			},
+			ArchiveFunc: func(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error) {
+				if version != nil && *version != stand.Version {
+					return nil, models.ErrVersionConflict
+				}
+				archivedAt = at
+				archived := stand.Clone()
+				unix := at.Unix()
+				archived.ArchivedAt, archived.Version = &unix, stand.Version+1
+				return &archived, nil
+			},
+		}
+		notifier := &MockNotifier{}
+		service := NewStandService(repo, notifier)
+		service.now = func() time.Time { return now }
+		return service, notifier, &archivedAt
+	}
+
+	t.Run("архивирование свободного стенда", func(t *testing.T) {
+		service, notifier, archivedAt := newService(models.Stand{ID: 41, Name: "alpha", EndDate: now.Unix() - 60, Version: 3})
+		store := audit.NewMemoryStore()
+		service.SetAuditStore(store)
+
+		version := int64(3)
+		if err := service.ArchiveStand(context.Background(), "41", &version); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if !archivedAt.Equal(now) {
+			t.Errorf("Ожидалось архивирование на момент %v, получено %v", now, *archivedAt)
+		}
+		if len(notifier.removed) != 1 || notifier.removed[0] != 41 {
+			t.Errorf("Клиенты должны убрать стенд с досок, получено %v", notifier.removed)
+		}
+		entries, _ := store.Query(context.Background(), models.AuditFilter{})
+		if len(entries) != 1 || string(entries[0].Changes["archivedAt"].After) != "1700000000" {
+			t.Errorf("Ожидалась запись об архивировании в журнале, получено %+v", entries)
+		}
+	})
+
+	t.Run("занятый стенд не архивируется", func(t *testing.T) {
+		service, _, _ := newService(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Unix() + 60})
+		if err := service.ArchiveStand(context.Background(), "41", nil); !errors.Is(err, ErrStandOccupied) {
+			t.Errorf("Ожидалась ошибка ErrStandOccupied, получено %v", err)
+		}
+	})
+
+	t.Run("устаревшая версия", func(t *testing.T) {
+		service, notifier, _ := newService(models.Stand{ID: 41, Name: "alpha", Version: 3})
+		version := int64(2)
+		err := service.ArchiveStand(context.Background(), "41", &version)
+		var conflict *models.ConflictError
+		if !errors.As(err, &conflict) || conflict.Current == nil || conflict.Current.Version != 3 {
+			t.Errorf("Ожидался конфликт версий с актуальным стендом, получено %v", err)
+		}
+		if len(notifier.removed) != 0 {
+			t.Errorf("Стенд не должен убираться с досок при конфликте: %v", notifier.removed)
+		}
+	})
+
+	t.Run("повторное архивирование", func(t *testing.T) {
+		archived := now.Unix()
+		service, _, _ := newService(models.Stand{ID: 41, Name: "alpha", ArchivedAt: &archived})
+		if err := service.ArchiveStand(context.Background(), "41", nil); !errors.Is(err, models.ErrStandArchived) {
+			t.Errorf("Ожидалась ошибка ErrStandArchived, получено %v", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package standservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
+)
+
+// ErrForbidden возвращается, если роли пользователя недостаточно для операции.
+var ErrForbidden = errors.New("недостаточно прав для операции")
+
+// bookingFields - поля стенда, которые меняются при бронировании. Остальные поля
+// описывают сам стенд и его развертывания и доступны только администраторам.
+var bookingFields = map[string]bool{
+	"users":   true,
+	"reason":  true,
+	"endDate": true,
+	"comment": true,
+}
+
+// canManageOthers сообщает, может ли пользователь из ctx продлевать и освобождать чужие бронирования.
+func canManageOthers(ctx context.Context) bool {
+	identity, ok := auth.IdentityFromContext(ctx)
+	return ok && identity.Role.AtLeast(auth.RoleLead)
+}
+
+// SetAuthRequired включает обязательную аутентификацию: операции, которые проверяют роль
+// пользователя, отклоняются, если в ctx нет пользователя. Без нее сервис работает, как
+// до появления ролей, и такие проверки не выполняются.
+func (s *StandService) SetAuthRequired(required bool) {
+	s.authRequired = required
+}
+
+// identity возвращает пользователя из ctx. Если пользователя нет, а аутентификация
+// обязательна, возвращается ErrForbidden.
+func (s *StandService) identity(ctx context.Context, action string) (auth.Identity, bool, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok && s.authRequired {
+		return auth.Identity{}, false, fmt.Errorf("%w: %s требует аутентификации", ErrForbidden, action)
+	}
+	return identity, ok, nil
+}
+
+// authorizeAdmin проверяет, что пользователь из ctx - администратор: только они создают,
+// клонируют и архивируют стенды. Без обязательной аутентификации запрос без пользователя
+// не проверяется.
+func (s *StandService) authorizeAdmin(ctx context.Context, action string) error {
+	identity, ok, err := s.identity(ctx, action)
+	if err != nil {
+		return err
+	}
+	if !ok || identity.Role.AtLeast(auth.RoleAdmin) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s требует роли %s, у пользователя %s роль %s", ErrForbidden, action, auth.RoleAdmin, identity.User, identity.Role)
+}
+
+// authorizePatch проверяет, может ли пользователь из ctx применить patch к стенду:
+// поля стенда и развертываний меняют только администраторы, чужие бронирования - руководители,
+// а разработчики - только свободные стенды и свои бронирования и только от своего имени.
+func (s *StandService) authorizePatch(ctx context.Context, id int64, patch models.StandPatch) error {
+	identity, ok, err := s.identity(ctx, "изменение стенда")
+	if err != nil {
+		return err
+	}
+	if !ok || identity.Role.AtLeast(auth.RoleAdmin) {
+		return nil
+	}
+	for key := range patch {
+		if !bookingFields[key] {
+			return fmt.Errorf("%w: изменение поля %s требует роли %s, у пользователя %s роль %s", ErrForbidden, key, auth.RoleAdmin, identity.User, identity.Role)
+		}
+	}
+	if identity.Role.AtLeast(auth.RoleLead) {
+		return nil
+	}
+
+	if value, ok := patch["users"]; ok {
+		var users string
+		// Пользователи сравниваются как в Stand.IsBookedBy и очереди.
+		if err := json.Unmarshal(value, &users); err == nil && strings.TrimSpace(users) != "" && !models.SameUser(users, identity.User) {
+			return fmt.Errorf("%w: пользователь %s не может бронировать стенд на имя %s", ErrForbidden, identity.User, users)
+		}
+	}
+	stand, err := s.repo.GetStand(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if stand.IsBooked(now) && !stand.IsBookedBy(identity.User) {
+		return fmt.Errorf("%w: %s занят пользователем %s", ErrNotBookingOwner, stand.Name, stand.Users)
+	}
+
+	// Дата окончания и причина относятся к бронированию того, кто останется в поле users.
+	// Иначе разработчик мог бы продлить истекшее бронирование другого пользователя.
+	_, endDate := patch["endDate"]
+	_, reason := patch["reason"]
+	if !endDate && !reason {
+		return nil
+	}
+	result, err := stand.Apply(patch)
+	if err != nil {
+		return err
+	}
+	_, setsUsers := patch["users"]
+	released := setsUsers && strings.TrimSpace(result.Users) == "" && !result.IsBooked(now)
+	if !released && !result.IsBookedBy(identity.User) {
+		return fmt.Errorf("%w: пользователь %s не может менять бронирование стенда %s на имя %q", ErrForbidden, identity.User, stand.Name, result.Users)
+	}
+	return nil
+}
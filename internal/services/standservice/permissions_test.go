@@ -0,0 +1,167 @@
+package standservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
+)
+
+func withRole(user string, role auth.Role) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{User: user, Role: role})
+}
+
+func TestStandService_Permissions(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	booked := models.Stand{ID: 41, Name: "alpha", Users: "Петров", EndDate: now.Unix() + 3600, Version: 1}
+
+	t.Run("разработчик не может освободить чужой стенд", func(t *testing.T) {
+		service, _, _ := newBookingTestService(booked, now)
+
+		err := service.Release(withRole("Иванов", auth.RoleDeveloper), "41", "Иванов")
+		if !errors.Is(err, ErrNotBookingOwner) {
+			t.Errorf("Ожидалась ошибка ErrNotBookingOwner, получено %v", err)
+		}
+	})
+
+	t.Run("руководитель освобождает и продлевает чужой стенд", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(booked, now)
+		ctx := withRole("Сидоров", auth.RoleLead)
+
+		if err := service.Extend(ctx, "41", "Сидоров", now.Add(2*time.Hour)); err != nil {
+			t.Fatalf("Ожидалась ошибка nil при продлении, получено %v", err)
+		}
+		if err := service.Release(ctx, "41", "Сидоров"); err != nil {
+			t.Fatalf("Ожидалась ошибка nil при освобождении, получено %v", err)
+		}
+		if released, _ := booked.Apply(*saved); released.Users != "" {
+			t.Errorf("Ожидалось освобождение стенда, получено %+v", released)
+		}
+	})
+
+	t.Run("поля развертывания меняет только администратор", func(t *testing.T) {
+		service, _, _ := newBookingTestService(booked, now)
+		patch := models.StandPatch{"frontBranch": json.RawMessage(`"feature/login"`)}
+
+		if err := service.UpdateStand(withRole("Сидоров", auth.RoleLead), "41", &booked.Version, patch); !errors.Is(err, ErrForbidden) {
+			t.Errorf("Ожидалась ошибка ErrForbidden для руководителя, получено %v", err)
+		}
+		if err := service.UpdateStand(withRole("Админов", auth.RoleAdmin), "41", &booked.Version, patch); err != nil {
+			t.Errorf("Ожидалась ошибка nil для администратора, получено %v", err)
+		}
+	})
+
+	t.Run("разработчик не может бронировать на чужое имя через PATCH", func(t *testing.T) {
+		free := models.Stand{ID: 41, EndDate: now.Unix() - 60, Version: 2}
+		service, _, _ := newBookingTestService(free, now)
+		ctx := withRole("Иванов", auth.RoleDeveloper)
+
+		err := service.UpdateStand(ctx, "41", &free.Version, models.StandPatch{"users": json.RawMessage(`"Петров"`)})
+		if !errors.Is(err, ErrForbidden) {
+			t.Errorf("Ожидалась ошибка ErrForbidden, получено %v", err)
+		}
+		if err := service.UpdateStand(ctx, "41", &free.Version, models.StandPatch{"users": json.RawMessage(`"Иванов"`)}); err != nil {
+			t.Errorf("Ожидалась ошибка nil при бронировании на себя, получено %v", err)
+		}
+	})
+}
+
+func TestStandService_DeveloperBookingFields(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	later := json.RawMessage(`1700007200`)
+	past := json.RawMessage(`1699999000`)
+	tests := []struct {
+		name  string
+		stand models.Stand
+		patch models.StandPatch
+		err   error
+	}{
+		{
+			name:  "продление своего бронирования",
+			stand: models.Stand{ID: 41, Users: "Иванов", EndDate: now.Unix() + 60},
+			patch: models.StandPatch{"endDate": later, "reason": json.RawMessage(`"релиз"`)},
+		},
+		{
+			name:  "продление истекшего бронирования другого пользователя",
+			stand: models.Stand{ID: 41, Users: "Петров", EndDate: now.Unix() - 60},
+			patch: models.StandPatch{"endDate": later},
+			err:   ErrForbidden,
+		},
+		{
+			name:  "причина истекшего бронирования другого пользователя",
+			stand: models.Stand{ID: 41, Users: "Петров", EndDate: now.Unix() - 60},
+			patch: models.StandPatch{"reason": json.RawMessage(`"тест"`)},
+			err:   ErrForbidden,
+		},
+		{
+			name:  "дата окончания без пользователя",
+			stand: models.Stand{ID: 41, EndDate: now.Unix() - 60},
+			patch: models.StandPatch{"endDate": later},
+			err:   ErrForbidden,
+		},
+		{
+			name:  "бронирование свободного стенда на себя",
+			stand: models.Stand{ID: 41, Users: "Петров", EndDate: now.Unix() - 60},
+			patch: models.StandPatch{"users": json.RawMessage(`"Иванов"`), "endDate": later},
+		},
+		{
+			name:  "бронирование на себя в другом регистре",
+			stand: models.Stand{ID: 41, EndDate: now.Unix() - 60},
+			patch: models.StandPatch{"users": json.RawMessage(`" иванов"`), "endDate": later},
+		},
+		{
+			name:  "освобождение своего бронирования",
+			stand: models.Stand{ID: 41, Users: "Иванов", EndDate: now.Unix() + 60},
+			patch: models.StandPatch{"users": json.RawMessage(`""`), "reason": json.RawMessage(`""`), "endDate": past},
+		},
+		{
+			name:  "бронирование без пользователя до будущей даты",
+			stand: models.Stand{ID: 41, Users: "Иванов", EndDate: now.Unix() + 60},
+			patch: models.StandPatch{"users": json.RawMessage(`""`), "endDate": later},
+			err:   ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _ := newBookingTestService(tt.stand, now)
+
+			err := service.UpdateStand(withRole("Иванов", auth.RoleDeveloper), "41", &tt.stand.Version, tt.patch)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Ожидалась ошибка %v, получено %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestStandService_AuthorizeAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		ctx      context.Context
+		err      error
+	}{
+		{name: "администратор", required: true, ctx: withRole("Админов", auth.RoleAdmin)},
+		{name: "руководитель", required: true, ctx: withRole("Сидоров", auth.RoleLead), err: ErrForbidden},
+		{name: "без пользователя при обязательной аутентификации", required: true, ctx: context.Background(), err: ErrForbidden},
+		{name: "без пользователя при выключенной аутентификации", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewStandService(&MockRepository{}, &MockNotifier{})
+			service.SetAuthRequired(tt.required)
+
+			if err := service.authorizeAdmin(tt.ctx, "архивирование стенда"); !errors.Is(err, tt.err) {
+				t.Errorf("Ожидалась ошибка %v, получено %v", tt.err, err)
+			}
+			if _, err := service.CreateStand(tt.ctx, models.StandPatch{"name": json.RawMessage(`"gamma"`)}); !errors.Is(err, tt.err) {
+				t.Errorf("CreateStand: ожидалась ошибка %v, получено %v", tt.err, err)
+			}
+		})
+	}
+}
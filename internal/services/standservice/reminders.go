@@ -0,0 +1,239 @@
+package standservice
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// ErrInvalidExtendToken возвращается при продлении по поддельному или поврежденному токену.
+var ErrInvalidExtendToken = errors.New("недействительный токен продления бронирования")
+
+// ReminderChannel определяет канал доставки напоминаний владельцам бронирований.
+type ReminderChannel interface {
+	Remind(ctx context.Context, reminder models.Reminder) error
+}
+
+// ReminderScheduler напоминает владельцам бронирований о скором окончании бронирования
+// за заданные интервалы до endDate и дает продлить бронирование по подписанному токену.
+type ReminderScheduler struct {
+	service  *StandService
+	offsets  []time.Duration
+	channels []ReminderChannel
+	extendBy time.Duration
+	secret   []byte
+	baseURL  string
+	interval time.Duration
+
+	mu   sync.Mutex
+	sent map[reminderKey]bool
+}
+
+// reminderKey определяет одно напоминание: продление бронирования меняет endDate
+// и снова включает напоминания.
+type reminderKey struct {
+	standID int64
+	user    string
+	endDate int64
+	offset  time.Duration
+}
+
+// ReminderOptions - параметры ReminderScheduler.
+type ReminderOptions struct {
+	// Offsets - за сколько до окончания бронирования напоминать.
+	Offsets []time.Duration
+	// ExtendBy - на сколько продлевает бронирование токен из напоминания.
+	ExtendBy time.Duration
+	// Secret - ключ подписи токенов продления. Если пуст, создается случайный ключ,
+	// и токены перестают действовать после перезапуска.
+	Secret string
+	// BaseURL - внешний адрес сервиса для ссылок продления.
+	BaseURL string
+	// CheckInterval - период проверки бронирований.
+	CheckInterval time.Duration
+}
+
+// NewReminderScheduler создает новый экземпляр ReminderScheduler.
+func NewReminderScheduler(service *StandService, options ReminderOptions, channels ...ReminderChannel) (*ReminderScheduler, error) {
+	secret := []byte(options.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("ошибка создания ключа подписи токенов продления: %w", err)
+		}
+	}
+	offsets := append([]time.Duration(nil), options.Offsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	return &ReminderScheduler{
+		service:  service,
+		offsets:  offsets,
+		channels: channels,
+		extendBy: options.ExtendBy,
+		secret:   secret,
+		baseURL:  strings.TrimSuffix(options.BaseURL, "/"),
+		interval: options.CheckInterval,
+		sent:     make(map[reminderKey]bool),
+	}, nil
+}
+
+// Run проверяет бронирования и отправляет напоминания до отмены ctx.
+func (r *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.SendReminders(ctx); err != nil {
+			log.Printf("Ошибка проверки напоминаний о бронированиях: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendReminders отправляет напоминания владельцам бронирований, до окончания которых
+// осталось не больше одного из интервалов. Если подошли сразу несколько интервалов,
+// например после перезапуска, отправляется одно напоминание по ближайшему. Напоминание,
+// которое не доставил ни один канал, отправляется повторно при следующей проверке.
+func (r *ReminderScheduler) SendReminders(ctx context.Context) error {
+	stands, err := r.service.GetStands(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := r.service.now()
+	// Каналы доставки могут быть медленными, поэтому под блокировкой только выбираются
+	// напоминания к отправке.
+	type dueReminder struct {
+		stand models.Stand
+		keys  []reminderKey
+	}
+	var reminders []dueReminder
+	r.mu.Lock()
+	for key := range r.sent {
+		if key.endDate <= now.Unix() {
+			delete(r.sent, key)
+		}
+	}
+	for _, stand := range stands {
+		if !stand.IsBooked(now) || stand.Users == "" {
+			continue
+		}
+		remaining := time.Unix(stand.EndDate, 0).Sub(now)
+		var due []reminderKey
+		for _, offset := range r.offsets {
+			if remaining <= offset {
+				due = append(due, reminderKey{standID: stand.ID, user: stand.Users, endDate: stand.EndDate, offset: offset})
+			}
+		}
+		if len(due) > 0 && !r.sent[due[0]] {
+			reminders = append(reminders, dueReminder{stand: stand, keys: due})
+		}
+	}
+	r.mu.Unlock()
+
+	for _, reminder := range reminders {
+		if !r.remind(ctx, reminder.stand, reminder.keys[0].offset) {
+			continue
+		}
+		r.mu.Lock()
+		for _, key := range reminder.keys {
+			r.sent[key] = true
+		}
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// remind отправляет напоминание во все каналы и сообщает, доставил ли его хотя бы один.
+// Ошибка одного канала не мешает остальным.
+func (r *ReminderScheduler) remind(ctx context.Context, stand models.Stand, offset time.Duration) bool {
+	until := time.Unix(stand.EndDate, 0).Add(r.extendBy).Unix()
+	reminder := models.Reminder{
+		Stand:       stand,
+		User:        stand.Users,
+		Offset:      int64(offset / time.Second),
+		ExtendUntil: until,
+		ExtendToken: r.extendToken(extendClaims{StandID: stand.ID, User: stand.Users, Until: until}),
+	}
+	if r.baseURL != "" {
+		reminder.ExtendURL = r.baseURL + "/reminders/extend?token=" + url.QueryEscape(reminder.ExtendToken)
+	}
+
+	log.Printf("Напоминание пользователю %s: бронирование стенда %s истекает через %s", stand.Users, stand.Name, offset)
+	delivered := false
+	for _, channel := range r.channels {
+		if err := channel.Remind(ctx, reminder); err != nil {
+			log.Printf("Ошибка отправки напоминания пользователю %s о стенде %s: %v", stand.Users, stand.Name, err)
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+// extendClaims - содержимое токена продления бронирования.
+type extendClaims struct {
+	StandID int64  `json:"standId"`
+	User    string `json:"user"`
+	Until   int64  `json:"until"`
+}
+
+// extendToken подписывает claims: токен состоит из JSON claims и HMAC-SHA256 подписи в base64url.
+func (r *ReminderScheduler) extendToken(claims extendClaims) string {
+	data, _ := json.Marshal(claims)
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write(data)
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ExtendWithToken продлевает бронирование по токену из напоминания. Токен действует,
+// пока бронирование принадлежит тому же пользователю и еще не продлено дальше.
+// Возвращает id стенда и новую дату окончания бронирования.
+func (r *ReminderScheduler) ExtendWithToken(ctx context.Context, token string) (int64, time.Time, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, time.Time{}, ErrInvalidExtendToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidExtendToken
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidExtendToken
+	}
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write(data)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return 0, time.Time{}, ErrInvalidExtendToken
+	}
+	var claims extendClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return 0, time.Time{}, ErrInvalidExtendToken
+	}
+
+	// Токен сам подтверждает пользователя, поэтому продление выполняется от его имени.
+	until := time.Unix(claims.Until, 0)
+	if err := r.service.extend(ctx, claims.StandID, claims.User, until); err != nil {
+		return 0, time.Time{}, err
+	}
+	return claims.StandID, until, nil
+}
@@ -0,0 +1,132 @@
+package standservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// MockReminderChannel - это мок для канала напоминаний.
+type MockReminderChannel struct {
+	reminders  []models.Reminder
+	RemindFunc func(ctx context.Context, reminder models.Reminder) error
+}
+
+func (m *MockReminderChannel) Remind(ctx context.Context, reminder models.Reminder) error {
+	m.reminders = append(m.reminders, reminder)
+	if m.RemindFunc != nil {
+		return m.RemindFunc(ctx, reminder)
+	}
+	return nil
+}
+
+func TestReminderScheduler(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	options := ReminderOptions{
+		Offsets:  []time.Duration{time.Hour, 24 * time.Hour},
+		ExtendBy: 24 * time.Hour,
+		Secret:   "secret",
+		BaseURL:  "https://booking.example.com/",
+	}
+
+	t.Run("напоминания по каждому интервалу один раз", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(30 * time.Hour).Unix()}, now)
+		channel := &MockReminderChannel{}
+		scheduler, err := NewReminderScheduler(service, options, channel)
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		scheduler.SendReminders(context.Background())
+		if len(channel.reminders) != 0 {
+			t.Fatalf("Рано для напоминания, получено %+v", channel.reminders)
+		}
+
+		service.now = func() time.Time { return now.Add(7 * time.Hour) }
+		scheduler.SendReminders(context.Background())
+		scheduler.SendReminders(context.Background())
+		service.now = func() time.Time { return now.Add(29*time.Hour + 30*time.Minute) }
+		scheduler.SendReminders(context.Background())
+
+		if len(channel.reminders) != 2 || channel.reminders[0].Offset != 86400 || channel.reminders[1].Offset != 3600 {
+			t.Fatalf("Ожидались напоминания за 24 часа и за час, получено %+v", channel.reminders)
+		}
+		reminder := channel.reminders[1]
+		if reminder.User != "Иванов" || reminder.ExtendUntil != now.Add(54*time.Hour).Unix() ||
+			reminder.ExtendURL != "https://booking.example.com/reminders/extend?token="+reminder.ExtendToken {
+			t.Errorf("Неожиданное напоминание: %+v", reminder)
+		}
+	})
+
+	t.Run("после перезапуска отправляется только ближайшее напоминание", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(30 * time.Minute).Unix()}, now)
+		channel := &MockReminderChannel{}
+		scheduler, _ := NewReminderScheduler(service, options, channel)
+
+		scheduler.SendReminders(context.Background())
+		if len(channel.reminders) != 1 || channel.reminders[0].Offset != 3600 {
+			t.Errorf("Ожидалось одно напоминание за час, получено %+v", channel.reminders)
+		}
+	})
+
+	t.Run("недоставленное напоминание отправляется повторно", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(30 * time.Minute).Unix()}, now)
+		var scheduler *ReminderScheduler
+		failing := &MockReminderChannel{RemindFunc: func(ctx context.Context, reminder models.Reminder) error {
+			// Доставка не должна выполняться под блокировкой планировщика.
+			if !scheduler.mu.TryLock() {
+				t.Error("Напоминание доставляется под блокировкой планировщика")
+			} else {
+				scheduler.mu.Unlock()
+			}
+			return errors.New("канал недоступен")
+		}}
+		scheduler, _ = NewReminderScheduler(service, options, failing)
+
+		scheduler.SendReminders(context.Background())
+		scheduler.SendReminders(context.Background())
+		if len(failing.reminders) != 2 {
+			t.Errorf("Ожидалась повторная отправка недоставленного напоминания, получено %d", len(failing.reminders))
+		}
+
+		// Напоминание считается отправленным, если его доставил хотя бы один канал.
+		channel := &MockReminderChannel{}
+		scheduler, _ = NewReminderScheduler(service, options, failing, channel)
+		scheduler.SendReminders(context.Background())
+		scheduler.SendReminders(context.Background())
+		if len(channel.reminders) != 1 {
+			t.Errorf("Ожидалось одно напоминание, получено %d", len(channel.reminders))
+		}
+	})
+
+	t.Run("продление по токену", func(t *testing.T) {
+		stand := models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(30 * time.Minute).Unix(), Version: 3}
+		service, saved, _ := newBookingTestService(stand, now)
+		channel := &MockReminderChannel{}
+		scheduler, _ := NewReminderScheduler(service, options, channel)
+		scheduler.SendReminders(context.Background())
+		token := channel.reminders[0].ExtendToken
+
+		id, until, err := scheduler.ExtendWithToken(context.Background(), token)
+		if err != nil || id != 41 || until.Unix() != channel.reminders[0].ExtendUntil {
+			t.Fatalf("Ожидалось продление стенда 41, получено %d %s %v", id, until, err)
+		}
+		if updated, _ := stand.Apply(*saved); updated.EndDate != until.Unix() {
+			t.Errorf("Ожидалось сохранение новой даты окончания, получено %+v", updated)
+		}
+
+		other, _ := NewReminderScheduler(service, ReminderOptions{Secret: "other"})
+		for name, forged := range map[string]string{
+			"чужая подпись": other.extendToken(extendClaims{StandID: 41, User: "Иванов", Until: until.Unix()}),
+			"измененный":    "x" + token,
+			"без подписи":   "abc",
+		} {
+			if _, _, err := scheduler.ExtendWithToken(context.Background(), forged); !errors.Is(err, ErrInvalidExtendToken) {
+				t.Errorf("%s: ожидалась ошибка ErrInvalidExtendToken, получено %v", name, err)
+			}
+		}
+	})
+}
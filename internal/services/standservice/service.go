@@ -2,24 +2,101 @@ package standservice
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
+
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
 )
 
+// maxPatchAttempts ограничивает число повторов патча без ожидаемой версии, если стенд
+// изменился между чтением состояния для журнала и записью.
+const maxPatchAttempts = 3
+
 // Repository определяет интерфейс для работы с хранилищем стендов.
 type Repository interface {
-	Patch(ctx context.Context, id string, standsData []byte) error
-	GetStands(ctx context.Context) ([]byte, error)
+	// Patch применяет патч к стенду и возвращает сохраненное состояние стенда. Если version
+	// не nil, стенд обновляется только при совпадении версии, иначе возвращается
+	// models.ErrVersionConflict.
+	Patch(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error)
+	// GetStands возвращает стенды, кроме архивных.
+	GetStands(ctx context.Context) ([]models.Stand, error)
+	// GetStand возвращает стенд по id, в том числе архивный.
+	GetStand(ctx context.Context, id int64) (*models.Stand, error)
+	// Create добавляет стенд и возвращает его с назначенным хранилищем id.
+	Create(ctx context.Context, stand models.Stand) (*models.Stand, error)
+	// Archive переносит стенд в архив на момент at и возвращает сохраненное состояние стенда.
+	// Версия проверяется так же, как в Patch. Архивный стенд нельзя изменить: Patch и Archive
+	// возвращают models.ErrStandArchived.
+	Archive(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error)
+}
+
+// ChangeSource определяет хранилище, которое сообщает об изменениях стендов,
+// сделанных в обход сервиса. Subscribe блокируется до отмены ctx.
+type ChangeSource interface {
+	Subscribe(ctx context.Context, onChange func(models.StandChange)) error
+}
+
+// StandQuerier определяет хранилище, которое само отбирает, упорядочивает и разбивает
+// на страницы стенды по запросу. Остальные хранилища отдают все стенды, а запрос
+// применяется в сервисе.
+type StandQuerier interface {
+	// QueryStands возвращает страницу неархивных стендов и общее число подходящих стендов.
+	QueryStands(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error)
 }
 
 // Notifier определяет интерфейс для отправки уведомлений.
 type Notifier interface {
-	Broadcast(message []byte)
+	// Broadcast рассылает полное состояние всех стендов.
+	Broadcast(stands []models.Stand)
+	// StandChanged рассылает новое состояние одного стенда.
+	StandChanged(stand models.Stand)
+	// StandRemoved сообщает об удалении стенда.
+	StandRemoved(id int64)
+}
+
+// Notifiers рассылает уведомления всем своим получателям по порядку.
+type Notifiers []Notifier
+
+// Broadcast рассылает полное состояние всех стендов каждому получателю.
+func (n Notifiers) Broadcast(stands []models.Stand) {
+	for _, notifier := range n {
+		notifier.Broadcast(stands)
+	}
+}
+
+// StandChanged рассылает новое состояние стенда каждому получателю.
+func (n Notifiers) StandChanged(stand models.Stand) {
+	for _, notifier := range n {
+		notifier.StandChanged(stand)
+	}
+}
+
+// StandRemoved сообщает об удалении стенда каждому получателю.
+func (n Notifiers) StandRemoved(id int64) {
+	for _, notifier := range n {
+		notifier.StandRemoved(id)
+	}
+}
+
+// AuditStore определяет хранилище журнала изменений стендов. Записи только добавляются.
+type AuditStore interface {
+	Append(ctx context.Context, entry models.AuditEntry) error
+	Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
 }
 
 // StandService предоставляет бизнес-логику для управления стендами.
 type StandService struct {
 	repo     Repository
 	notifier Notifier
+	waitlist *waitlist
+	audit    AuditStore
+	history  *bookingHistory
+	known    *knownStates
+	now      func() time.Time
+	// authRequired - аутентификация обязательна, см. SetAuthRequired.
+	authRequired bool
 }
 
 // NewStandService создает новый экземпляр StandService.
@@ -27,31 +104,226 @@ func NewStandService(repo Repository, notifier Notifier) *StandService {
 	return &StandService{
 		repo:     repo,
 		notifier: notifier,
+		waitlist: newWaitlist(),
+		known:    newKnownStates(),
+		now:      time.Now,
+	}
+}
+
+// SetAuditStore включает запись изменений стендов в журнал store.
+func (s *StandService) SetAuditStore(store AuditStore) {
+	s.audit = store
+}
+
+// UpdateStand обновляет данные о стенде и уведомляет всех клиентов. Если version не nil,
+// обновление применяется только к этой версии стенда, иначе возвращается *models.ConflictError.
+func (s *StandService) UpdateStand(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return err
+	}
+	if err := patch.Validate(); err != nil {
+		log.Printf("Отклонен некорректный патч стенда %d: %v", standID, err)
+		return err
 	}
+	if err := s.authorizePatch(ctx, standID, patch); err != nil {
+		log.Printf("Отклонен патч стенда %d: %v", standID, err)
+		return err
+	}
+
+	return s.applyPatch(ctx, standID, actingUser(ctx, ""), version, patch)
 }
 
-// UpdateStand обновляет данные о стенде и уведомляет всех клиентов.
-func (s *StandService) UpdateStand(ctx context.Context, id string, data []byte) error {
-	if err := s.repo.Patch(ctx, id, data); err != nil {
+// applyPatch сохраняет патч стенда от имени actor и рассылает клиентам его новое состояние.
+func (s *StandService) applyPatch(ctx context.Context, id int64, actor string, version *int64, patch models.StandPatch) error {
+	if err := s.patch(ctx, id, actor, version, patch); err != nil {
+		return err
+	}
+
+	if err := s.broadcastStand(ctx, id); err != nil {
+		return err
+	}
+
+	log.Println("Стенд успешно обновлен и уведомления разосланы.")
+	return nil
+}
+
+// patch сохраняет патч стенда и записывает изменение от имени actor в журнал.
+// При конфликте версий дополняет ошибку актуальным состоянием стенда.
+func (s *StandService) patch(ctx context.Context, id int64, actor string, version *int64, patch models.StandPatch) error {
+	var err error
+	for attempt := 0; attempt < maxPatchAttempts; attempt++ {
+		// Для журнала патч записывается условно на прочитанную версию стенда: тогда она и есть
+		// состояние до изменения, а параллельное изменение дает конфликт версий, а не ложную запись.
+		before := s.auditSnapshot(ctx, id)
+		expected := version
+		if expected == nil && before != nil {
+			expected = &before.Version
+		}
+
+		var after *models.Stand
+		after, err = s.repo.Patch(ctx, id, expected, patch)
+		if err == nil {
+			s.known.observe(*after)
+			if before != nil {
+				s.recordChanges(ctx, *before, *after, actor)
+			}
+			return nil
+		}
+		if version != nil || !errors.Is(err, models.ErrVersionConflict) {
+			break
+		}
+	}
+	if !errors.Is(err, models.ErrVersionConflict) {
 		log.Printf("Ошибка обновления стенда в репозитории: %v", err)
 		return err
 	}
 
+	log.Printf("Конфликт версий при обновлении стенда %d: %v", id, err)
+	return s.conflictError(ctx, id)
+}
+
+// conflictError возвращает ошибку конфликта версий с актуальным состоянием стенда.
+func (s *StandService) conflictError(ctx context.Context, id int64) *models.ConflictError {
+	current, err := s.repo.GetStand(ctx, id)
+	if err != nil {
+		log.Printf("Ошибка получения актуального состояния стенда %d: %v", id, err)
+		return &models.ConflictError{}
+	}
+	withQueue := s.withQueues([]models.Stand{*current})
+	return &models.ConflictError{Current: &withQueue[0]}
+}
+
+// actingUser возвращает пользователя, от имени которого выполняется операция. Если запрос
+// аутентифицирован, это пользователь из токена, а переданный клиентом user игнорируется.
+func actingUser(ctx context.Context, user string) string {
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		return identity.User
+	}
+	return user
+}
+
+// broadcastStands рассылает клиентам актуальное состояние всех стендов.
+func (s *StandService) broadcastStands(ctx context.Context) error {
 	latestStands, err := s.repo.GetStands(ctx)
 	if err != nil {
 		log.Printf("Ошибка получения актуального состояния стендов: %v", err)
 		return err
 	}
 
+	for _, stand := range latestStands {
+		s.known.observe(stand)
+		s.recordHistory(ctx, stand)
+	}
 	if s.notifier != nil {
-		s.notifier.Broadcast(latestStands)
+		s.notifier.Broadcast(s.withQueues(latestStands))
 	}
+	return nil
+}
 
-	log.Println("Стенд успешно обновлен и уведомления разосланы.")
+// broadcastStand рассылает клиентам актуальное состояние одного стенда.
+func (s *StandService) broadcastStand(ctx context.Context, id int64) error {
+	stand, err := s.repo.GetStand(ctx, id)
+	if err != nil {
+		log.Printf("Ошибка получения актуального состояния стенда %d: %v", id, err)
+		return err
+	}
+
+	s.known.observe(*stand)
+	s.recordHistory(ctx, *stand)
+	s.notifyStandChanged(*stand)
 	return nil
 }
 
+// notifyStandChanged дополняет стенд очередью и рассылает его клиентам.
+func (s *StandService) notifyStandChanged(stand models.Stand) {
+	if s.notifier != nil {
+		s.notifier.StandChanged(s.withQueues([]models.Stand{stand})[0])
+	}
+}
+
+// GetStands возвращает текущее состояние всех стендов вместе с очередями.
+func (s *StandService) GetStands(ctx context.Context) ([]models.Stand, error) {
+	stands, err := s.repo.GetStands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.withQueues(stands), nil
+}
+
+// QueryStands возвращает страницу неархивных стендов по запросу и общее число подходящих
+// стендов. Занятость стендов определяется на текущий момент.
+func (s *StandService) QueryStands(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error) {
+	query.Now = s.now().Unix()
+	if querier, ok := s.repo.(StandQuerier); ok {
+		stands, total, err := querier.QueryStands(ctx, query)
+		if err != nil {
+			return nil, 0, err
+		}
+		return s.withQueues(stands), total, nil
+	}
+
+	stands, err := s.repo.GetStands(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := query.Apply(stands)
+	return s.withQueues(page), total, nil
+}
+
+// GetStand возвращает стенд по id вместе с очередью на него, в том числе архивный.
+func (s *StandService) GetStand(ctx context.Context, id string) (*models.Stand, error) {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return nil, err
+	}
+	stand, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+	stand.Queue = s.waitlist.entries(stand.ID)
+	return stand, nil
+}
+
 // GetInitialStands возвращает начальное состояние стендов для нового клиента.
-func (s *StandService) GetInitialStands(ctx context.Context) ([]byte, error) {
-	return s.repo.GetStands(ctx)
+func (s *StandService) GetInitialStands(ctx context.Context) ([]models.Stand, error) {
+	return s.GetStands(ctx)
+}
+
+// WatchChanges рассылает клиентам изменения стендов, полученные из source,
+// пока не будет отменен ctx. Изменения отдельных стендов рассылаются точечно,
+// после переподключения к source клиенты получают полное состояние.
+func (s *StandService) WatchChanges(ctx context.Context, source ChangeSource) {
+	err := source.Subscribe(ctx, func(change models.StandChange) {
+		log.Printf("Получено внешнее изменение стенда: %s id %d", change.Type, change.Stand.ID)
+		switch change.Type {
+		case models.ChangeInsert, models.ChangeUpdate:
+			// Realtime присылает и изменения, которые сделал сам сервис: они уже записаны
+			// в историю и разосланы клиентам, поэтому повторно не обрабатываются.
+			if !s.known.observe(change.Stand) {
+				log.Printf("Пропущено эхо изменения стенда %d версии %d", change.Stand.ID, change.Stand.Version)
+				return
+			}
+			if change.Stand.IsArchived() {
+				if s.notifier != nil {
+					s.notifier.StandRemoved(change.Stand.ID)
+				}
+				return
+			}
+			s.recordHistory(ctx, change.Stand)
+			s.notifyStandChanged(change.Stand)
+		case models.ChangeDelete:
+			s.known.forget(change.Stand.ID)
+			if s.notifier != nil {
+				s.notifier.StandRemoved(change.Stand.ID)
+			}
+		default:
+			if err := s.broadcastStands(ctx); err != nil {
+				log.Printf("Ошибка рассылки внешнего изменения стенда: %v", err)
+			}
+		}
+	})
+	if err != nil {
+		log.Printf("Ошибка подписки на изменения стендов: %v", err)
+	}
 }
@@ -2,87 +2,333 @@ package standservice
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
 )
 
 // MockRepository - это мок для репозитория.
 type MockRepository struct {
-	PatchFunc     func(ctx context.Context, id string, data []byte) error
-	GetStandsFunc func(ctx context.Context) ([]byte, error)
+	PatchFunc     func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error)
+	GetStandsFunc func(ctx context.Context) ([]models.Stand, error)
+	GetStandFunc  func(ctx context.Context, id int64) (*models.Stand, error)
+	CreateFunc    func(ctx context.Context, stand models.Stand) (*models.Stand, error)
+	ArchiveFunc   func(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error)
 }
 
-func (m *MockRepository) Patch(ctx context.Context, id string, data []byte) error {
+func (m *MockRepository) Patch(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
 	if m.PatchFunc != nil {
-		return m.PatchFunc(ctx, id, data)
+		return m.PatchFunc(ctx, id, version, patch)
 	}
-	return nil
+	return &models.Stand{ID: id, Name: "initial"}, nil
 }
 
-func (m *MockRepository) GetStands(ctx context.Context) ([]byte, error) {
+func (m *MockRepository) GetStands(ctx context.Context) ([]models.Stand, error) {
 	if m.GetStandsFunc != nil {
 		return m.GetStandsFunc(ctx)
 	}
-	return []byte("initial data"), nil
+	return []models.Stand{{ID: 1, Name: "initial"}}, nil
+}
+
+func (m *MockRepository) GetStand(ctx context.Context, id int64) (*models.Stand, error) {
+	if m.GetStandFunc != nil {
+		return m.GetStandFunc(ctx, id)
+	}
+	return &models.Stand{ID: 1, Name: "initial"}, nil
+}
+
+func (m *MockRepository) Create(ctx context.Context, stand models.Stand) (*models.Stand, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, stand)
+	}
+	stand.ID = 2
+	return &stand, nil
+}
+
+func (m *MockRepository) Archive(ctx context.Context, id int64, version *int64, at time.Time) (*models.Stand, error) {
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(ctx, id, version, at)
+	}
+	archivedAt := at.Unix()
+	return &models.Stand{ID: id, Name: "initial", ArchivedAt: &archivedAt}, nil
 }
 
 // MockNotifier - это мок для уведомителя.
 type MockNotifier struct {
-	BroadcastFunc   func(message []byte)
-	broadcastCalled bool
-	lastMessage     []byte
+	BroadcastFunc    func(stands []models.Stand)
+	StandChangedFunc func(stand models.Stand)
+	StandRemovedFunc func(id int64)
+	broadcastCalled  bool
+	lastStands       []models.Stand
+	changed          []models.Stand
+	removed          []int64
 }
 
-func (m *MockNotifier) Broadcast(message []byte) {
+func (m *MockNotifier) Broadcast(stands []models.Stand) {
 	m.broadcastCalled = true
-	m.lastMessage = message
+	m.lastStands = stands
 	if m.BroadcastFunc != nil {
-		m.BroadcastFunc(message)
+		m.BroadcastFunc(stands)
+	}
+}
+
+func (m *MockNotifier) StandChanged(stand models.Stand) {
+	m.changed = append(m.changed, stand)
+	if m.StandChangedFunc != nil {
+		m.StandChangedFunc(stand)
+	}
+}
+
+func (m *MockNotifier) StandRemoved(id int64) {
+	m.removed = append(m.removed, id)
+	if m.StandRemovedFunc != nil {
+		m.StandRemovedFunc(id)
 	}
 }
 
 func TestStandService_UpdateStand(t *testing.T) {
 	t.Run("успешное обновление и уведомление", func(t *testing.T) {
 		repo := &MockRepository{
-			GetStandsFunc: func(ctx context.Context) ([]byte, error) {
-				return []byte("updated data"), nil
+			GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+				return &models.Stand{ID: 1, Name: "updated"}, nil
 			},
 		}
 		notifier := &MockNotifier{}
 		service := NewStandService(repo, notifier)
 
-		err := service.UpdateStand(context.Background(), "stand1", []byte(`{"status": "occupied"}`))
+		err := service.UpdateStand(context.Background(), "1", nil, models.StandPatch{"users": json.RawMessage(`"Иванов"`)})
 
 		if err != nil {
 			t.Errorf("Ожидалась ошибка nil, получено %v", err)
 		}
 
-		if !notifier.broadcastCalled {
-			t.Error("Ожидался вызов Broadcast, но он не был вызван")
+		if len(notifier.changed) != 1 || notifier.changed[0].Name != "updated" {
+			t.Errorf("Ожидалась рассылка стенда 'updated', получено %+v", notifier.changed)
 		}
 
-		if string(notifier.lastMessage) != "updated data" {
-			t.Errorf("Ожидалось сообщение 'updated data', получено '%s'", string(notifier.lastMessage))
+		if notifier.broadcastCalled {
+			t.Error("Полное состояние не должно рассылаться при изменении одного стенда")
 		}
 	})
 
 	t.Run("ошибка при обновлении в репозитории", func(t *testing.T) {
 		repo := &MockRepository{
-			PatchFunc: func(ctx context.Context, id string, data []byte) error {
-				return errors.New("repo update error")
+			PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+				return nil, errors.New("repo update error")
 			},
 		}
 		notifier := &MockNotifier{}
 		service := NewStandService(repo, notifier)
 
-		err := service.UpdateStand(context.Background(), "stand1", []byte(`{"status": "occupied"}`))
+		err := service.UpdateStand(context.Background(), "1", nil, models.StandPatch{"users": json.RawMessage(`"Иванов"`)})
 
 		if err == nil {
 			t.Error("Ожидалась ошибка, но получено nil")
 		}
 
-		if notifier.broadcastCalled {
-			t.Error("Broadcast не должен был вызываться при ошибке обновления")
+		if len(notifier.changed) != 0 {
+			t.Error("StandChanged не должен был вызываться при ошибке обновления")
+		}
+	})
+
+	t.Run("некорректный патч не доходит до репозитория", func(t *testing.T) {
+		patched := false
+		repo := &MockRepository{
+			PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+				patched = true
+				return &models.Stand{ID: id}, nil
+			},
+		}
+		service := NewStandService(repo, &MockNotifier{})
+
+		err := service.UpdateStand(context.Background(), "1", nil, models.StandPatch{"status": json.RawMessage(`"occupied"`)})
+
+		if !errors.Is(err, models.ErrInvalidPatch) {
+			t.Errorf("Ожидалась ошибка ErrInvalidPatch, получено %v", err)
+		}
+		if patched {
+			t.Error("Patch не должен был вызываться для некорректного патча")
+		}
+	})
+
+	t.Run("id с оператором PostgREST не доходит до репозитория", func(t *testing.T) {
+		repo := &MockRepository{
+			PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+				t.Errorf("Patch не должен был вызываться, вызван для стенда %d", id)
+				return &models.Stand{ID: id}, nil
+			},
+		}
+		service := NewStandService(repo, &MockNotifier{})
+
+		err := service.UpdateStand(context.Background(), "gt.0", nil, models.StandPatch{"users": json.RawMessage(`"Иванов"`)})
+
+		if !errors.Is(err, models.ErrInvalidStandID) {
+			t.Errorf("Ожидалась ошибка ErrInvalidStandID, получено %v", err)
+		}
+	})
+
+	t.Run("конфликт версий возвращает актуальное состояние стенда", func(t *testing.T) {
+		repo := &MockRepository{
+			PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+				if version == nil || *version != 2 {
+					t.Errorf("Ожидалась версия 2, получено %v", version)
+				}
+				return nil, models.ErrVersionConflict
+			},
+			GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+				return &models.Stand{ID: 41, Name: "alpha", Users: "Петров", Version: 3}, nil
+			},
+		}
+		notifier := &MockNotifier{}
+		service := NewStandService(repo, notifier)
+
+		version := int64(2)
+		err := service.UpdateStand(context.Background(), "41", &version, models.StandPatch{"users": json.RawMessage(`"Иванов"`)})
+
+		var conflict *models.ConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, models.ErrVersionConflict) {
+			t.Fatalf("Ожидалась ошибка ConflictError, получено %v", err)
+		}
+		if conflict.Current == nil || conflict.Current.Version != 3 || conflict.Current.Users != "Петров" {
+			t.Errorf("Ожидалось актуальное состояние стенда, получено %+v", conflict.Current)
+		}
+		if len(notifier.changed) != 0 {
+			t.Error("StandChanged не должен был вызываться при конфликте версий")
+		}
+	})
+}
+
+// MockChangeSource - это мок для источника внешних изменений стендов.
+type MockChangeSource struct {
+	changes []models.StandChange
+}
+
+func (m *MockChangeSource) Subscribe(ctx context.Context, onChange func(models.StandChange)) error {
+	for _, change := range m.changes {
+		onChange(change)
+	}
+	return nil
+}
+
+func TestStandService_WatchChanges(t *testing.T) {
+	broadcasts := 0
+	notifier := &MockNotifier{BroadcastFunc: func(stands []models.Stand) { broadcasts++ }}
+	service := NewStandService(&MockRepository{}, notifier)
+
+	service.WatchChanges(context.Background(), &MockChangeSource{changes: []models.StandChange{
+		{Type: models.ChangeInsert, Stand: models.Stand{ID: 1}},
+		{Type: models.ChangeUpdate, Stand: models.Stand{ID: 2, Name: "beta"}},
+		{Type: models.ChangeDelete, Stand: models.Stand{ID: 3}},
+		{Type: models.ChangeResync},
+	}})
+
+	if len(notifier.changed) != 2 || notifier.changed[1].Name != "beta" {
+		t.Errorf("Ожидалась точечная рассылка двух стендов, получено %+v", notifier.changed)
+	}
+	if len(notifier.removed) != 1 || notifier.removed[0] != 3 {
+		t.Errorf("Ожидалось уведомление об удалении стенда 3, получено %v", notifier.removed)
+	}
+	if broadcasts != 1 {
+		t.Errorf("Ожидалась одна полная рассылка после переподключения, получено %d", broadcasts)
+	}
+}
+
+func TestStandService_WatchChangesEcho(t *testing.T) {
+	stand := models.Stand{ID: 41, Name: "alpha", Version: 7}
+	repo := &MockRepository{
+		GetStandFunc: func(ctx context.Context, id int64) (*models.Stand, error) {
+			current := stand
+			return &current, nil
+		},
+		PatchFunc: func(ctx context.Context, id int64, version *int64, patch models.StandPatch) (*models.Stand, error) {
+			updated, err := stand.Apply(patch)
+			updated.Version++
+			stand = updated
+			return &updated, err
+		},
+	}
+	notifier := &MockNotifier{}
+	service := NewStandService(repo, notifier)
+
+	if err := service.UpdateStand(context.Background(), "41", nil, models.StandPatch{"comment": json.RawMessage(`"релиз"`)}); err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	echo := stand
+	// Внешние редакторы таблицы не увеличивают version.
+	external := stand
+	external.Comment = "внешнее"
+	repeated := external
+	repeated.Users = "Иванов"
+	service.WatchChanges(context.Background(), &MockChangeSource{changes: []models.StandChange{
+		{Type: models.ChangeUpdate, Stand: echo},
+		{Type: models.ChangeUpdate, Stand: models.Stand{ID: 41, Name: "alpha", Version: 7}},
+		{Type: models.ChangeUpdate, Stand: external},
+		{Type: models.ChangeUpdate, Stand: repeated},
+	}})
+
+	if len(notifier.changed) != 3 || notifier.changed[1].Comment != "внешнее" || notifier.changed[2].Users != "Иванов" {
+		t.Errorf("Ожидалась рассылка только внешних изменений той же версии, получено %+v", notifier.changed)
+	}
+}
+
+// MockQueryRepository - это мок хранилища, которое само выбирает стенды по запросу.
+type MockQueryRepository struct {
+	MockRepository
+	QueryStandsFunc func(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error)
+}
+
+func (m *MockQueryRepository) QueryStands(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error) {
+	return m.QueryStandsFunc(ctx, query)
+}
+
+func TestStandService_QueryStands(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	stands := []models.Stand{
+		{ID: 1, Name: "alpha", Users: "Иванов", EndDate: now.Unix() + 3600},
+		{ID: 2, Name: "beta", EndDate: now.Unix() - 60},
+	}
+
+	t.Run("запрос применяется к стендам хранилища", func(t *testing.T) {
+		repo := &MockRepository{
+			GetStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+				return stands, nil
+			},
+		}
+		service := NewStandService(repo, nil)
+		service.now = func() time.Time { return now }
+
+		page, total, err := service.QueryStands(context.Background(), models.StandQuery{Status: models.StandStatusFree})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if len(page) != 1 || page[0].ID != 2 || total != 1 {
+			t.Errorf("Ожидался свободный стенд beta, получено %+v из %d", page, total)
+		}
+	})
+
+	t.Run("запрос передается хранилищу", func(t *testing.T) {
+		var received models.StandQuery
+		repo := &MockQueryRepository{
+			QueryStandsFunc: func(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error) {
+				received = query
+				return stands[:1], 5, nil
+			},
+		}
+		service := NewStandService(repo, nil)
+		service.now = func() time.Time { return now }
+
+		page, total, err := service.QueryStands(context.Background(), models.StandQuery{BookedBy: "Иванов", Limit: 1})
+		if err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		if len(page) != 1 || total != 5 {
+			t.Errorf("Ожидалась страница хранилища, получено %+v из %d", page, total)
+		}
+		if received.Now != now.Unix() || received.BookedBy != "Иванов" || received.Limit != 1 {
+			t.Errorf("Неожиданный запрос к хранилищу: %+v", received)
 		}
 	})
 }
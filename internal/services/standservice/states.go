@@ -0,0 +1,45 @@
+package standservice
+
+import (
+	"sync"
+
+	"mts/booking_service/internal/models"
+)
+
+// knownStates хранит последнее состояние каждого стенда, которое сервис записал или разослал
+// клиентам. По нему WatchChanges отличает эхо собственных изменений сервиса от внешних:
+// внешние редакторы таблицы не обязаны увеличивать version.
+type knownStates struct {
+	mu     sync.Mutex
+	stands map[int64]models.Stand
+}
+
+func newKnownStates() *knownStates {
+	return &knownStates{stands: make(map[int64]models.Stand)}
+}
+
+// observe запоминает состояние стенда и сообщает, новое ли оно: устаревшая версия
+// или совпадение с уже известным состоянием той же версии считаются эхом.
+func (k *knownStates) observe(stand models.Stand) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if last, ok := k.stands[stand.ID]; ok {
+		if stand.Version < last.Version {
+			return false
+		}
+		if stand.Version == last.Version && len(models.Diff(last, stand)) == 0 {
+			return false
+		}
+	}
+	k.stands[stand.ID] = stand.Clone()
+	return true
+}
+
+// forget удаляет состояние стенда, например после его удаления из хранилища.
+func (k *knownStates) forget(standID int64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.stands, standID)
+}
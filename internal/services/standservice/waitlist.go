@@ -0,0 +1,213 @@
+package standservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// defaultQueueBookingDuration используется, если пользователь встал в очередь без желаемой длительности.
+const defaultQueueBookingDuration = 24 * time.Hour
+
+var (
+	// ErrStandFree возвращается при попытке встать в очередь на свободный стенд.
+	ErrStandFree = errors.New("стенд свободен, его можно забронировать")
+	// ErrAlreadyQueued возвращается, если пользователь уже стоит в очереди или занимает стенд.
+	ErrAlreadyQueued = errors.New("пользователь уже в очереди на стенд")
+	// ErrNotQueued возвращается, если пользователя нет в очереди на стенд.
+	ErrNotQueued = errors.New("пользователь не стоит в очереди на стенд")
+)
+
+// waitlist хранит очереди на стенды в памяти процесса.
+type waitlist struct {
+	mu     sync.Mutex
+	queues map[int64][]models.QueueEntry
+}
+
+func newWaitlist() *waitlist {
+	return &waitlist{queues: make(map[int64][]models.QueueEntry)}
+}
+
+// add добавляет пользователя в конец очереди и возвращает его позицию, начиная с 1.
+func (w *waitlist) add(standID int64, entry models.QueueEntry) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if indexOf(w.queues[standID], entry.User) >= 0 {
+		return 0, ErrAlreadyQueued
+	}
+	w.queues[standID] = append(w.queues[standID], entry)
+	return len(w.queues[standID]), nil
+}
+
+// remove убирает пользователя из очереди.
+func (w *waitlist) remove(standID int64, user string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	queue := w.queues[standID]
+	i := indexOf(queue, user)
+	if i < 0 {
+		return ErrNotQueued
+	}
+	queue = append(queue[:i:i], queue[i+1:]...)
+	if len(queue) == 0 {
+		delete(w.queues, standID)
+	} else {
+		w.queues[standID] = queue
+	}
+	return nil
+}
+
+// peek возвращает первого в очереди, не удаляя его.
+func (w *waitlist) peek(standID int64) (models.QueueEntry, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	queue := w.queues[standID]
+	if len(queue) == 0 {
+		return models.QueueEntry{}, false
+	}
+	return queue[0], true
+}
+
+// entries возвращает копию очереди на стенд.
+func (w *waitlist) entries(standID int64) []models.QueueEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]models.QueueEntry(nil), w.queues[standID]...)
+}
+
+// clear удаляет очередь на стенд.
+func (w *waitlist) clear(standID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.queues, standID)
+}
+
+func indexOf(queue []models.QueueEntry, user string) int {
+	for i, entry := range queue {
+		if models.SameUser(entry.User, user) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Enqueue ставит пользователя в очередь на занятый стенд и возвращает его позицию, начиная с 1.
+// Когда стенд освободится, он будет автоматически забронирован за первым в очереди на duration.
+func (s *StandService) Enqueue(ctx context.Context, id, user, reason string, duration time.Duration) (int, error) {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return 0, err
+	}
+	user = actingUser(ctx, user)
+	if strings.TrimSpace(user) == "" {
+		return 0, fmt.Errorf("%w: не указан пользователь", ErrInvalidBooking)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%w: отрицательная длительность бронирования", ErrInvalidBooking)
+	}
+	if duration == 0 {
+		duration = defaultQueueBookingDuration
+	}
+
+	stand, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return 0, err
+	}
+	if !stand.IsBooked(s.now()) {
+		return 0, fmt.Errorf("%w: %s", ErrStandFree, stand.Name)
+	}
+	if stand.IsBookedBy(user) {
+		return 0, fmt.Errorf("%w: %s уже занят этим пользователем", ErrAlreadyQueued, stand.Name)
+	}
+
+	position, err := s.waitlist.add(stand.ID, models.QueueEntry{
+		User:       user,
+		Reason:     reason,
+		Duration:   int64(duration / time.Second),
+		EnqueuedAt: s.now().Unix(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, stand.Name)
+	}
+
+	log.Printf("Пользователь %s встал в очередь на стенд %s, позиция %d", user, stand.Name, position)
+	return position, s.broadcastStand(ctx, standID)
+}
+
+// Dequeue убирает пользователя из очереди на стенд.
+func (s *StandService) Dequeue(ctx context.Context, id, user string) error {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return err
+	}
+	user = actingUser(ctx, user)
+	stand, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return err
+	}
+	if err := s.waitlist.remove(stand.ID, user); err != nil {
+		return fmt.Errorf("%w: %s", err, stand.Name)
+	}
+
+	log.Printf("Пользователь %s покинул очередь на стенд %s", user, stand.Name)
+	return s.broadcastStand(ctx, standID)
+}
+
+// GetQueue возвращает очередь на стенд.
+func (s *StandService) GetQueue(ctx context.Context, id string) ([]models.QueueEntry, error) {
+	standID, err := models.ParseStandID(id)
+	if err != nil {
+		return nil, err
+	}
+	stand, err := s.repo.GetStand(ctx, standID)
+	if err != nil {
+		return nil, err
+	}
+	return s.waitlist.entries(stand.ID), nil
+}
+
+// handoverPatch формирует патч освобождения стенда: если в очереди кто-то есть,
+// стенд сразу бронируется за первым в очереди, иначе поля бронирования очищаются
+// и endDate устанавливается в releasedAt. Второе значение - получатель стенда из очереди.
+func (s *StandService) handoverPatch(standID int64, releasedAt int64) (models.StandPatch, *models.QueueEntry) {
+	next, ok := s.waitlist.peek(standID)
+	if !ok {
+		return bookingPatch("", "", releasedAt, s.now().Unix()), nil
+	}
+	now := s.now()
+	until := now.Add(time.Duration(next.Duration) * time.Second)
+	return bookingPatch(next.User, next.Reason, until.Unix(), now.Unix()), &next
+}
+
+// completeHandover убирает получившего стенд пользователя из очереди.
+func (s *StandService) completeHandover(stand models.Stand, next *models.QueueEntry) {
+	if next == nil {
+		return
+	}
+	if err := s.waitlist.remove(stand.ID, next.User); err != nil {
+		log.Printf("Ошибка удаления пользователя %s из очереди на стенд %s: %v", next.User, stand.Name, err)
+		return
+	}
+	log.Printf("Стенд %s передан следующему в очереди пользователю %s", stand.Name, next.User)
+}
+
+// withQueues дополняет стенды текущим состоянием очередей.
+func (s *StandService) withQueues(stands []models.Stand) []models.Stand {
+	for i := range stands {
+		if queue := s.waitlist.entries(stands[i].ID); len(queue) > 0 {
+			stands[i].Queue = queue
+		}
+	}
+	return stands
+}
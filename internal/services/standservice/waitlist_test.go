@@ -0,0 +1,74 @@
+package standservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+func TestStandService_Waitlist(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	booked := models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Unix() + 3600}
+
+	t.Run("очередь на занятый стенд", func(t *testing.T) {
+		service, _, notifier := newBookingTestService(booked, now)
+
+		position, err := service.Enqueue(context.Background(), "41", "Петров", "тесты", 2*time.Hour)
+		if err != nil || position != 1 {
+			t.Fatalf("Ожидалась позиция 1, получено %d, ошибка %v", position, err)
+		}
+		position, err = service.Enqueue(context.Background(), "41", "Сидоров", "", 0)
+		if err != nil || position != 2 {
+			t.Fatalf("Ожидалась позиция 2, получено %d, ошибка %v", position, err)
+		}
+		if _, err := service.Enqueue(context.Background(), "41", "петров", "", 0); !errors.Is(err, ErrAlreadyQueued) {
+			t.Errorf("Ожидалась ошибка ErrAlreadyQueued, получено %v", err)
+		}
+		if _, err := service.Enqueue(context.Background(), "41", "Иванов", "", 0); !errors.Is(err, ErrAlreadyQueued) {
+			t.Errorf("Владелец бронирования не должен вставать в очередь, получено %v", err)
+		}
+
+		last := notifier.changed[len(notifier.changed)-1]
+		if len(last.Queue) != 2 {
+			t.Errorf("Ожидалась очередь из двух человек в рассылке, получено %+v", last)
+		}
+
+		if err := service.Dequeue(context.Background(), "41", "Петров"); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+		queue, _ := service.GetQueue(context.Background(), "41")
+		if len(queue) != 1 || queue[0].User != "Сидоров" || queue[0].Duration != int64(defaultQueueBookingDuration/time.Second) {
+			t.Errorf("Неожиданная очередь после выхода: %+v", queue)
+		}
+	})
+
+	t.Run("на свободный стенд очередь не нужна", func(t *testing.T) {
+		service, _, _ := newBookingTestService(models.Stand{ID: 41, EndDate: now.Unix() - 1}, now)
+
+		if _, err := service.Enqueue(context.Background(), "41", "Петров", "", 0); !errors.Is(err, ErrStandFree) {
+			t.Errorf("Ожидалась ошибка ErrStandFree, получено %v", err)
+		}
+	})
+
+	t.Run("освобожденный стенд передается следующему в очереди", func(t *testing.T) {
+		service, saved, _ := newBookingTestService(booked, now)
+		if _, err := service.Enqueue(context.Background(), "41", "Петров", "тесты", 2*time.Hour); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		if err := service.Release(context.Background(), "41", "Иванов"); err != nil {
+			t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+		}
+
+		handedOver, _ := booked.Apply(*saved)
+		if handedOver.Users != "Петров" || handedOver.Reason != "тесты" || handedOver.EndDate != now.Add(2*time.Hour).Unix() {
+			t.Errorf("Стенд не передан следующему в очереди: %+v", handedOver)
+		}
+		if queue, _ := service.GetQueue(context.Background(), "41"); len(queue) != 0 {
+			t.Errorf("Получивший стенд пользователь должен покинуть очередь: %+v", queue)
+		}
+	})
+}
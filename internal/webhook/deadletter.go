@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DeadLetter - уведомление, которое не удалось доставить.
+type DeadLetter struct {
+	// Time - время последней попытки, Unix-время в секундах.
+	Time     int64           `json:"time"`
+	Endpoint string          `json:"endpoint"`
+	Event    string          `json:"event"`
+	StandID  int64           `json:"standId"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+}
+
+// deadLetterLog дописывает недоставленные уведомления в файл, по одной JSON-записи на строку.
+type deadLetterLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newDeadLetterLog(path string) (*deadLetterLog, error) {
+	if path == "" {
+		return &deadLetterLog{}, nil
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("ошибка создания каталога журнала недоставленных уведомлений: %w", err)
+		}
+	}
+	return &deadLetterLog{path: path}, nil
+}
+
+// write записывает недоставленное уведомление в лог приложения и в файл, если он задан.
+func (l *deadLetterLog) write(letter DeadLetter) {
+	log.Printf("Уведомление %s о стенде %d не доставлено получателю %s после %d попыток: %s",
+		letter.Event, letter.StandID, letter.Endpoint, letter.Attempts, letter.Error)
+	if l.path == "" {
+		return
+	}
+
+	line, err := json.Marshal(letter)
+	if err != nil {
+		log.Printf("Ошибка сериализации недоставленного уведомления: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("Ошибка открытия журнала недоставленных уведомлений: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("Ошибка записи в журнал недоставленных уведомлений: %v", err)
+	}
+}
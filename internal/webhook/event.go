@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// Типы событий стендов, о которых отправляются уведомления.
+const (
+	// EventCreated - стенд добавлен в хранилище.
+	EventCreated    = "created"
+	EventBooked     = "booked"
+	EventReleased   = "released"
+	EventExpiring   = "expiring"
+	EventRedeployed = "redeployed"
+	// EventReminder - напоминание владельцу о скором окончании бронирования со ссылкой продления.
+	EventReminder = "reminder"
+)
+
+var eventTypes = map[string]bool{
+	EventCreated:    true,
+	EventBooked:     true,
+	EventReleased:   true,
+	EventExpiring:   true,
+	EventRedeployed: true,
+	EventReminder:   true,
+}
+
+// Event - событие стенда, передаваемое в шаблон уведомления.
+type Event struct {
+	Type string
+	// Time - время события, Unix-время в секундах.
+	Time  int64
+	Stand models.Stand
+	// User - пользователь, к бронированию которого относится событие.
+	User string
+	// Components - компоненты, развернутые заново, для события redeployed.
+	Components []string
+	// ExtendUntil и ExtendURL - до какого момента и по какой ссылке можно продлить
+	// бронирование, для события reminder.
+	ExtendUntil int64
+	ExtendURL   string
+}
+
+// detectEvents сравнивает прежнее состояние стенда, известное на момент seen, с новым
+// и возвращает произошедшие события.
+func detectEvents(prev models.Stand, seen time.Time, cur models.Stand, now time.Time) []Event {
+	var events []Event
+	newEvent := func(eventType, user string) Event {
+		return Event{Type: eventType, Time: now.Unix(), Stand: cur, User: user}
+	}
+
+	// Освобождение - переход из занятого состояния в свободное. Бронирование, истекшее
+	// до прежнего состояния, уже было завершено, и его пользователь в поле users ничего не значит.
+	if prev.Users != "" && prev.IsBooked(seen) && !cur.IsBooked(now) {
+		events = append(events, newEvent(EventReleased, prev.Users))
+	}
+	if cur.Users != "" && cur.IsBooked(now) && (!prev.IsBooked(now) || !prev.IsBookedBy(cur.Users)) {
+		events = append(events, newEvent(EventBooked, cur.Users))
+	}
+	if components := redeployedComponents(prev, cur); len(components) > 0 {
+		event := newEvent(EventRedeployed, cur.Users)
+		event.Components = components
+		events = append(events, event)
+	}
+	return events
+}
+
+// redeployedComponents возвращает компоненты, у которых изменилась ветка или дата развертывания.
+func redeployedComponents(prev, cur models.Stand) []string {
+	var components []string
+	for name, deployment := range cur.Deployments {
+		before := prev.Deployments[name]
+		if deref(before.Branch) != deref(deployment.Branch) || derefInt(before.Date) != derefInt(deployment.Date) {
+			components = append(components, name)
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+// message возвращает текст уведомления. name - имя стенда, уже оформленное для формата получателя.
+func (e Event) message(name string) string {
+	switch e.Type {
+	case EventCreated:
+		return fmt.Sprintf("Добавлен стенд %s", name)
+	case EventBooked:
+		text := fmt.Sprintf("Стенд %s забронирован пользователем %s до %s", name, e.User, formatTime(e.Stand.EndDate))
+		if e.Stand.Reason != "" {
+			text += ", причина: " + e.Stand.Reason
+		}
+		return text
+	case EventReleased:
+		return fmt.Sprintf("Стенд %s освобожден, бронирование пользователя %s завершено", name, e.User)
+	case EventExpiring:
+		return fmt.Sprintf("Бронирование стенда %s пользователем %s истекает %s", name, e.User, formatTime(e.Stand.EndDate))
+	case EventReminder:
+		text := fmt.Sprintf("Напоминание: бронирование стенда %s пользователем %s истекает %s", name, e.User, formatTime(e.Stand.EndDate))
+		if e.ExtendURL != "" {
+			text += fmt.Sprintf(". Продлить до %s: %s", formatTime(e.ExtendUntil), e.ExtendURL)
+		}
+		return text
+	case EventRedeployed:
+		deployed := make([]string, 0, len(e.Components))
+		for _, component := range e.Components {
+			deployed = append(deployed, strings.TrimSpace(component+" "+deref(e.Stand.Deployments[component].Branch)))
+		}
+		return fmt.Sprintf("На стенд %s развернуты: %s", name, strings.Join(deployed, ", "))
+	default:
+		return fmt.Sprintf("Событие %s стенда %s", e.Type, name)
+	}
+}
+
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).Format("02.01.2006 15:04 MST")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,270 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+// queueSize - сколько уведомлений может ждать отправки одному получателю.
+const queueSize = 256
+
+// delivery - уведомление, ожидающее отправки получателю.
+type delivery struct {
+	event Event
+	body  []byte
+}
+
+// Notifier отправляет уведомления о событиях стендов во внешние webhook. Реализует
+// standservice.Notifier: события определяются сравнением нового состояния стенда с прежним.
+// Каждому получателю уведомления отправляются по порядку отдельной горутиной, поэтому
+// недоступный получатель не задерживает остальных и сервис стендов.
+type Notifier struct {
+	cfg        config.WebhooksConfig
+	client     *http.Client
+	endpoints  []*endpoint
+	queues     map[*endpoint]chan delivery
+	deadLetter *deadLetterLog
+	now        func() time.Time
+
+	mu     sync.Mutex
+	stands map[int64]models.Stand
+	// seen - когда запомнено состояние стенда из stands.
+	seen     map[int64]time.Time
+	expiring map[int64]int64
+}
+
+// NewNotifier создает Notifier по конфигурации. Уведомления отправляются после запуска Run.
+func NewNotifier(cfg config.WebhooksConfig) (*Notifier, error) {
+	deadLetter, err := newDeadLetterLog(cfg.DeadLetterFile)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		queues:     make(map[*endpoint]chan delivery),
+		deadLetter: deadLetter,
+		now:        time.Now,
+		stands:     make(map[int64]models.Stand),
+		seen:       make(map[int64]time.Time),
+		expiring:   make(map[int64]int64),
+	}
+	for _, endpointCfg := range cfg.Endpoints {
+		e, err := newEndpoint(endpointCfg)
+		if err != nil {
+			return nil, err
+		}
+		n.endpoints = append(n.endpoints, e)
+		n.queues[e] = make(chan delivery, queueSize)
+	}
+	return n, nil
+}
+
+// Run отправляет уведомления и проверяет истекающие бронирования до отмены ctx.
+func (n *Notifier) Run(ctx context.Context) {
+	for _, e := range n.endpoints {
+		go n.deliver(ctx, e)
+	}
+
+	ticker := time.NewTicker(n.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		n.checkExpiring()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Broadcast обновляет известное состояние стендов. Для стендов, которые уже были
+// известны, отправляются уведомления об изменениях.
+func (n *Notifier) Broadcast(stands []models.Stand) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, stand := range stands {
+		n.updateLocked(stand, false)
+	}
+}
+
+// StandChanged отправляет уведомления о событиях, произошедших со стендом.
+func (n *Notifier) StandChanged(stand models.Stand) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.updateLocked(stand, true)
+}
+
+// StandRemoved забывает удаленный стенд.
+func (n *Notifier) StandRemoved(id int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.stands, id)
+	delete(n.seen, id)
+	delete(n.expiring, id)
+}
+
+// Remind реализует интерфейс standservice.ReminderChannel: ставит напоминание в очереди
+// получателей, подписанных на событие reminder. Возвращает ошибку, если напоминание
+// не удалось поставить в очередь хотя бы одному из них.
+func (n *Notifier) Remind(ctx context.Context, reminder models.Reminder) error {
+	return n.publish(Event{
+		Type:        EventReminder,
+		Time:        n.now().Unix(),
+		Stand:       reminder.Stand,
+		User:        reminder.User,
+		ExtendUntil: reminder.ExtendUntil,
+		ExtendURL:   reminder.ExtendURL,
+	})
+}
+
+// updateLocked запоминает новое состояние стенда и отправляет уведомления о его изменениях.
+// О новом стенде при точечном изменении отправляется событие created, а при полной рассылке
+// он лишь запоминается, чтобы не уведомлять о стендах и бронированиях, сделанных до запуска.
+func (n *Notifier) updateLocked(stand models.Stand, notifyNew bool) {
+	now := n.now()
+	prev, known := n.stands[stand.ID]
+	seen := n.seen[stand.ID]
+	n.stands[stand.ID], n.seen[stand.ID] = stand, now
+	if !known {
+		if notifyNew {
+			n.publish(Event{Type: EventCreated, Time: now.Unix(), Stand: stand, User: stand.Users})
+		}
+		return
+	}
+	for _, event := range detectEvents(prev, seen, stand, now) {
+		n.publish(event)
+	}
+}
+
+// checkExpiring отправляет событие expiring для бронирований, которые закончатся
+// в пределах ExpiringBefore. О каждом бронировании уведомляется один раз, продление
+// бронирования снова включает уведомление.
+func (n *Notifier) checkExpiring() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	deadline := now.Add(n.cfg.ExpiringBefore).Unix()
+	for id, stand := range n.stands {
+		if !stand.IsBooked(now) || stand.EndDate > deadline || n.expiring[id] == stand.EndDate {
+			continue
+		}
+		n.expiring[id] = stand.EndDate
+		n.publish(Event{Type: EventExpiring, Time: now.Unix(), Stand: stand, User: stand.Users})
+	}
+}
+
+// publish ставит уведомление в очереди подписанных на событие получателей. Уведомления,
+// которые не удалось поставить в очередь, записываются в журнал недоставленных, а их
+// ошибки возвращаются.
+func (n *Notifier) publish(event Event) error {
+	var errs []error
+	for _, e := range n.endpoints {
+		if !e.accepts(event.Type) {
+			continue
+		}
+		body, err := e.body(event)
+		if err != nil {
+			n.deadLetter.write(n.letter(e, event, nil, 0, err))
+			errs = append(errs, fmt.Errorf("получатель %s: %w", e.Name, err))
+			continue
+		}
+		select {
+		case n.queues[e] <- delivery{event: event, body: body}:
+		default:
+			err := fmt.Errorf("очередь уведомлений получателя переполнена")
+			n.deadLetter.write(n.letter(e, event, body, 0, err))
+			errs = append(errs, fmt.Errorf("получатель %s: %w", e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver отправляет уведомления из очереди получателя до отмены ctx.
+func (n *Notifier) deliver(ctx context.Context, e *endpoint) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-n.queues[e]:
+			n.send(ctx, e, d)
+		}
+	}
+}
+
+// send отправляет уведомление с повторными попытками. Пауза между попытками удваивается.
+// Если все попытки неудачны, уведомление записывается в журнал недоставленных.
+func (n *Notifier) send(ctx context.Context, e *endpoint, d delivery) {
+	backoff := n.cfg.Backoff
+	attempts := 1 + max(n.cfg.Retries, 0)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = n.post(ctx, e, d.body); err == nil {
+			return
+		}
+		log.Printf("Ошибка отправки уведомления %s получателю %s, попытка %d из %d: %v", d.event.Type, e.Name, attempt, attempts, err)
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			n.deadLetter.write(n.letter(e, d.event, d.body, attempt, err))
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	n.deadLetter.write(n.letter(e, d.event, d.body, attempts, err))
+}
+
+// post выполняет один запрос к получателю. Ответ со статусом не 2xx считается ошибкой.
+func (n *Notifier) post(ctx context.Context, e *endpoint, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("получатель ответил статусом %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+func (n *Notifier) letter(e *endpoint, event Event, body []byte, attempts int, err error) DeadLetter {
+	return DeadLetter{
+		Time:     n.now().Unix(),
+		Endpoint: e.Name,
+		Event:    event.Type,
+		StandID:  event.Stand.ID,
+		Body:     body,
+		Attempts: attempts,
+		Error:    err.Error(),
+	}
+}
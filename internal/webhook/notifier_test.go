@@ -0,0 +1,298 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+// receiver - локальный получатель уведомлений, отвечающий статусами из statuses по очереди.
+type receiver struct {
+	mu       sync.Mutex
+	server   *httptest.Server
+	statuses []int
+	bodies   []string
+	requests int
+	received chan string
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses, received: make(chan string, 16)}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		status := http.StatusOK
+		if r.requests < len(r.statuses) {
+			status = r.statuses[r.requests]
+		}
+		r.requests++
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			r.received <- string(body)
+		}
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *receiver) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case body := <-r.received:
+		return body
+	case <-time.After(2 * time.Second):
+		t.Fatal("Уведомление не получено")
+		return ""
+	}
+}
+
+func (r *receiver) expectNone(t *testing.T) {
+	t.Helper()
+	select {
+	case body := <-r.received:
+		t.Errorf("Ожидалось отсутствие уведомлений, получено %s", body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestNotifier(t *testing.T, now time.Time, endpoints ...config.WebhookEndpointConfig) *Notifier {
+	t.Helper()
+	n, err := NewNotifier(config.WebhooksConfig{
+		ExpiringBefore: time.Hour,
+		CheckInterval:  time.Hour,
+		Timeout:        time.Second,
+		Retries:        2,
+		Backoff:        time.Millisecond,
+		DeadLetterFile: filepath.Join(t.TempDir(), "dead-letter.jsonl"),
+		Endpoints:      endpoints,
+	})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+	n.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go n.Run(ctx)
+	return n
+}
+
+func TestNotifier_Events(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	chat := newReceiver(t)
+	ci := newReceiver(t)
+	n := newTestNotifier(t, now,
+		config.WebhookEndpointConfig{Name: "chat", URL: chat.server.URL, Format: FormatSlack, Events: []string{EventBooked, EventReleased}, Channel: "#stands"},
+		config.WebhookEndpointConfig{
+			Name:     "ci",
+			URL:      ci.server.URL,
+			Events:   []string{EventRedeployed},
+			Template: `{"stand": {{json .Stand.Name}}, "components": {{json .Components}}}`,
+		},
+	)
+
+	link := "https://alpha.example.com"
+	stand := models.Stand{ID: 41, Name: "alpha", StandLink: &link, Deployments: map[string]models.Deployment{}}
+	n.Broadcast([]models.Stand{stand})
+
+	t.Run("бронирование", func(t *testing.T) {
+		stand.Users, stand.Reason, stand.EndDate = "Иванов", "релиз", now.Add(3*time.Hour).Unix()
+		n.StandChanged(stand)
+
+		var message chatMessage
+		json.Unmarshal([]byte(chat.next(t)), &message)
+		if !strings.Contains(message.Text, "<https://alpha.example.com|alpha> забронирован пользователем Иванов") ||
+			!strings.Contains(message.Text, "причина: релиз") || message.Channel != "#stands" {
+			t.Errorf("Неожиданное уведомление: %+v", message)
+		}
+		ci.expectNone(t)
+	})
+
+	t.Run("развертывание по пользовательскому шаблону", func(t *testing.T) {
+		branch := "feature/SBX-1"
+		stand.Deployments = map[string]models.Deployment{"front": {Branch: &branch}}
+		n.StandChanged(stand)
+
+		if body := ci.next(t); body != `{"stand": "alpha", "components": ["front"]}` {
+			t.Errorf("Неожиданное уведомление: %s", body)
+		}
+		chat.expectNone(t)
+	})
+
+	t.Run("освобождение", func(t *testing.T) {
+		stand.Users, stand.Reason, stand.EndDate = "", "", now.Unix()
+		n.StandChanged(stand)
+
+		var message chatMessage
+		json.Unmarshal([]byte(chat.next(t)), &message)
+		if !strings.Contains(message.Text, "бронирование пользователя Иванов завершено") {
+			t.Errorf("Неожиданное уведомление: %+v", message)
+		}
+	})
+
+	t.Run("полная рассылка не уведомляет о новых стендах", func(t *testing.T) {
+		n.Broadcast([]models.Stand{{ID: 42, Name: "beta", Users: "Петров", EndDate: now.Add(time.Hour).Unix()}})
+		chat.expectNone(t)
+	})
+
+	t.Run("изменение стенда с истекшим бронированием не освобождает его повторно", func(t *testing.T) {
+		n.Broadcast([]models.Stand{{ID: 43, Name: "gamma", Users: "Петров", EndDate: now.Add(-time.Hour).Unix()}})
+		n.StandChanged(models.Stand{ID: 43, Name: "gamma", Users: "Петров", EndDate: now.Add(-time.Hour).Unix(), Comment: "тест"})
+		n.StandChanged(models.Stand{ID: 43, Name: "gamma", EndDate: now.Unix()})
+		chat.expectNone(t)
+	})
+
+	t.Run("новый стенд с развертываниями", func(t *testing.T) {
+		branch := "master"
+		n.StandChanged(models.Stand{ID: 44, Name: "delta", Deployments: map[string]models.Deployment{"front": {Branch: &branch}}})
+		ci.expectNone(t)
+		chat.expectNone(t)
+	})
+}
+
+func TestNotifier_Created(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	target := newReceiver(t)
+	n := newTestNotifier(t, now, config.WebhookEndpointConfig{URL: target.server.URL, Events: []string{EventCreated, EventRedeployed}})
+
+	branch := "master"
+	n.StandChanged(models.Stand{ID: 44, Name: "delta", Deployments: map[string]models.Deployment{"front": {Branch: &branch}}})
+
+	var message genericMessage
+	json.Unmarshal([]byte(target.next(t)), &message)
+	if message.Event != EventCreated || message.Stand.ID != 44 || message.Message != "Добавлен стенд delta" {
+		t.Errorf("Неожиданное уведомление: %+v", message)
+	}
+	target.expectNone(t)
+}
+
+func TestNotifier_Expiring(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	chat := newReceiver(t)
+	n := newTestNotifier(t, now, config.WebhookEndpointConfig{URL: chat.server.URL, Format: FormatMattermost, Events: []string{EventExpiring}})
+
+	n.Broadcast([]models.Stand{
+		{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(30 * time.Minute).Unix()},
+		{ID: 42, Name: "beta", Users: "Петров", EndDate: now.Add(3 * time.Hour).Unix()},
+	})
+	n.checkExpiring()
+	n.checkExpiring()
+
+	var message chatMessage
+	json.Unmarshal([]byte(chat.next(t)), &message)
+	if !strings.Contains(message.Text, "Бронирование стенда **alpha** пользователем Иванов истекает") {
+		t.Errorf("Неожиданное уведомление: %+v", message)
+	}
+	chat.expectNone(t)
+
+	// После продления уведомление отправляется снова, когда срок опять подойдет.
+	n.StandChanged(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(50 * time.Minute).Unix()})
+	n.checkExpiring()
+	chat.next(t)
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("доставка после неудачных попыток", func(t *testing.T) {
+		target := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+		n := newTestNotifier(t, now, config.WebhookEndpointConfig{URL: target.server.URL})
+		n.Broadcast([]models.Stand{{ID: 41, Name: "alpha"}})
+		n.StandChanged(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(time.Hour).Unix()})
+
+		var message genericMessage
+		json.Unmarshal([]byte(target.next(t)), &message)
+		if message.Event != EventBooked || message.User != "Иванов" || message.Stand.ID != 41 {
+			t.Errorf("Неожиданное уведомление: %+v", message)
+		}
+		if target.requests != 3 {
+			t.Errorf("Ожидалось 3 попытки, получено %d", target.requests)
+		}
+	})
+
+	t.Run("недоставленное уведомление попадает в журнал", func(t *testing.T) {
+		target := newReceiver(t, 500, 500, 500)
+		n := newTestNotifier(t, now, config.WebhookEndpointConfig{Name: "broken", URL: target.server.URL})
+		n.Broadcast([]models.Stand{{ID: 41, Name: "alpha"}})
+		n.StandChanged(models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(time.Hour).Unix()})
+
+		var letter DeadLetter
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if data, err := os.ReadFile(n.cfg.DeadLetterFile); err == nil && len(data) > 0 {
+				json.Unmarshal(data, &letter)
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if letter.Endpoint != "broken" || letter.Event != EventBooked || letter.Attempts != 3 || !strings.Contains(letter.Error, "500") {
+			t.Errorf("Неожиданная запись журнала недоставленных уведомлений: %+v", letter)
+		}
+	})
+}
+
+func TestNewNotifier_InvalidConfig(t *testing.T) {
+	endpoints := map[string]config.WebhookEndpointConfig{
+		"без url":             {Name: "chat"},
+		"неизвестный формат":  {URL: "http://localhost", Format: "teams"},
+		"неизвестное событие": {URL: "http://localhost", Events: []string{"deleted"}},
+		"некорректный шаблон": {URL: "http://localhost", Template: "{{"},
+	}
+	for name, endpoint := range endpoints {
+		if _, err := NewNotifier(config.WebhooksConfig{Endpoints: []config.WebhookEndpointConfig{endpoint}}); err == nil {
+			t.Errorf("%s: ожидалась ошибка конфигурации", name)
+		}
+	}
+}
+
+func TestNotifier_Remind(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	chat := newReceiver(t)
+	n := newTestNotifier(t, now, config.WebhookEndpointConfig{URL: chat.server.URL, Format: FormatSlack, Events: []string{EventReminder}})
+
+	err := n.Remind(context.Background(), models.Reminder{
+		Stand:       models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: now.Add(time.Hour).Unix()},
+		User:        "Иванов",
+		ExtendUntil: now.Add(25 * time.Hour).Unix(),
+		ExtendURL:   "https://booking.example.com/reminders/extend?token=abc",
+	})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	var message chatMessage
+	json.Unmarshal([]byte(chat.next(t)), &message)
+	if !strings.Contains(message.Text, "Напоминание: бронирование стенда *alpha* пользователем Иванов истекает") ||
+		!strings.Contains(message.Text, "https://booking.example.com/reminders/extend?token=abc") {
+		t.Errorf("Неожиданное уведомление: %+v", message)
+	}
+}
+
+func TestNotifier_RemindError(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	target := newReceiver(t)
+	n := newTestNotifier(t, now, config.WebhookEndpointConfig{
+		Name:     "broken",
+		URL:      target.server.URL,
+		Events:   []string{EventReminder},
+		Template: `{{.Stand.Missing}}`,
+	})
+
+	err := n.Remind(context.Background(), models.Reminder{Stand: models.Stand{ID: 41, Name: "alpha"}, User: "Иванов"})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Ожидалась ошибка постановки напоминания в очередь получателя broken, получено %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+)
+
+// Форматы тела запроса к получателю.
+const (
+	FormatSlack      = "slack"
+	FormatMattermost = "mattermost"
+	FormatGeneric    = "generic"
+)
+
+// templateFuncs доступны в пользовательских шаблонах. json сериализует значение в JSON,
+// чтобы строки в шаблоне экранировались корректно.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// endpoint - получатель уведомлений с разобранной конфигурацией.
+type endpoint struct {
+	config.WebhookEndpointConfig
+	events   map[string]bool
+	template *template.Template
+}
+
+func newEndpoint(cfg config.WebhookEndpointConfig) (*endpoint, error) {
+	e := &endpoint{WebhookEndpointConfig: cfg}
+	if e.URL == "" {
+		return nil, fmt.Errorf("для получателя уведомлений %s не задан url", e.Name)
+	}
+	if e.Name == "" {
+		e.Name = e.URL
+	}
+	switch e.Format {
+	case "":
+		e.Format = FormatGeneric
+	case FormatSlack, FormatMattermost, FormatGeneric:
+	default:
+		return nil, fmt.Errorf("неизвестный формат получателя уведомлений %s: %s", e.Name, e.Format)
+	}
+
+	if len(cfg.Events) > 0 {
+		e.events = make(map[string]bool)
+		for _, event := range cfg.Events {
+			if !eventTypes[event] {
+				return nil, fmt.Errorf("неизвестное событие получателя уведомлений %s: %s", e.Name, event)
+			}
+			e.events[event] = true
+		}
+	}
+
+	if cfg.Template != "" {
+		tmpl, err := template.New(e.Name).Funcs(templateFuncs).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора шаблона получателя уведомлений %s: %w", e.Name, err)
+		}
+		e.template = tmpl
+	}
+	return e, nil
+}
+
+// accepts сообщает, подписан ли получатель на событие.
+func (e *endpoint) accepts(eventType string) bool {
+	return e.events == nil || e.events[eventType]
+}
+
+// body формирует тело запроса о событии по шаблону получателя или стандартному формату.
+func (e *endpoint) body(event Event) ([]byte, error) {
+	if e.template != nil {
+		var buf bytes.Buffer
+		if err := e.template.Execute(&buf, event); err != nil {
+			return nil, fmt.Errorf("ошибка заполнения шаблона: %w", err)
+		}
+		if !json.Valid(buf.Bytes()) {
+			return nil, fmt.Errorf("шаблон сформировал некорректный JSON: %s", buf.String())
+		}
+		return buf.Bytes(), nil
+	}
+
+	switch e.Format {
+	case FormatSlack, FormatMattermost:
+		return json.Marshal(chatMessage{
+			Text:     event.message(e.standName(event)),
+			Channel:  e.Channel,
+			Username: e.Username,
+		})
+	default:
+		return json.Marshal(genericMessage{
+			Event:      event.Type,
+			Time:       event.Time,
+			User:       event.User,
+			Components: event.Components,
+			ExtendURL:  event.ExtendURL,
+			Message:    event.message(event.Stand.Name),
+			Stand:      event.Stand,
+		})
+	}
+}
+
+// standName оформляет имя стенда ссылкой в разметке чата получателя.
+func (e *endpoint) standName(event Event) string {
+	name, link := event.Stand.Name, deref(event.Stand.StandLink)
+	switch {
+	case e.Format == FormatSlack && link != "":
+		return fmt.Sprintf("<%s|%s>", link, name)
+	case e.Format == FormatSlack:
+		return "*" + name + "*"
+	case link != "":
+		return fmt.Sprintf("[%s](%s)", name, link)
+	default:
+		return "**" + name + "**"
+	}
+}
+
+// chatMessage - тело входящего webhook Slack и Mattermost.
+type chatMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// genericMessage - тело уведомления в формате generic.
+type genericMessage struct {
+	Event      string       `json:"event"`
+	Time       int64        `json:"time"`
+	User       string       `json:"user"`
+	Components []string     `json:"components,omitempty"`
+	ExtendURL  string       `json:"extendUrl,omitempty"`
+	Message    string       `json:"message"`
+	Stand      models.Stand `json:"stand"`
+}
@@ -1,20 +1,191 @@
 package dto
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"mts/booking_service/internal/models"
+)
 
 // WsMessage - это общая структура для всех WebSocket сообщений.
 type WsMessage struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	Type string `json:"type"`
+	// RequestID - необязательный идентификатор запроса клиента. Возвращается
+	// в ответных ACK и ERROR, чтобы клиент мог сопоставить их со своим запросом.
+	RequestID string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
 }
 
+// Типы сообщений, которые клиент отправляет серверу.
+const (
+	MessageTypePatch   = "PATCH"
+	MessageTypeCreate  = "CREATE"
+	MessageTypeClone   = "CLONE"
+	MessageTypeArchive = "ARCHIVE"
+	MessageTypeBook    = "BOOK"
+	MessageTypeExtend  = "EXTEND"
+	MessageTypeRelease = "RELEASE"
+	MessageTypeEnqueue = "ENQUEUE"
+	MessageTypeDequeue = "DEQUEUE"
+	MessageTypeResync  = "RESYNC"
+)
+
+// Типы сообщений, которые сервер отправляет клиенту.
+const (
+	MessageTypeUpdate       = "UPDATE"
+	MessageTypeStandChanged = "STAND_CHANGED"
+	MessageTypeStandRemoved = "STAND_REMOVED"
+	MessageTypeReminder     = "REMINDER"
+	MessageTypeAck          = "ACK"
+	MessageTypeError        = "ERROR"
+)
+
 // PatchPayload - это структура для payload'а PATCH сообщения.
 type PatchPayload struct {
-	ID         string          `json:"id"`
-	UpdateData json.RawMessage `json:"updateData"`
+	ID         string            `json:"id"`
+	UpdateData models.StandPatch `json:"updateData"`
+	// Version - версия стенда, поверх которой клиент делает изменение.
+	// Если не указана, изменение применяется к текущей версии.
+	Version *int64 `json:"version,omitempty"`
+}
+
+// CreatePayload - это структура для payload'а CREATE сообщения и тела POST /stands.
+type CreatePayload struct {
+	// Stand - поля нового стенда в формате патча, обязательно имя: {"name": "alpha"}.
+	Stand models.StandPatch `json:"stand"`
+}
+
+// ClonePayload - это структура для payload'а CLONE сообщения и тела POST /stands/clone.
+type ClonePayload struct {
+	// ID - стенд, ветки компонентов которого копируются.
+	ID string `json:"id"`
+	// Stand - поля нового стенда поверх копии, обязательно имя.
+	Stand models.StandPatch `json:"stand"`
+}
+
+// ArchivePayload - это структура для payload'а ARCHIVE сообщения и тела POST /stands/archive.
+type ArchivePayload struct {
+	ID      string `json:"id"`
+	Version *int64 `json:"version,omitempty"`
+}
+
+// StandRemovedPayload - это структура для payload'а STAND_REMOVED сообщения.
+type StandRemovedPayload struct {
+	ID int64 `json:"id"`
 }
 
-// ErrorPayload - это структура для payload'а ERROR сообщения.
+// Машиночитаемые коды ошибок в ERROR сообщениях и JSON-ответах REST.
+const (
+	ErrorCodeInvalidRequest     = "invalid_request"
+	ErrorCodeUnknownMessageType = "unknown_message_type"
+	ErrorCodeMethodNotAllowed   = "method_not_allowed"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeForbidden          = "forbidden"
+	ErrorCodeInvalidPatch       = "invalid_patch"
+	ErrorCodeConflict           = "conflict"
+	ErrorCodeStandNotFound      = "stand_not_found"
+	ErrorCodeStandExists        = "stand_exists"
+	ErrorCodeStandArchived      = "stand_archived"
+	ErrorCodeInvalidBooking     = "invalid_booking"
+	ErrorCodeStandOccupied      = "stand_occupied"
+	ErrorCodeStandNotBooked     = "stand_not_booked"
+	ErrorCodeNotBookingOwner    = "not_booking_owner"
+	ErrorCodeStandFree          = "stand_free"
+	ErrorCodeAlreadyQueued      = "already_queued"
+	ErrorCodeNotQueued          = "not_queued"
+	ErrorCodeInvalidToken       = "invalid_token"
+	ErrorCodeStorageUnavailable = "storage_unavailable"
+	ErrorCodeInternal           = "internal"
+)
+
+// ErrorPayload - это структура для payload'а ERROR сообщения и тела ошибки REST.
 type ErrorPayload struct {
 	Message string `json:"message"`
+	// Code - машиночитаемый код ошибки, одна из констант ErrorCode*.
+	Code string `json:"code"`
+	// Details - необязательные подробности ошибки, например поле патча в "field".
+	Details map[string]any `json:"details,omitempty"`
+	// Stand - актуальное состояние стенда при конфликте версий.
+	Stand *models.Stand `json:"stand,omitempty"`
+}
+
+// BookPayload - это структура для payload'а BOOK сообщения.
+type BookPayload struct {
+	ID     string `json:"id"`
+	User   string `json:"user"`
+	Reason string `json:"reason"`
+	Until  int64  `json:"until"`
+}
+
+// ExtendPayload - это структура для payload'а EXTEND сообщения.
+type ExtendPayload struct {
+	ID    string `json:"id"`
+	User  string `json:"user"`
+	Until int64  `json:"until"`
+}
+
+// ReleasePayload - это структура для payload'а RELEASE сообщения.
+type ReleasePayload struct {
+	ID   string `json:"id"`
+	User string `json:"user"`
+}
+
+// EnqueuePayload - это структура для payload'а ENQUEUE сообщения.
+type EnqueuePayload struct {
+	ID     string `json:"id"`
+	User   string `json:"user"`
+	Reason string `json:"reason"`
+	// Duration - желаемая длительность бронирования в секундах.
+	Duration int64 `json:"duration"`
+}
+
+// DequeuePayload - это структура для payload'а DEQUEUE сообщения.
+type DequeuePayload struct {
+	ID   string `json:"id"`
+	User string `json:"user"`
+}
+
+// QueuePositionPayload - это структура ответа с позицией пользователя в очереди.
+type QueuePositionPayload struct {
+	Position int `json:"position"`
+}
+
+// ReminderPayload - это структура для payload'а REMINDER сообщения, которое получают
+// только сессии владельца бронирования. Чтобы продлить бронирование, клиенту достаточно
+// отправить EXTEND с until, равным ExtendUntil.
+type ReminderPayload struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	User    string `json:"user"`
+	EndDate int64  `json:"endDate"`
+	// Offset - за сколько секунд до окончания бронирования отправлено напоминание.
+	Offset      int64  `json:"offset"`
+	ExtendUntil int64  `json:"extendUntil"`
+	ExtendToken string `json:"extendToken"`
+	ExtendURL   string `json:"extendUrl,omitempty"`
+}
+
+// ExtendResultPayload - это структура ответа на продление бронирования по ссылке из напоминания.
+type ExtendResultPayload struct {
+	ID    int64 `json:"id"`
+	Until int64 `json:"until"`
+}
+
+// DeployHookPayload - это событие развертывания в формате generic для /hooks/deploy.
+type DeployHookPayload struct {
+	// Stand - имя или id стенда.
+	Stand     string `json:"stand"`
+	Component string `json:"component"`
+	Branch    string `json:"branch"`
+	User      string `json:"user"`
+	// Date - время развертывания в Unix-секундах. Если не указано, используется время получения.
+	Date int64 `json:"date,omitempty"`
+	// Status - итог развертывания. Если указан и не равен success, событие пропускается.
+	Status string `json:"status,omitempty"`
+}
+
+// DeployHookResult - это структура ответа /hooks/deploy.
+type DeployHookResult struct {
+	Applied bool `json:"applied"`
+	// Reason - почему событие пропущено, например неуспешное развертывание.
+	Reason string `json:"reason,omitempty"`
 }
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// AuditService определяет чтение журнала изменений стендов.
+type AuditService interface {
+	QueryAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+// AuditHandler отдает журнал изменений стендов через REST.
+type AuditHandler struct {
+	service AuditService
+	mux     *router
+}
+
+// NewAuditHandler создает обработчик GET /audit. Параметры запроса standId, user,
+// from и to ограничивают выборку стендом, автором и временем изменения.
+func NewAuditHandler(service AuditService) *AuditHandler {
+	h := &AuditHandler{
+		service: service,
+		mux:     newRouter(),
+	}
+
+	h.mux.handle("GET /audit", h.handleGet)
+	h.mux.HandleFunc("/audit", handleMethodNotAllowed)
+
+	return h
+}
+
+func (h *AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Routes возвращает операции обработчика вида "GET /path".
+func (h *AuditHandler) Routes() []string {
+	return h.mux.Routes()
+}
+
+func (h *AuditHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	entries, err := h.service.QueryAudit(r.Context(), filter)
+	if err != nil {
+		log.Printf("Ошибка получения журнала изменений: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// parseAuditFilter разбирает параметры запроса журнала. Время принимается
+// в Unix-секундах или в формате RFC 3339.
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{Actor: query.Get("user")}
+
+	if value := query.Get("standId"); value != "" {
+		standID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return filter, errInvalidQuery("standId", value)
+		}
+		filter.StandID = &standID
+	}
+	for name, target := range map[string]**int64{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		unix, err := parseTime(value)
+		if err != nil {
+			return filter, errInvalidQuery(name, value)
+		}
+		*target = &unix
+	}
+	return filter, nil
+}
+
+// parseTime разбирает время в Unix-секундах или в формате RFC 3339.
+func parseTime(value string) (int64, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return unix, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+// errInvalidQuery возвращает ошибку некорректного параметра запроса.
+func errInvalidQuery(name, value string) error {
+	return fmt.Errorf("Некорректное значение параметра %s: %s", name, value)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// MockAuditService - это мок для журнала изменений.
+type MockAuditService struct {
+	QueryAuditFunc func(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+func (m *MockAuditService) QueryAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if m.QueryAuditFunc != nil {
+		return m.QueryAuditFunc(ctx, filter)
+	}
+	return []models.AuditEntry{}, nil
+}
+
+func TestAuditHandler(t *testing.T) {
+	t.Run("выборка по стенду, пользователю и времени", func(t *testing.T) {
+		var got models.AuditFilter
+		service := &MockAuditService{
+			QueryAuditFunc: func(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+				got = filter
+				return []models.AuditEntry{{ID: 1, Time: 1_700_000_000, Actor: "Иванов", StandID: 41, Transport: models.TransportREST}}, nil
+			},
+		}
+		handler := NewAuditHandler(service)
+
+		req := httptest.NewRequest(http.MethodGet, "/audit?standId=41&user=Иванов&from=1700000000&to=2023-11-15T00:00:00Z", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Ожидался статус 200, получено %d", rec.Code)
+		}
+		if got.StandID == nil || *got.StandID != 41 || got.Actor != "Иванов" ||
+			got.From == nil || *got.From != 1_700_000_000 || got.To == nil || *got.To != 1_700_006_400 {
+			t.Errorf("Неожиданный фильтр: %+v", got)
+		}
+		var entries []models.AuditEntry
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil || len(entries) != 1 || entries[0].Actor != "Иванов" {
+			t.Errorf("Неожиданный ответ: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("некорректные параметры", func(t *testing.T) {
+		handler := NewAuditHandler(&MockAuditService{})
+		for _, query := range []string{"standId=abc", "from=вчера", "to=2023-13-01"} {
+			req := httptest.NewRequest(http.MethodGet, "/audit?"+query, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var payload dto.ErrorPayload
+			json.Unmarshal(rec.Body.Bytes(), &payload)
+			if rec.Code != http.StatusBadRequest || payload.Code != dto.ErrorCodeInvalidRequest {
+				t.Errorf("%s: ожидался статус 400 с кодом invalid_request, получено %d %s", query, rec.Code, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("неподдерживаемый метод", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewAuditHandler(&MockAuditService{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/audit", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Ожидался статус 405, получено %d", rec.Code)
+		}
+	})
+}
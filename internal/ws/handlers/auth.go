@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// RequireAuth пропускает к next только запросы с действительным токеном и
+// добавляет пользователя в контекст запроса.
+func RequireAuth(authenticator *auth.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			log.Printf("Отклонен запрос %s %s без действительного токена: %v", r.Method, r.URL.Path, err)
+			writeError(w, http.StatusUnauthorized, dto.ErrorCodeUnauthorized, "Требуется аутентификация.")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+	})
+}
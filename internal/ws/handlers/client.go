@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// clientSendBuffer - размер очереди исходящих сообщений клиента. Клиент, который
+// не успевает разбирать очередь, отключается.
+const clientSendBuffer = 256
+
+// Heartbeat задает параметры проверки живости WebSocket-соединений.
+type Heartbeat struct {
+	// PingInterval - период отправки ping клиенту.
+	PingInterval time.Duration
+	// PongTimeout - сколько ждать pong сверх PingInterval, прежде чем считать клиента отключенным.
+	PongTimeout time.Duration
+	// WriteTimeout - максимальное время записи одного сообщения клиенту.
+	WriteTimeout time.Duration
+}
+
+// DefaultHeartbeat - параметры проверки живости соединений по умолчанию.
+var DefaultHeartbeat = Heartbeat{
+	PingInterval: 30 * time.Second,
+	PongTimeout:  10 * time.Second,
+	WriteTimeout: 10 * time.Second,
+}
+
+// client - WebSocket-соединение с собственной очередью исходящих сообщений.
+// В соединение пишет только горутина writePump, поэтому медленный клиент
+// не задерживает рассылку остальным.
+type client struct {
+	// ctx - контекст запросов клиента к сервису, содержит аутентифицированного пользователя.
+	ctx context.Context
+	// user - пользователь сессии, которому адресуются личные сообщения. Пуст, если неизвестен.
+	user      string
+	conn      *websocket.Conn
+	send      chan dto.WsMessage
+	heartbeat Heartbeat
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newClient(ctx context.Context, conn *websocket.Conn, heartbeat Heartbeat) *client {
+	return &client{
+		ctx:       ctx,
+		conn:      conn,
+		send:      make(chan dto.WsMessage, clientSendBuffer),
+		heartbeat: heartbeat,
+	}
+}
+
+// enqueue ставит сообщение в очередь клиента. Если очередь переполнена, клиент
+// отключается и возвращается false.
+func (c *client) enqueue(message dto.WsMessage) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- message:
+		return true
+	default:
+		log.Println("Очередь сообщений клиента переполнена, клиент отключается.")
+		c.closeLocked()
+		// Соединение закрывается сразу, чтобы writePump не дописывал очередь зависшему клиенту.
+		c.conn.Close()
+		return false
+	}
+}
+
+// close закрывает очередь клиента. После отправки уже поставленных сообщений
+// writePump закроет соединение. Повторный вызов ничего не делает.
+func (c *client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *client) closeLocked() {
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// startReading устанавливает дедлайн чтения, который продлевается каждым pong.
+// Если клиент перестал отвечать, чтение завершится ошибкой и клиент будет отключен.
+func (c *client) startReading() {
+	readTimeout := c.heartbeat.PingInterval + c.heartbeat.PongTimeout
+	c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+}
+
+// writePump отправляет сообщения из очереди в соединение и периодически пингует
+// клиента, пока очередь не закрыта.
+func (c *client) writePump() {
+	ticker := time.NewTicker(c.heartbeat.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.heartbeat.WriteTimeout))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := c.conn.WriteJSON(message); err != nil {
+				log.Printf("Ошибка отправки сообщения клиенту: %v", err)
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(c.heartbeat.WriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("Ошибка отправки ping клиенту: %v", err)
+				return
+			}
+		}
+	}
+}
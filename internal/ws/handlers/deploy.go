@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// Источники событий развертывания.
+const (
+	deploySourceGitLab  = "gitlab"
+	deploySourceJenkins = "jenkins"
+	deploySourceGeneric = "generic"
+)
+
+// maxDeployHookBody ограничивает размер события развертывания: тело читается целиком
+// до проверки подписи, а эндпоинт доступен без аутентификации.
+const maxDeployHookBody = 1 << 20
+
+// DeploymentRecorder записывает развертывания компонентов на стенды.
+type DeploymentRecorder interface {
+	RecordDeployment(ctx context.Context, event models.DeploymentEvent) error
+}
+
+// DeployHookHandler принимает события развертывания из CI/CD и обновляет поля
+// развертывания стендов через сервис, поэтому клиенты сразу получают изменения.
+type DeployHookHandler struct {
+	recorder   DeploymentRecorder
+	secret     []byte
+	components map[string]string
+	mux        *router
+	now        func() time.Time
+}
+
+// NewDeployHookHandler создает обработчик POST /hooks/deploy. Формат события задается
+// параметром source (gitlab, jenkins, generic), по умолчанию GitLab определяется
+// по заголовку X-Gitlab-Event, остальные события считаются generic.
+func NewDeployHookHandler(recorder DeploymentRecorder, cfg config.HooksConfig) *DeployHookHandler {
+	h := &DeployHookHandler{
+		recorder:   recorder,
+		secret:     []byte(cfg.Secret),
+		components: make(map[string]string, len(cfg.Components)),
+		mux:        newRouter(),
+		now:        time.Now,
+	}
+	for name, component := range cfg.Components {
+		h.components[strings.ToLower(name)] = component
+	}
+
+	h.mux.handle("POST /hooks/deploy", h.handleDeploy)
+	h.mux.HandleFunc("/hooks/deploy", handleMethodNotAllowed)
+
+	return h
+}
+
+func (h *DeployHookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r.WithContext(audit.WithTransport(r.Context(), models.TransportHook)))
+}
+
+// Routes возвращает операции обработчика вида "GET /path".
+func (h *DeployHookHandler) Routes() []string {
+	return h.mux.Routes()
+}
+
+func (h *DeployHookHandler) handleDeploy(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDeployHookBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, dto.ErrorCodeInvalidRequest, "Слишком большое событие развертывания")
+			return
+		}
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Ошибка чтения тела запроса")
+		return
+	}
+	if !h.verify(r, body) {
+		writeError(w, http.StatusUnauthorized, dto.ErrorCodeUnauthorized, "Неверная подпись события развертывания")
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		source = deploySourceGeneric
+		if r.Header.Get("X-Gitlab-Event") != "" {
+			source = deploySourceGitLab
+		}
+	}
+
+	var event models.DeploymentEvent
+	var skip string
+	switch source {
+	case deploySourceGitLab:
+		event, skip, err = parseGitLabDeployment(body)
+	case deploySourceJenkins:
+		event, skip, err = parseJenkinsDeployment(body)
+	case deploySourceGeneric:
+		event, skip, err = parseGenericDeployment(body)
+	default:
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Неизвестный источник события развертывания: "+source)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Некорректное событие развертывания: "+err.Error())
+		return
+	}
+	if skip != "" {
+		log.Printf("Событие развертывания %s пропущено: %s", source, skip)
+		writeJSON(w, http.StatusAccepted, dto.DeployHookResult{Reason: skip})
+		return
+	}
+
+	if component, ok := h.components[strings.ToLower(event.Component)]; ok {
+		event.Component = component
+	}
+	if event.Date == 0 {
+		event.Date = h.now().Unix()
+	}
+	if err := h.recorder.RecordDeployment(r.Context(), event); err != nil {
+		log.Printf("Ошибка записи развертывания из %s: %v", source, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.DeployHookResult{Applied: true})
+}
+
+// verify проверяет общий секрет в заголовке X-Gitlab-Token или X-Deploy-Token
+// либо HMAC-SHA256 подпись тела в заголовке X-Signature-256 вида sha256=<hex>.
+func (h *DeployHookHandler) verify(r *http.Request, body []byte) bool {
+	for _, header := range []string{"X-Gitlab-Token", "X-Deploy-Token"} {
+		if token := r.Header.Get(header); token != "" {
+			return hmac.Equal([]byte(token), h.secret)
+		}
+	}
+
+	signature, ok := strings.CutPrefix(r.Header.Get("X-Signature-256"), "sha256=")
+	if !ok {
+		return false
+	}
+	sum, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
+
+// gitLabDeployment - событие Deployment Hook GitLab.
+type gitLabDeployment struct {
+	ObjectKind      string `json:"object_kind"`
+	Status          string `json:"status"`
+	StatusChangedAt string `json:"status_changed_at"`
+	Environment     string `json:"environment"`
+	Ref             string `json:"ref"`
+	Project         struct {
+		Name              string `json:"name"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// parseGitLabDeployment разбирает событие GitLab: стенд - окружение, компонент - проект.
+// Второе значение - причина, по которой событие пропускается.
+func parseGitLabDeployment(body []byte) (models.DeploymentEvent, string, error) {
+	var payload gitLabDeployment
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return models.DeploymentEvent{}, "", err
+	}
+	if payload.ObjectKind != "deployment" {
+		return models.DeploymentEvent{}, "событие " + payload.ObjectKind + " не является развертыванием", nil
+	}
+	if payload.Status != "success" {
+		return models.DeploymentEvent{}, "развертывание в статусе " + payload.Status, nil
+	}
+
+	event := models.DeploymentEvent{
+		Stand:     payload.Environment,
+		Component: payload.Project.PathWithNamespace,
+		Branch:    normalizeBranch(payload.Ref),
+		User:      payload.User.Username,
+	}
+	if event.Component == "" {
+		event.Component = payload.Project.Name
+	}
+	if t, err := time.Parse("2006-01-02 15:04:05 -0700", payload.StatusChangedAt); err == nil {
+		event.Date = t.Unix()
+	}
+	return event, "", nil
+}
+
+// jenkinsDeployment - событие Notification Plugin Jenkins. Стенд, компонент, ветка
+// и пользователь передаются параметрами сборки STAND, COMPONENT, BRANCH и USER.
+type jenkinsDeployment struct {
+	Name  string `json:"name"`
+	Build struct {
+		Phase     string `json:"phase"`
+		Status    string `json:"status"`
+		Timestamp int64  `json:"timestamp"`
+		SCM       struct {
+			Branch string `json:"branch"`
+		} `json:"scm"`
+		Parameters map[string]string `json:"parameters"`
+	} `json:"build"`
+}
+
+// parseJenkinsDeployment разбирает событие Jenkins. Если параметр COMPONENT не задан,
+// компонентом считается имя задачи, если не задан BRANCH - ветка из scm.
+func parseJenkinsDeployment(body []byte) (models.DeploymentEvent, string, error) {
+	var payload jenkinsDeployment
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return models.DeploymentEvent{}, "", err
+	}
+	if payload.Build.Phase != "COMPLETED" && payload.Build.Phase != "FINALIZED" {
+		return models.DeploymentEvent{}, "сборка в фазе " + payload.Build.Phase, nil
+	}
+	if payload.Build.Status != "SUCCESS" {
+		return models.DeploymentEvent{}, "сборка завершилась со статусом " + payload.Build.Status, nil
+	}
+
+	params := payload.Build.Parameters
+	event := models.DeploymentEvent{
+		Stand:     params["STAND"],
+		Component: params["COMPONENT"],
+		Branch:    normalizeBranch(params["BRANCH"]),
+		User:      params["USER"],
+	}
+	if event.Component == "" {
+		event.Component = payload.Name
+	}
+	if event.Branch == "" {
+		event.Branch = normalizeBranch(payload.Build.SCM.Branch)
+	}
+	if payload.Build.Timestamp > 0 {
+		event.Date = payload.Build.Timestamp / 1000
+	}
+	return event, "", nil
+}
+
+// parseGenericDeployment разбирает событие в формате dto.DeployHookPayload.
+func parseGenericDeployment(body []byte) (models.DeploymentEvent, string, error) {
+	var payload dto.DeployHookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return models.DeploymentEvent{}, "", err
+	}
+	if payload.Status != "" && payload.Status != "success" {
+		return models.DeploymentEvent{}, "развертывание в статусе " + payload.Status, nil
+	}
+	return models.DeploymentEvent{
+		Stand:     payload.Stand,
+		Component: payload.Component,
+		Branch:    normalizeBranch(payload.Branch),
+		User:      payload.User,
+		Date:      payload.Date,
+	}, "", nil
+}
+
+// normalizeBranch убирает из имени ветки префиксы refs/heads/ и origin/.
+func normalizeBranch(branch string) string {
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	return strings.TrimPrefix(branch, "origin/")
+}
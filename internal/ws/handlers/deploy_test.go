@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// MockDeploymentRecorder - это мок для записи развертываний.
+type MockDeploymentRecorder struct {
+	RecordDeploymentFunc func(ctx context.Context, event models.DeploymentEvent) error
+}
+
+func (m *MockDeploymentRecorder) RecordDeployment(ctx context.Context, event models.DeploymentEvent) error {
+	return m.RecordDeploymentFunc(ctx, event)
+}
+
+func TestDeployHookHandler(t *testing.T) {
+	const secret = "s3cret"
+	now := time.Unix(1_700_000_000, 0)
+
+	var recorded []models.DeploymentEvent
+	handler := NewDeployHookHandler(&MockDeploymentRecorder{
+		RecordDeploymentFunc: func(ctx context.Context, event models.DeploymentEvent) error {
+			if transport := audit.TransportFromContext(ctx); transport != models.TransportHook {
+				t.Errorf("Ожидался транспорт %s, получено %s", models.TransportHook, transport)
+			}
+			if event.Stand == "missing" {
+				return models.ErrStandNotFound
+			}
+			recorded = append(recorded, event)
+			return nil
+		},
+	}, config.HooksConfig{Secret: secret, Components: map[string]string{"shop/frontend": "front", "backend-deploy": "back"}})
+	handler.now = func() time.Time { return now }
+
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	gitlab := `{"object_kind":"deployment","status":"success","status_changed_at":"2023-11-14 22:13:20 +0000",` +
+		`"environment":"alpha","ref":"feature/login","project":{"name":"frontend","path_with_namespace":"shop/frontend"},"user":{"username":"ivanov"}}`
+	jenkins := `{"name":"backend-deploy","build":{"phase":"COMPLETED","status":"SUCCESS","timestamp":1700000000000,` +
+		`"scm":{"branch":"origin/release/1.2"},"parameters":{"STAND":"beta","USER":"petrov"}}}`
+	generic := `{"stand":"gamma","component":"back","branch":"refs/heads/main","user":"ci"}`
+
+	cases := map[string]struct {
+		query   string
+		headers map[string]string
+		body    string
+		status  int
+		want    *models.DeploymentEvent
+	}{
+		"развертывание из GitLab": {
+			headers: map[string]string{"X-Gitlab-Event": "Deployment Hook", "X-Gitlab-Token": secret},
+			body:    gitlab,
+			status:  http.StatusOK,
+			want:    &models.DeploymentEvent{Stand: "alpha", Component: "front", Branch: "feature/login", User: "ivanov", Date: 1_700_000_000},
+		},
+		"развертывание из Jenkins": {
+			query:   "?source=jenkins",
+			headers: map[string]string{"X-Deploy-Token": secret},
+			body:    jenkins,
+			status:  http.StatusOK,
+			want:    &models.DeploymentEvent{Stand: "beta", Component: "back", Branch: "release/1.2", User: "petrov", Date: 1_700_000_000},
+		},
+		"generic с HMAC-подписью": {
+			headers: map[string]string{"X-Signature-256": sign(generic)},
+			body:    generic,
+			status:  http.StatusOK,
+			want:    &models.DeploymentEvent{Stand: "gamma", Component: "back", Branch: "main", User: "ci", Date: now.Unix()},
+		},
+		"неверный токен": {
+			headers: map[string]string{"X-Gitlab-Event": "Deployment Hook", "X-Gitlab-Token": "wrong"},
+			body:    gitlab,
+			status:  http.StatusUnauthorized,
+		},
+		"подпись другого тела": {
+			headers: map[string]string{"X-Signature-256": sign(generic)},
+			body:    strings.Replace(generic, "gamma", "alpha", 1),
+			status:  http.StatusUnauthorized,
+		},
+		"без подписи": {
+			body:   generic,
+			status: http.StatusUnauthorized,
+		},
+		"неуспешное развертывание пропускается": {
+			headers: map[string]string{"X-Gitlab-Event": "Deployment Hook", "X-Gitlab-Token": secret},
+			body:    strings.Replace(gitlab, `"success"`, `"failed"`, 1),
+			status:  http.StatusAccepted,
+		},
+		"неизвестный стенд": {
+			headers: map[string]string{"X-Deploy-Token": secret},
+			body:    `{"stand":"missing","component":"front","branch":"main"}`,
+			status:  http.StatusNotFound,
+		},
+		"слишком большое тело": {
+			headers: map[string]string{"X-Deploy-Token": secret},
+			body:    `{"stand":"gamma","padding":"` + strings.Repeat("x", maxDeployHookBody) + `"}`,
+			status:  http.StatusRequestEntityTooLarge,
+		},
+		"некорректный JSON": {
+			headers: map[string]string{"X-Deploy-Token": secret},
+			body:    `{"stand":`,
+			status:  http.StatusBadRequest,
+		},
+	}
+	for name, tc := range cases {
+		recorded = nil
+		req := httptest.NewRequest(http.MethodPost, "/hooks/deploy"+tc.query, strings.NewReader(tc.body))
+		for key, value := range tc.headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s: ожидался статус %d, получено %d: %s", name, tc.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if tc.want == nil {
+			if len(recorded) != 0 {
+				t.Errorf("%s: развертывание не должно записываться, получено %+v", name, recorded)
+			}
+			continue
+		}
+		if len(recorded) != 1 || recorded[0] != *tc.want {
+			t.Errorf("%s: ожидалось развертывание %+v, получено %+v", name, *tc.want, recorded)
+		}
+		var result dto.DeployHookResult
+		if json.Unmarshal(rec.Body.Bytes(), &result); !result.Applied {
+			t.Errorf("%s: неожиданный ответ %s", name, rec.Body.String())
+		}
+	}
+
+	t.Run("неподдерживаемый метод", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hooks/deploy", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Ожидался статус %d, получено %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
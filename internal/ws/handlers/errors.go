@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/services/standservice"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// serviceErrors сопоставляет типизированные ошибки сервиса стендов и хранилища
+// с HTTP-статусом, кодом и сообщением для клиента. Проверяются по порядку.
+var serviceErrors = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{models.ErrInvalidPatch, http.StatusBadRequest, dto.ErrorCodeInvalidPatch, "Некорректные данные для обновления стенда."},
+	{models.ErrVersionConflict, http.StatusConflict, dto.ErrorCodeConflict, "Стенд был изменен другим пользователем. Обновите данные и повторите попытку."},
+	{models.ErrInvalidStandID, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Некорректный id стенда."},
+	{models.ErrStandNotFound, http.StatusNotFound, dto.ErrorCodeStandNotFound, "Стенд не найден."},
+	{models.ErrStandArchived, http.StatusConflict, dto.ErrorCodeStandArchived, "Стенд перенесен в архив."},
+	{models.ErrStorageUnavailable, http.StatusServiceUnavailable, dto.ErrorCodeStorageUnavailable, "Хранилище стендов недоступно. Повторите попытку позже."},
+	{standservice.ErrStandExists, http.StatusConflict, dto.ErrorCodeStandExists, "Стенд с таким именем уже существует."},
+	{standservice.ErrInvalidBooking, http.StatusBadRequest, dto.ErrorCodeInvalidBooking, "Некорректные параметры бронирования."},
+	{standservice.ErrStandOccupied, http.StatusConflict, dto.ErrorCodeStandOccupied, "Стенд уже занят."},
+	{standservice.ErrStandNotBooked, http.StatusConflict, dto.ErrorCodeStandNotBooked, "Стенд не забронирован."},
+	{standservice.ErrForbidden, http.StatusForbidden, dto.ErrorCodeForbidden, "Недостаточно прав для этой операции."},
+	{standservice.ErrNotBookingOwner, http.StatusForbidden, dto.ErrorCodeNotBookingOwner, "Стенд забронирован другим пользователем."},
+	{standservice.ErrStandFree, http.StatusConflict, dto.ErrorCodeStandFree, "Стенд свободен, его можно забронировать."},
+	{standservice.ErrAlreadyQueued, http.StatusConflict, dto.ErrorCodeAlreadyQueued, "Пользователь уже в очереди на стенд."},
+	{standservice.ErrNotQueued, http.StatusConflict, dto.ErrorCodeNotQueued, "Пользователь не стоит в очереди на стенд."},
+	{standservice.ErrInvalidExtendToken, http.StatusForbidden, dto.ErrorCodeInvalidToken, "Ссылка для продления бронирования недействительна."},
+}
+
+// errorPayload формирует payload ошибки для клиента и HTTP-статус. При конфликте версий
+// в него добавляется актуальное состояние стенда, при некорректном патче - имя поля.
+func errorPayload(err error) (int, dto.ErrorPayload) {
+	status := http.StatusInternalServerError
+	payload := dto.ErrorPayload{Message: "Не удалось обновить данные.", Code: dto.ErrorCodeInternal}
+	for _, known := range serviceErrors {
+		if errors.Is(err, known.err) {
+			status = known.status
+			payload = dto.ErrorPayload{Message: known.message, Code: known.code}
+			break
+		}
+	}
+
+	var conflict *models.ConflictError
+	if errors.As(err, &conflict) {
+		payload.Stand = conflict.Current
+	}
+	var fieldErr *models.FieldError
+	if errors.As(err, &fieldErr) {
+		payload.Details = map[string]any{"field": fieldErr.Field}
+	}
+	return status, payload
+}
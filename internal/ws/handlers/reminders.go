@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// ReminderExtender продлевает бронирование по токену из напоминания.
+type ReminderExtender interface {
+	ExtendWithToken(ctx context.Context, token string) (int64, time.Time, error)
+}
+
+// RemindersHandler продлевает бронирования по ссылкам из напоминаний. Токен в ссылке
+// подтверждает пользователя, поэтому обработчик не требует аутентификации.
+type RemindersHandler struct {
+	extender ReminderExtender
+	mux      *router
+}
+
+// extendPage - страница продления бронирования по ссылке из письма или чата: форма
+// подтверждения, если задан Token, иначе результат продления.
+var extendPage = template.Must(template.New("extend").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Продление бронирования</title></head>
+<body>
+{{- if .Token}}
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<p>Продлить бронирование стенда?</p>
+<button type="submit">Продлить</button>
+</form>
+{{- else if .Error}}
+<p>Не удалось продлить бронирование: {{.Error}}</p>
+{{- else}}
+<p>Бронирование стенда продлено до {{.Until}}.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// extendPageData - данные страницы extendPage.
+type extendPageData struct {
+	Token string
+	Until string
+	Error string
+}
+
+// NewRemindersHandler создает обработчик GET и POST /reminders/extend?token=.
+// GET только показывает страницу подтверждения: ссылку из письма или чата открывают
+// и почтовые сканеры, и предпросмотр ссылок, поэтому бронирование продлевает POST формы.
+func NewRemindersHandler(extender ReminderExtender) *RemindersHandler {
+	h := &RemindersHandler{
+		extender: extender,
+		mux:      newRouter(),
+	}
+
+	h.mux.handle("GET /reminders/extend", h.handleConfirm)
+	h.mux.handle("POST /reminders/extend", h.handleExtend)
+	h.mux.HandleFunc("/reminders/extend", handleMethodNotAllowed)
+
+	return h
+}
+
+func (h *RemindersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r.WithContext(audit.WithTransport(r.Context(), models.TransportREST)))
+}
+
+// Routes возвращает операции обработчика вида "GET /path".
+func (h *RemindersHandler) Routes() []string {
+	return h.mux.Routes()
+}
+
+func (h *RemindersHandler) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Не указан токен продления")
+		return
+	}
+	writeExtendPage(w, http.StatusOK, extendPageData{Token: token})
+}
+
+// handleExtend продлевает бронирование по токену из запроса или формы подтверждения.
+// На отправку формы отвечает страницей с результатом, на остальные запросы - JSON.
+func (h *RemindersHandler) handleExtend(w http.ResponseWriter, r *http.Request) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	fromForm := contentType == "application/x-www-form-urlencoded"
+
+	token := r.FormValue("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Не указан токен продления")
+		return
+	}
+
+	id, until, err := h.extender.ExtendWithToken(r.Context(), token)
+	if err != nil {
+		log.Printf("Ошибка продления бронирования по ссылке: %v", err)
+		if fromForm {
+			status, payload := errorPayload(err)
+			writeExtendPage(w, status, extendPageData{Error: payload.Message})
+			return
+		}
+		writeServiceError(w, err)
+		return
+	}
+
+	if fromForm {
+		writeExtendPage(w, http.StatusOK, extendPageData{Until: until.Format("02.01.2006 15:04 MST")})
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.ExtendResultPayload{ID: id, Until: until.Unix()})
+}
+
+// writeExtendPage отправляет клиенту страницу extendPage.
+func writeExtendPage(w http.ResponseWriter, status int, data extendPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := extendPage.Execute(w, data); err != nil {
+		log.Printf("Ошибка формирования страницы продления: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/services/standservice"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// MockReminderExtender - это мок для продления бронирования по токену.
+type MockReminderExtender struct {
+	ExtendWithTokenFunc func(ctx context.Context, token string) (int64, time.Time, error)
+}
+
+func (m *MockReminderExtender) ExtendWithToken(ctx context.Context, token string) (int64, time.Time, error) {
+	return m.ExtendWithTokenFunc(ctx, token)
+}
+
+func TestRemindersHandler(t *testing.T) {
+	extended := 0
+	handler := NewRemindersHandler(&MockReminderExtender{
+		ExtendWithTokenFunc: func(ctx context.Context, token string) (int64, time.Time, error) {
+			if token != "valid" {
+				return 0, time.Time{}, standservice.ErrInvalidExtendToken
+			}
+			extended++
+			return 41, time.Unix(1_700_090_000, 0), nil
+		},
+	})
+
+	cases := map[string]struct {
+		method string
+		query  string
+		status int
+		code   string
+	}{
+		"продление запросом POST": {http.MethodPost, "token=valid", http.StatusOK, ""},
+		"недействительный токен":  {http.MethodPost, "token=forged", http.StatusForbidden, dto.ErrorCodeInvalidToken},
+		"без токена":              {http.MethodGet, "", http.StatusBadRequest, dto.ErrorCodeInvalidRequest},
+		"неподдерживаемый метод":  {http.MethodDelete, "token=valid", http.StatusMethodNotAllowed, dto.ErrorCodeMethodNotAllowed},
+	}
+	for name, tc := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, "/reminders/extend?"+tc.query, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("%s: ожидался статус %d, получено %d", name, tc.status, rec.Code)
+			continue
+		}
+		if tc.code != "" {
+			var payload dto.ErrorPayload
+			if json.Unmarshal(rec.Body.Bytes(), &payload); payload.Code != tc.code {
+				t.Errorf("%s: ожидался код %s, получено %s", name, tc.code, payload.Code)
+			}
+			continue
+		}
+		var result dto.ExtendResultPayload
+		if json.Unmarshal(rec.Body.Bytes(), &result); result.ID != 41 || result.Until != 1_700_090_000 {
+			t.Errorf("%s: неожиданный ответ %s", name, rec.Body.String())
+		}
+	}
+
+	t.Run("переход по ссылке только показывает подтверждение", func(t *testing.T) {
+		extended = 0
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reminders/extend?token=valid", nil))
+
+		body := rec.Body.String()
+		if rec.Code != http.StatusOK || !strings.Contains(body, `<form method="post">`) || !strings.Contains(body, `name="token" value="valid"`) {
+			t.Errorf("Ожидалась страница подтверждения, получено %d %s", rec.Code, body)
+		}
+		if extended != 0 {
+			t.Error("GET не должен продлевать бронирование")
+		}
+	})
+
+	t.Run("отправка формы подтверждения", func(t *testing.T) {
+		extended = 0
+		req := httptest.NewRequest(http.MethodPost, "/reminders/extend?token=valid", strings.NewReader("token=valid"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || extended != 1 || !strings.Contains(rec.Body.String(), "Бронирование стенда продлено до") {
+			t.Errorf("Ожидалось продление со страницей результата, получено %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("форма с недействительным токеном", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/reminders/extend", strings.NewReader("token=forged"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "Не удалось продлить бронирование") {
+			t.Errorf("Ожидалась страница с ошибкой, получено %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+)
+
+// defaultReportPeriod - период отчета, если параметр from не задан.
+const defaultReportPeriod = 30 * 24 * time.Hour
+
+// ReportsService определяет построение отчетов об использовании стендов.
+type ReportsService interface {
+	Reports(ctx context.Context, from, to time.Time) ([]models.StandReport, error)
+}
+
+// ReportsHandler отдает отчеты об использовании стендов через REST.
+type ReportsHandler struct {
+	service ReportsService
+	mux     *router
+	now     func() time.Time
+}
+
+// NewReportsHandler создает обработчик GET /reports. Параметры from и to задают период
+// отчета, по умолчанию последние 30 дней. format=csv или заголовок Accept: text/csv
+// включает выгрузку в CSV.
+func NewReportsHandler(service ReportsService) *ReportsHandler {
+	h := &ReportsHandler{
+		service: service,
+		mux:     newRouter(),
+		now:     time.Now,
+	}
+
+	h.mux.handle("GET /reports", h.handleGet)
+	h.mux.HandleFunc("/reports", handleMethodNotAllowed)
+
+	return h
+}
+
+func (h *ReportsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Routes возвращает операции обработчика вида "GET /path".
+func (h *ReportsHandler) Routes() []string {
+	return h.mux.Routes()
+}
+
+func (h *ReportsHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	to := h.now()
+	if value := query.Get("to"); value != "" {
+		unix, err := parseTime(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, errInvalidQuery("to", value).Error())
+			return
+		}
+		to = time.Unix(unix, 0)
+	}
+	from := to.Add(-defaultReportPeriod)
+	if value := query.Get("from"); value != "" {
+		unix, err := parseTime(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, errInvalidQuery("from", value).Error())
+			return
+		}
+		from = time.Unix(unix, 0)
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Начало периода должно быть раньше окончания")
+		return
+	}
+
+	reports, err := h.service.Reports(r.Context(), from, to)
+	if err != nil {
+		log.Printf("Ошибка построения отчета об использовании стендов: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if query.Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		writeReportsCSV(w, reports)
+		return
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// writeReportsCSV отправляет отчеты в CSV с заголовком из имен JSON-полей.
+func writeReportsCSV(w http.ResponseWriter, reports []models.StandReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="reports.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"standId", "name", "utilization", "averageBooking", "bookings", "distinctUsers", "peakContention"})
+	for _, report := range reports {
+		_ = writer.Write([]string{
+			strconv.FormatInt(report.StandID, 10),
+			report.Name,
+			strconv.FormatFloat(report.Utilization, 'f', 2, 64),
+			strconv.FormatInt(report.AverageBooking, 10),
+			strconv.Itoa(report.Bookings),
+			strconv.Itoa(report.DistinctUsers),
+			strconv.Itoa(report.PeakContention),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Ошибка записи отчета в CSV: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mts/booking_service/internal/models"
+)
+
+// MockReportsService - это мок для построения отчетов.
+type MockReportsService struct {
+	ReportsFunc func(ctx context.Context, from, to time.Time) ([]models.StandReport, error)
+}
+
+func (m *MockReportsService) Reports(ctx context.Context, from, to time.Time) ([]models.StandReport, error) {
+	if m.ReportsFunc != nil {
+		return m.ReportsFunc(ctx, from, to)
+	}
+	return []models.StandReport{}, nil
+}
+
+func TestReportsHandler(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	var gotFrom, gotTo time.Time
+	service := &MockReportsService{
+		ReportsFunc: func(ctx context.Context, from, to time.Time) ([]models.StandReport, error) {
+			gotFrom, gotTo = from, to
+			return []models.StandReport{{StandID: 41, Name: "alpha, main", Utilization: 37.5, AverageBooking: 3600, Bookings: 2, DistinctUsers: 2, PeakContention: 3}}, nil
+		},
+	}
+	handler := NewReportsHandler(service)
+	handler.now = func() time.Time { return now }
+
+	t.Run("JSON за период по умолчанию", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Ожидался статус 200, получено %d", rec.Code)
+		}
+		if !gotTo.Equal(now) || !gotFrom.Equal(now.Add(-defaultReportPeriod)) {
+			t.Errorf("Неожиданный период отчета: %s - %s", gotFrom, gotTo)
+		}
+		var reports []models.StandReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &reports); err != nil || len(reports) != 1 || reports[0].PeakContention != 3 {
+			t.Errorf("Неожиданный ответ: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("CSV за заданный период", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports?from=1699000000&to=2023-11-14T00:00:00Z&format=csv", nil))
+
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+			t.Fatalf("Ожидался CSV со статусом 200, получено %d %s", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if gotFrom.Unix() != 1_699_000_000 || gotTo.Unix() != 1_699_920_000 {
+			t.Errorf("Неожиданный период отчета: %d - %d", gotFrom.Unix(), gotTo.Unix())
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil || len(records) != 2 {
+			t.Fatalf("Ожидались заголовок и одна строка, получено %v, %v", records, err)
+		}
+		if records[1][1] != "alpha, main" || records[1][2] != "37.50" || records[1][6] != "3" {
+			t.Errorf("Неожиданная строка отчета: %v", records[1])
+		}
+	})
+
+	t.Run("некорректный период", func(t *testing.T) {
+		for _, query := range []string{"from=вчера", "from=1700000000&to=1690000000"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports?"+query, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: ожидался статус 400, получено %d", query, rec.Code)
+			}
+		}
+	})
+}
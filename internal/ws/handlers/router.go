@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+)
+
+// router - ServeMux REST-обработчика, который запоминает зарегистрированные операции,
+// чтобы их можно было сверить со спецификацией OpenAPI.
+type router struct {
+	*http.ServeMux
+	routes []string
+}
+
+func newRouter() *router {
+	return &router{ServeMux: http.NewServeMux()}
+}
+
+// handle регистрирует операцию с pattern вида "GET /stands/{id}". Ответы 405 для прочих
+// методов регистрируются через HandleFunc и операциями не считаются.
+func (r *router) handle(pattern string, handler http.HandlerFunc) {
+	r.HandleFunc(pattern, handler)
+	r.routes = append(r.routes, pattern)
+}
+
+// Routes возвращает операции обработчика в порядке регистрации.
+func (r *router) Routes() []string {
+	return slices.Clone(r.routes)
+}
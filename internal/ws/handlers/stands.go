@@ -1,67 +1,395 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
-	"mts/booking_service/internal/services/standservice"
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
 )
 
+// StandsService определяет операции сервиса стендов, доступные через REST.
+type StandsService interface {
+	QueryStands(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error)
+	GetStand(ctx context.Context, id string) (*models.Stand, error)
+	UpdateStand(ctx context.Context, id string, version *int64, patch models.StandPatch) error
+	Book(ctx context.Context, id, user, reason string, until time.Time) error
+	Extend(ctx context.Context, id, user string, until time.Time) error
+	Release(ctx context.Context, id, user string) error
+	Enqueue(ctx context.Context, id, user, reason string, duration time.Duration) (int, error)
+	Dequeue(ctx context.Context, id, user string) error
+	GetQueue(ctx context.Context, id string) ([]models.QueueEntry, error)
+	CreateStand(ctx context.Context, fields models.StandPatch) (*models.Stand, error)
+	CloneStand(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error)
+	ArchiveStand(ctx context.Context, id string, version *int64) error
+}
+
+// StandsHandler отдает стенды и операции над ними через REST.
 type StandsHandler struct {
-	repo standservice.Repository
+	service StandsService
+	mux     *router
 }
 
-func NewStandsHandler(repo standservice.Repository) *StandsHandler {
-	return &StandsHandler{repo: repo}
+// NewStandsHandler создает обработчик /stands. Стенд доступен как ресурс /stands/{id},
+// а GET /stands принимает фильтры, порядок и страницу в параметрах запроса.
+func NewStandsHandler(service StandsService) *StandsHandler {
+	h := &StandsHandler{
+		service: service,
+		mux:     newRouter(),
+	}
+
+	h.mux.handle("GET /stands", h.handleGet)
+	h.mux.handle("GET /stands/{id}", h.handleGetStand)
+	h.mux.handle("PATCH /stands", h.handlePatch)
+	h.mux.handle("PATCH /stands/{id}", h.handlePatchStand)
+	h.mux.handle("POST /stands", h.handleCreate)
+	h.mux.handle("POST /stands/clone", h.handleClone)
+	h.mux.handle("POST /stands/archive", h.handleArchive)
+	h.mux.handle("POST /stands/book", h.handleBook)
+	h.mux.handle("POST /stands/extend", h.handleExtend)
+	h.mux.handle("POST /stands/release", h.handleRelease)
+	h.mux.handle("GET /stands/queue", h.handleGetQueue)
+	h.mux.handle("POST /stands/queue", h.handleEnqueue)
+	h.mux.handle("DELETE /stands/queue", h.handleDequeue)
+	h.mux.HandleFunc("/stands", handleMethodNotAllowed)
+	h.mux.HandleFunc("/stands/{id}", handleMethodNotAllowed)
+	// Пути операций не являются стендами: GET и PATCH на них не ищут стенд с id "book".
+	for _, path := range []string{"/stands/clone", "/stands/archive", "/stands/book", "/stands/extend", "/stands/release"} {
+		h.mux.HandleFunc("GET "+path, handleMethodNotAllowed)
+		h.mux.HandleFunc("PATCH "+path, handleMethodNotAllowed)
+	}
+	h.mux.HandleFunc("PATCH /stands/queue", handleMethodNotAllowed)
+
+	return h
 }
 
 func (h *StandsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.handleGet(w, r)
-	case http.MethodPatch:
-		h.handlePatch(w, r)
-	default:
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-	}
+	h.mux.ServeHTTP(w, r.WithContext(audit.WithTransport(r.Context(), models.TransportREST)))
+}
+
+// Routes возвращает операции обработчика вида "GET /path".
+func (h *StandsHandler) Routes() []string {
+	return h.mux.Routes()
+}
+
+func handleMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, dto.ErrorCodeMethodNotAllowed, "Метод не поддерживается")
 }
 
 func (h *StandsHandler) handleGet(w http.ResponseWriter, r *http.Request) {
-	stands, err := h.repo.GetStands(r.Context())
+	query, err := parseStandQuery(r)
 	if err != nil {
-		http.Error(w, "Ошибка получения данных", http.StatusInternalServerError)
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(stands)
+	stands, total, err := h.service.QueryStands(r.Context(), query)
+	if err != nil {
+		log.Printf("Ошибка получения стендов: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+	if stands == nil {
+		stands = []models.Stand{}
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSON(w, http.StatusOK, stands)
 }
 
-func (h *StandsHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
+func (h *StandsHandler) handleGetStand(w http.ResponseWriter, r *http.Request) {
+	stand, err := h.service.GetStand(r.Context(), r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Ошибка чтения тела запроса", http.StatusBadRequest)
+		log.Printf("Ошибка получения стенда: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(stand.Version, 10)))
+	writeJSON(w, http.StatusOK, stand)
+}
+
+// handlePatchStand применяет патч из тела запроса к стенду. Версия стенда, поверх которой
+// сделано изменение, передается в If-Match значением ETag из GET /stands/{id}.
+func (h *StandsHandler) handlePatchStand(w http.ResponseWriter, r *http.Request) {
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	var patch models.StandPatch
+	if !decodeBody(w, r, &patch) {
 		return
 	}
 
-	var patchData struct {
-		ID         string          `json:"id"`
-		UpdateData json.RawMessage `json:"updateData"`
+	if err := h.service.UpdateStand(r.Context(), r.PathValue("id"), version, patch); err != nil {
+		log.Printf("Ошибка обновления стенда: %v", err)
+		writeServiceError(w, err)
+		return
 	}
 
-	if err := json.Unmarshal(body, &patchData); err != nil {
-		http.Error(w, "Некорректный формат JSON", http.StatusBadRequest)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StandsHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
+	var patchData dto.PatchPayload
+	if !decodeBody(w, r, &patchData) {
 		return
 	}
 
-	if err := h.repo.Patch(r.Context(), patchData.ID, patchData.UpdateData); err != nil {
+	if err := h.service.UpdateStand(r.Context(), patchData.ID, patchData.Version, patchData.UpdateData); err != nil {
 		log.Printf("Ошибка обновления стенда: %v", err)
-		http.Error(w, "Ошибка обновления данных", http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StandsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var createData dto.CreatePayload
+	if !decodeBody(w, r, &createData) {
+		return
+	}
+
+	stand, err := h.service.CreateStand(r.Context(), createData.Stand)
+	if err != nil {
+		log.Printf("Ошибка создания стенда: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, stand)
+}
+
+func (h *StandsHandler) handleClone(w http.ResponseWriter, r *http.Request) {
+	var cloneData dto.ClonePayload
+	if !decodeBody(w, r, &cloneData) {
+		return
+	}
+
+	stand, err := h.service.CloneStand(r.Context(), cloneData.ID, cloneData.Stand)
+	if err != nil {
+		log.Printf("Ошибка клонирования стенда: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, stand)
+}
+
+func (h *StandsHandler) handleArchive(w http.ResponseWriter, r *http.Request) {
+	var archiveData dto.ArchivePayload
+	if !decodeBody(w, r, &archiveData) {
+		return
+	}
+
+	if err := h.service.ArchiveStand(r.Context(), archiveData.ID, archiveData.Version); err != nil {
+		log.Printf("Ошибка архивирования стенда: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StandsHandler) handleBook(w http.ResponseWriter, r *http.Request) {
+	var bookData dto.BookPayload
+	if !decodeBody(w, r, &bookData) {
+		return
+	}
+
+	until := time.Unix(bookData.Until, 0)
+	if err := h.service.Book(r.Context(), bookData.ID, bookData.User, bookData.Reason, until); err != nil {
+		log.Printf("Ошибка бронирования стенда: %v", err)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *StandsHandler) handleExtend(w http.ResponseWriter, r *http.Request) {
+	var extendData dto.ExtendPayload
+	if !decodeBody(w, r, &extendData) {
+		return
+	}
+
+	until := time.Unix(extendData.Until, 0)
+	if err := h.service.Extend(r.Context(), extendData.ID, extendData.User, until); err != nil {
+		log.Printf("Ошибка продления бронирования стенда: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StandsHandler) handleRelease(w http.ResponseWriter, r *http.Request) {
+	var releaseData dto.ReleasePayload
+	if !decodeBody(w, r, &releaseData) {
+		return
+	}
+
+	if err := h.service.Release(r.Context(), releaseData.ID, releaseData.User); err != nil {
+		log.Printf("Ошибка освобождения стенда: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StandsHandler) handleGetQueue(w http.ResponseWriter, r *http.Request) {
+	queue, err := h.service.GetQueue(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		log.Printf("Ошибка получения очереди на стенд: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+	if queue == nil {
+		queue = []models.QueueEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, queue)
+}
+
+func (h *StandsHandler) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	var enqueueData dto.EnqueuePayload
+	if !decodeBody(w, r, &enqueueData) {
+		return
+	}
+
+	duration := time.Duration(enqueueData.Duration) * time.Second
+	position, err := h.service.Enqueue(r.Context(), enqueueData.ID, enqueueData.User, enqueueData.Reason, duration)
+	if err != nil {
+		log.Printf("Ошибка постановки в очередь на стенд: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.QueuePositionPayload{Position: position})
+}
+
+func (h *StandsHandler) handleDequeue(w http.ResponseWriter, r *http.Request) {
+	var dequeueData dto.DequeuePayload
+	if !decodeBody(w, r, &dequeueData) {
+		return
+	}
+
+	if err := h.service.Dequeue(r.Context(), dequeueData.ID, dequeueData.User); err != nil {
+		log.Printf("Ошибка выхода из очереди на стенд: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseStandQuery разбирает параметры выборки стендов: status (free или booked), bookedBy,
+// expiringBefore, ветки компонентов вида frontBranch=main, sort, limit и offset.
+func parseStandQuery(r *http.Request) (models.StandQuery, error) {
+	values := r.URL.Query()
+	query := models.StandQuery{BookedBy: values.Get("bookedBy"), Sort: values.Get("sort")}
+
+	switch status := models.StandStatus(values.Get("status")); status {
+	case "", models.StandStatusFree, models.StandStatusBooked:
+		query.Status = status
+	default:
+		return query, errInvalidQuery("status", string(status))
+	}
+	if value := values.Get("expiringBefore"); value != "" {
+		unix, err := parseTime(value)
+		if err != nil {
+			return query, errInvalidQuery("expiringBefore", value)
+		}
+		query.ExpiringBefore = &unix
+	}
+	for name := range values {
+		component, ok := models.ComponentOf(name)
+		if !ok || name != component+"Branch" {
+			continue
+		}
+		if query.Branches == nil {
+			query.Branches = make(map[string]string)
+		}
+		query.Branches[component] = values.Get(name)
+	}
+	if field, _ := query.SortField(); !slices.Contains(models.StandSortFields, field) {
+		return query, errInvalidQuery("sort", query.Sort)
+	}
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		value := values.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return query, errInvalidQuery(name, value)
+		}
+		*target = n
+	}
+	return query, nil
+}
+
+// parseIfMatch разбирает версию стенда из заголовка If-Match. Без заголовка или со значением "*"
+// изменение применяется к текущей версии стенда.
+func parseIfMatch(value string) (*int64, error) {
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(value, "W/"), `"`), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Некорректное значение заголовка If-Match: %s", value)
+	}
+	return &version, nil
+}
+
+// writeJSON сериализует v и отправляет его клиенту с указанным статусом.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Ошибка сериализации ответа: %v", err)
+		http.Error(w, "Ошибка получения данных", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// decodeBody читает JSON из тела запроса. При ошибке отвечает клиенту 400 и возвращает false.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Ошибка чтения тела запроса")
+		return false
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeInvalidRequest, "Некорректный формат JSON")
+		return false
+	}
+	return true
+}
+
+// writeError отправляет клиенту JSON-тело ошибки с кодом.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, dto.ErrorPayload{Message: message, Code: code})
+}
+
+// writeServiceError отправляет клиенту JSON-тело ошибки сервиса стендов.
+func writeServiceError(w http.ResponseWriter, err error) {
+	status, payload := errorPayload(err)
+	writeJSON(w, status, payload)
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/services/standservice"
+	"mts/booking_service/internal/ws/dto"
+)
+
+func TestStandsHandler_Booking(t *testing.T) {
+	t.Run("успешное бронирование", func(t *testing.T) {
+		var bookedID, bookedUser string
+		service := &MockStandUpdater{
+			BookFunc: func(ctx context.Context, id, user, reason string, until time.Time) error {
+				bookedID, bookedUser = id, user
+				return nil
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPost, "/stands/book", strings.NewReader(`{"id":"41","user":"Иванов","until":1764704428}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("Ожидался статус 204, получено %d", rec.Code)
+		}
+		if bookedID != "41" || bookedUser != "Иванов" {
+			t.Errorf("Неожиданные параметры бронирования: %s %s", bookedID, bookedUser)
+		}
+	})
+
+	t.Run("ошибки сервиса отображаются в HTTP-статусы и коды", func(t *testing.T) {
+		cases := map[error]struct {
+			status int
+			code   string
+		}{
+			standservice.ErrStandOccupied:                           {http.StatusConflict, dto.ErrorCodeStandOccupied},
+			standservice.ErrNotBookingOwner:                         {http.StatusForbidden, dto.ErrorCodeNotBookingOwner},
+			standservice.ErrInvalidBooking:                          {http.StatusBadRequest, dto.ErrorCodeInvalidBooking},
+			fmt.Errorf("%w: timeout", models.ErrStorageUnavailable): {http.StatusServiceUnavailable, dto.ErrorCodeStorageUnavailable},
+			errors.New("неизвестная ошибка"):                        {http.StatusInternalServerError, dto.ErrorCodeInternal},
+		}
+		for serviceErr, want := range cases {
+			service := &MockStandUpdater{
+				ReleaseFunc: func(ctx context.Context, id, user string) error {
+					return serviceErr
+				},
+			}
+			handler := NewStandsHandler(service)
+
+			req := httptest.NewRequest(http.MethodPost, "/stands/release", strings.NewReader(`{"id":"41","user":"Иванов"}`))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var payload dto.ErrorPayload
+			json.Unmarshal(rec.Body.Bytes(), &payload)
+			if rec.Code != want.status || payload.Code != want.code {
+				t.Errorf("%v: ожидался статус %d и код %s, получено %d %s", serviceErr, want.status, want.code, rec.Code, payload.Code)
+			}
+		}
+	})
+
+	t.Run("некорректный патч возвращает поле в подробностях", func(t *testing.T) {
+		service := &MockStandUpdater{
+			UpdateStandFunc: func(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
+				return patch.Validate()
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPatch, "/stands", strings.NewReader(`{"id":"41","updateData":{"status":"occupied"}}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var payload dto.ErrorPayload
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("Ожидалось JSON-тело ошибки: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest || payload.Code != dto.ErrorCodeInvalidPatch || payload.Details["field"] != "status" {
+			t.Errorf("Неожиданный ответ: %d %+v", rec.Code, payload)
+		}
+	})
+
+	t.Run("конфликт версий при PATCH возвращает 409 и актуальный стенд", func(t *testing.T) {
+		service := &MockStandUpdater{
+			UpdateStandFunc: func(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
+				return &models.ConflictError{Current: &models.Stand{ID: 41, Version: 3}}
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPatch, "/stands", strings.NewReader(`{"id":"41","version":2,"updateData":{"users":"Иванов"}}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("Ожидался статус 409, получено %d", rec.Code)
+		}
+		var payload dto.ErrorPayload
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("Ожидалось JSON-тело ошибки: %v", err)
+		}
+		if payload.Code != "conflict" || payload.Stand == nil || payload.Stand.Version != 3 {
+			t.Errorf("Неожиданное тело ошибки: %+v", payload)
+		}
+	})
+
+	t.Run("неподдерживаемый метод", func(t *testing.T) {
+		handler := NewStandsHandler(&MockStandUpdater{})
+
+		req := httptest.NewRequest(http.MethodGet, "/stands/book", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var payload dto.ErrorPayload
+		json.Unmarshal(rec.Body.Bytes(), &payload)
+		if rec.Code != http.StatusMethodNotAllowed || payload.Code != dto.ErrorCodeMethodNotAllowed {
+			t.Errorf("Ожидался статус 405 с кодом method_not_allowed, получено %d %+v", rec.Code, payload)
+		}
+	})
+}
+
+func TestStandsHandler_Resources(t *testing.T) {
+	t.Run("фильтры, порядок и страница из параметров запроса", func(t *testing.T) {
+		var received models.StandQuery
+		service := &MockStandUpdater{
+			QueryStandsFunc: func(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error) {
+				received = query
+				return []models.Stand{{ID: 41, Name: "alpha"}}, 7, nil
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodGet, "/stands?status=booked&bookedBy=Иванов&expiringBefore=2023-11-14T22:13:20Z&frontBranch=main&sort=-endDate&limit=1&offset=3", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Total-Count") != "7" {
+			t.Fatalf("Ожидался статус 200 с X-Total-Count 7, получено %d %q", rec.Code, rec.Header().Get("X-Total-Count"))
+		}
+		if received.Status != models.StandStatusBooked || received.BookedBy != "Иванов" || received.Branches["front"] != "main" ||
+			received.Sort != "-endDate" || received.Limit != 1 || received.Offset != 3 {
+			t.Errorf("Неожиданный запрос стендов: %+v", received)
+		}
+		if received.ExpiringBefore == nil || *received.ExpiringBefore != 1_700_000_000 {
+			t.Errorf("Ожидался expiringBefore 1700000000, получено %v", received.ExpiringBefore)
+		}
+	})
+
+	t.Run("некорректные параметры запроса", func(t *testing.T) {
+		handler := NewStandsHandler(&MockStandUpdater{})
+		for _, query := range []string{"status=busy", "sort=reason", "limit=-1", "offset=x", "expiringBefore=завтра"} {
+			req := httptest.NewRequest(http.MethodGet, "/stands?"+query, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var payload dto.ErrorPayload
+			json.Unmarshal(rec.Body.Bytes(), &payload)
+			if rec.Code != http.StatusBadRequest || payload.Code != dto.ErrorCodeInvalidRequest {
+				t.Errorf("%s: ожидался статус 400 с кодом invalid_request, получено %d %+v", query, rec.Code, payload)
+			}
+		}
+	})
+
+	t.Run("стенд по id с версией в ETag", func(t *testing.T) {
+		service := &MockStandUpdater{
+			GetStandFunc: func(ctx context.Context, id string) (*models.Stand, error) {
+				if id != "41" {
+					return nil, models.ErrStandNotFound
+				}
+				return &models.Stand{ID: 41, Name: "alpha", Version: 3}, nil
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodGet, "/stands/41", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var stand models.Stand
+		json.Unmarshal(rec.Body.Bytes(), &stand)
+		if rec.Code != http.StatusOK || stand.ID != 41 || rec.Header().Get("ETag") != `"3"` {
+			t.Errorf("Ожидался стенд 41 с ETag \"3\", получено %d %q %s", rec.Code, rec.Header().Get("ETag"), rec.Body)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/stands/404", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Ожидался статус 404, получено %d", rec.Code)
+		}
+	})
+
+	t.Run("патч стенда по id с версией из If-Match", func(t *testing.T) {
+		var patchedID string
+		var patchedVersion *int64
+		var patched models.StandPatch
+		service := &MockStandUpdater{
+			UpdateStandFunc: func(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
+				patchedID, patchedVersion, patched = id, version, patch
+				return nil
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPatch, "/stands/41", strings.NewReader(`{"comment":"релиз"}`))
+		req.Header.Set("If-Match", `"3"`)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("Ожидался статус 204, получено %d %s", rec.Code, rec.Body)
+		}
+		if patchedID != "41" || patchedVersion == nil || *patchedVersion != 3 || string(patched["comment"]) != `"релиз"` {
+			t.Errorf("Неожиданные параметры патча: %s %v %v", patchedID, patchedVersion, patched)
+		}
+
+		req = httptest.NewRequest(http.MethodPatch, "/stands/41", strings.NewReader(`{}`))
+		req.Header.Set("If-Match", "latest")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Ожидался статус 400 для некорректного If-Match, получено %d", rec.Code)
+		}
+	})
+
+	t.Run("пути операций не являются стендами", func(t *testing.T) {
+		handler := NewStandsHandler(&MockStandUpdater{
+			GetStandFunc: func(ctx context.Context, id string) (*models.Stand, error) {
+				t.Errorf("Не ожидался запрос стенда %s", id)
+				return nil, models.ErrStandNotFound
+			},
+		})
+		for _, target := range []string{"GET /stands/release", "PATCH /stands/queue", "DELETE /stands/41"} {
+			method, path, _ := strings.Cut(target, " ")
+			req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s: ожидался статус 405, получено %d", target, rec.Code)
+			}
+		}
+	})
+}
+
+func TestStandsHandler_Lifecycle(t *testing.T) {
+	t.Run("создание стенда возвращает 201 и стенд", func(t *testing.T) {
+		service := &MockStandUpdater{
+			CreateStandFunc: func(ctx context.Context, fields models.StandPatch) (*models.Stand, error) {
+				if string(fields["name"]) != `"beta"` {
+					t.Errorf("Неожиданные поля стенда: %v", fields)
+				}
+				return &models.Stand{ID: 42, Name: "beta"}, nil
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPost, "/stands", strings.NewReader(`{"stand":{"name":"beta"}}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var stand models.Stand
+		json.Unmarshal(rec.Body.Bytes(), &stand)
+		if rec.Code != http.StatusCreated || stand.ID != 42 {
+			t.Errorf("Ожидался статус 201 с новым стендом, получено %d %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("клонирование стенда с занятым именем", func(t *testing.T) {
+		service := &MockStandUpdater{
+			CloneStandFunc: func(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error) {
+				return nil, fmt.Errorf("%w: alpha", standservice.ErrStandExists)
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPost, "/stands/clone", strings.NewReader(`{"id":"41","stand":{"name":"alpha"}}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var payload dto.ErrorPayload
+		json.Unmarshal(rec.Body.Bytes(), &payload)
+		if rec.Code != http.StatusConflict || payload.Code != dto.ErrorCodeStandExists {
+			t.Errorf("Ожидался статус 409 с кодом stand_exists, получено %d %+v", rec.Code, payload)
+		}
+	})
+
+	t.Run("архивирование стенда", func(t *testing.T) {
+		var archivedID string
+		var archivedVersion *int64
+		service := &MockStandUpdater{
+			ArchiveStandFunc: func(ctx context.Context, id string, version *int64) error {
+				archivedID, archivedVersion = id, version
+				return nil
+			},
+		}
+		handler := NewStandsHandler(service)
+
+		req := httptest.NewRequest(http.MethodPost, "/stands/archive", strings.NewReader(`{"id":"41","version":3}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("Ожидался статус 204, получено %d", rec.Code)
+		}
+		if archivedID != "41" || archivedVersion == nil || *archivedVersion != 3 {
+			t.Errorf("Неожиданные параметры архивирования: %s %v", archivedID, archivedVersion)
+		}
+	})
+}
+
+func TestRequireAuth(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(&config.AuthConfig{Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatalf("Ожидалась ошибка nil, получено %v", err)
+	}
+
+	var caller auth.Identity
+	handler := RequireAuth(authenticator, NewStandsHandler(&MockStandUpdater{
+		ReleaseFunc: func(ctx context.Context, id, user string) error {
+			caller, _ = auth.IdentityFromContext(ctx)
+			return nil
+		},
+	}))
+
+	t.Run("запрос без токена отклоняется", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/stands/release", strings.NewReader(`{"id":"41","user":"Иванов"}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var payload dto.ErrorPayload
+		json.Unmarshal(rec.Body.Bytes(), &payload)
+		if rec.Code != http.StatusUnauthorized || payload.Code != dto.ErrorCodeUnauthorized {
+			t.Errorf("Ожидался статус 401 с кодом unauthorized, получено %d %+v", rec.Code, payload)
+		}
+	})
+
+	t.Run("пользователь из токена доступен сервису", func(t *testing.T) {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "Иванов",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte("secret"))
+
+		req := httptest.NewRequest(http.MethodPost, "/stands/release", strings.NewReader(`{"id":"41","user":"Петров"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent || caller.User != "Иванов" {
+			t.Errorf("Ожидался статус 204 и пользователь Иванов, получено %d %+v", rec.Code, caller)
+		}
+	})
+}
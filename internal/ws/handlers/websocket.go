@@ -3,44 +3,91 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"maps"
 	"net/http"
-	"sync"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"mts/booking_service/internal/audit"
+	"mts/booking_service/internal/auth"
+	"mts/booking_service/internal/models"
 	"mts/booking_service/internal/ws/dto"
 )
 
 // StandUpdater определяет интерфейс для сервиса стендов.
 type StandUpdater interface {
-	UpdateStand(ctx context.Context, id string, data []byte) error
-	GetInitialStands(ctx context.Context) ([]byte, error)
-}
-
-var upgrader = websocket.Upgrader{
-	CheckOrigin:     func(r *http.Request) bool { return true },
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+	UpdateStand(ctx context.Context, id string, version *int64, patch models.StandPatch) error
+	GetInitialStands(ctx context.Context) ([]models.Stand, error)
+	Book(ctx context.Context, id, user, reason string, until time.Time) error
+	Extend(ctx context.Context, id, user string, until time.Time) error
+	Release(ctx context.Context, id, user string) error
+	Enqueue(ctx context.Context, id, user, reason string, duration time.Duration) (int, error)
+	Dequeue(ctx context.Context, id, user string) error
+	CreateStand(ctx context.Context, fields models.StandPatch) (*models.Stand, error)
+	CloneStand(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error)
+	ArchiveStand(ctx context.Context, id string, version *int64) error
 }
 
 // Hub управляет пулом WebSocket-клиентов.
 type Hub struct {
-	clients    map[*websocket.Conn]bool
-	mu         sync.Mutex
+	clients    map[*client]bool
 	service    StandUpdater
-	broadcast  chan []byte
-	register   chan *websocket.Conn
-	unregister chan *websocket.Conn
+	handlers   map[string]func(c *client, msg dto.WsMessage)
+	heartbeat  Heartbeat
+	upgrader   websocket.Upgrader
+	origins    map[string]bool
+	broadcast  chan dto.WsMessage
+	direct     chan directMessage
+	register   chan *client
+	unregister chan *client
+}
+
+// directMessage - сообщение для всех сессий одного пользователя.
+type directMessage struct {
+	user    string
+	message dto.WsMessage
 }
 
 // NewHub создает новый Hub.
 func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[*websocket.Conn]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *websocket.Conn),
-		unregister: make(chan *websocket.Conn),
+	h := &Hub{
+		clients:    make(map[*client]bool),
+		heartbeat:  DefaultHeartbeat,
+		broadcast:  make(chan dto.WsMessage),
+		direct:     make(chan directMessage),
+		register:   make(chan *client),
+		unregister: make(chan *client),
 	}
+	h.upgrader = websocket.Upgrader{
+		CheckOrigin:     h.checkOrigin,
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+	h.handlers = map[string]func(c *client, msg dto.WsMessage){
+		dto.MessageTypePatch:   h.handlePatch,
+		dto.MessageTypeCreate:  h.handleCreate,
+		dto.MessageTypeClone:   h.handleClone,
+		dto.MessageTypeArchive: h.handleArchive,
+		dto.MessageTypeBook:    h.handleBook,
+		dto.MessageTypeExtend:  h.handleExtend,
+		dto.MessageTypeRelease: h.handleRelease,
+		dto.MessageTypeEnqueue: h.handleEnqueue,
+		dto.MessageTypeDequeue: h.handleDequeue,
+		dto.MessageTypeResync: func(c *client, msg dto.WsMessage) {
+			h.sendInitialStands(c, msg.RequestID)
+		},
+	}
+	return h
+}
+
+// MessageTypes возвращает типы сообщений клиента, которые обрабатывает хаб, по алфавиту.
+func (h *Hub) MessageTypes() []string {
+	return slices.Sorted(maps.Keys(h.handlers))
 }
 
 // SetService устанавливает сервис для хаба.
@@ -48,123 +95,383 @@ func (h *Hub) SetService(service StandUpdater) {
 	h.service = service
 }
 
-// Run запускает главный цикл Hub для обработки событий.
+// SetHeartbeat устанавливает параметры проверки живости соединений.
+// Действует на клиентов, подключившихся после вызова.
+func (h *Hub) SetHeartbeat(heartbeat Heartbeat) {
+	h.heartbeat = heartbeat
+}
+
+// AnyOrigin в списке SetAllowedOrigins разрешает подключения со страниц любого Origin.
+const AnyOrigin = "*"
+
+// SetAllowedOrigins разрешает подключения к хабу со страниц с указанными Origin помимо
+// страниц самого сервиса. Пустой список разрешает только страницы сервиса, AnyOrigin - любые.
+func (h *Hub) SetAllowedOrigins(origins []string) {
+	h.origins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		h.origins[origin] = true
+	}
+}
+
+// checkOrigin разрешает подключения без Origin (не из браузера), со страниц сервиса
+// и с разрешенных Origin.
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.origins[AnyOrigin] || h.origins[origin] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Run запускает главный цикл Hub для обработки событий. Сообщения только ставятся
+// в очереди клиентов, поэтому рассылка не ждет медленных клиентов.
 func (h *Hub) Run() {
 	for {
 		select {
-		case conn := <-h.register:
-			h.mu.Lock()
-			h.clients[conn] = true
-			h.mu.Unlock()
+		case c := <-h.register:
+			h.clients[c] = true
 			log.Println("Новый клиент подключен.")
-			h.sendInitialStands(conn)
+			// Начальное состояние ставится в очередь в цикле хаба, чтобы рассылки,
+			// отправленные после его чтения, не обогнали его.
+			h.sendInitialStands(c, "")
 
-		case conn := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[conn]; ok {
-				delete(h.clients, conn)
-				conn.Close()
+		case c := <-h.unregister:
+			if _, ok := h.clients[c]; ok {
+				delete(h.clients, c)
+				c.close()
 				log.Println("Клиент отключен.")
 			}
-			h.mu.Unlock()
 
 		case message := <-h.broadcast:
-			h.mu.Lock()
-			for conn := range h.clients {
-				updateMsg := dto.WsMessage{
-					Type:    "UPDATE",
-					Payload: message,
+			for c := range h.clients {
+				if !c.enqueue(message) {
+					delete(h.clients, c)
 				}
-				if err := conn.WriteJSON(updateMsg); err != nil {
-					log.Printf("Ошибка отправки сообщения клиенту: %v", err)
+			}
+			log.Printf("Сообщение '%s' разослано всем клиентам.", message.Type)
+
+		case direct := <-h.direct:
+			sessions := 0
+			for c := range h.clients {
+				if c.user == "" || !strings.EqualFold(c.user, direct.user) {
+					continue
+				}
+				sessions++
+				if !c.enqueue(direct.message) {
+					delete(h.clients, c)
 				}
 			}
-			h.mu.Unlock()
-			log.Println("Сообщение 'UPDATE' разослано всем клиентам.")
+			log.Printf("Сообщение '%s' отправлено пользователю %s в %d сессий.", direct.message.Type, direct.user, sessions)
 		}
 	}
 }
 
-func (h *Hub) sendInitialStands(conn *websocket.Conn) {
-	initialStands, err := h.service.GetInitialStands(context.Background())
+// sendInitialStands отправляет клиенту полное состояние стендов. Используется
+// при подключении и по запросу RESYNC, в ответ на который возвращается requestID запроса.
+func (h *Hub) sendInitialStands(c *client, requestID string) {
+	initialStands, err := h.service.GetInitialStands(c.ctx)
 	if err != nil {
 		log.Printf("Ошибка получения начального состояния стендов: %v", err)
-		h.sendError(conn, "Не удалось получить начальное состояние стендов.")
+		_, errPayload := errorPayload(err)
+		errPayload.Message = "Не удалось получить начальное состояние стендов."
+		h.sendErrorPayload(c, requestID, errPayload)
 		return
 	}
 
-	updateMsg := dto.WsMessage{
-		Type:    "UPDATE",
-		Payload: initialStands,
+	payload, err := json.Marshal(initialStands)
+	if err != nil {
+		log.Printf("Ошибка сериализации начального состояния стендов: %v", err)
+		h.sendError(c, requestID, dto.ErrorCodeInternal, "Не удалось получить начальное состояние стендов.")
+		return
+	}
+
+	c.enqueue(dto.WsMessage{
+		Type:      dto.MessageTypeUpdate,
+		RequestID: requestID,
+		Payload:   payload,
+	})
+}
+
+// Broadcast реализует интерфейс Notifier для StandService: рассылает полное состояние стендов.
+func (h *Hub) Broadcast(stands []models.Stand) {
+	h.send(dto.MessageTypeUpdate, stands)
+}
+
+// StandChanged реализует интерфейс Notifier для StandService: рассылает новое состояние стенда.
+func (h *Hub) StandChanged(stand models.Stand) {
+	h.send(dto.MessageTypeStandChanged, stand)
+}
+
+// StandRemoved реализует интерфейс Notifier для StandService: сообщает об удалении стенда.
+func (h *Hub) StandRemoved(id int64) {
+	h.send(dto.MessageTypeStandRemoved, dto.StandRemovedPayload{ID: id})
+}
+
+// Remind реализует интерфейс ReminderChannel для ReminderScheduler: отправляет
+// напоминание во все открытые сессии владельца бронирования.
+func (h *Hub) Remind(ctx context.Context, reminder models.Reminder) error {
+	payload, err := json.Marshal(dto.ReminderPayload{
+		ID:          reminder.Stand.ID,
+		Name:        reminder.Stand.Name,
+		User:        reminder.User,
+		EndDate:     reminder.Stand.EndDate,
+		Offset:      reminder.Offset,
+		ExtendUntil: reminder.ExtendUntil,
+		ExtendToken: reminder.ExtendToken,
+		ExtendURL:   reminder.ExtendURL,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации напоминания: %w", err)
 	}
-	if err := conn.WriteJSON(updateMsg); err != nil {
-		log.Printf("Ошибка отправки начального состояния клиенту: %v", err)
+	select {
+	case h.direct <- directMessage{user: reminder.User, message: dto.WsMessage{Type: dto.MessageTypeReminder, Payload: payload}}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// Broadcast реализует интерфейс Notifier для StandService.
-func (h *Hub) Broadcast(message []byte) {
-	h.broadcast <- message
+// send сериализует payload и рассылает сообщение всем клиентам.
+func (h *Hub) send(messageType string, payload any) {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Ошибка сериализации сообщения '%s' для рассылки: %v", messageType, err)
+		return
+	}
+	h.broadcast <- dto.WsMessage{Type: messageType, Payload: message}
 }
 
 // ServeHTTP обрабатывает входящие HTTP-запросы и обновляет их до WebSocket.
 func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Ошибка обновления до WebSocket: %v", err)
 		return
 	}
-	h.register <- conn
-	go h.handleClientMessages(conn)
+	// Контекст запроса отменяется после возврата из ServeHTTP, а пользователь
+	// из него нужен на все время соединения.
+	c := newClient(audit.WithTransport(context.WithoutCancel(r.Context()), models.TransportWS), conn, h.heartbeat)
+	c.user = sessionUser(r)
+	go c.writePump()
+	h.register <- c
+	go h.handleClientMessages(c)
 }
 
-func (h *Hub) handleClientMessages(conn *websocket.Conn) {
+// sessionUser возвращает пользователя сессии: аутентифицированного пользователя
+// или, если аутентификация выключена, пользователя из параметра запроса user.
+func sessionUser(r *http.Request) string {
+	if identity, ok := auth.IdentityFromContext(r.Context()); ok {
+		return identity.User
+	}
+	return r.URL.Query().Get("user")
+}
+
+func (h *Hub) handleClientMessages(c *client) {
 	defer func() {
-		h.unregister <- conn
+		h.unregister <- c
 	}()
 
+	c.startReading()
+
 	for {
 		var msg dto.WsMessage
-		if err := conn.ReadJSON(&msg); err != nil {
+		if err := c.conn.ReadJSON(&msg); err != nil {
 			log.Printf("Ошибка чтения JSON сообщения: %v", err)
-			h.sendError(conn, "Некорректный формат сообщения.")
+			h.sendError(c, "", dto.ErrorCodeInvalidRequest, "Некорректный формат сообщения.")
 			// В случае ошибки чтения, соединение, вероятно, невалидно, поэтому выходим из цикла.
 			break
 		}
 
-		switch msg.Type {
-		case "PATCH":
-			h.handlePatch(conn, msg.Payload)
-		default:
+		handle, ok := h.handlers[msg.Type]
+		if !ok {
 			log.Printf("Получен неизвестный тип сообщения: %s", msg.Type)
-			h.sendError(conn, "Неизвестный тип сообщения.")
+			h.sendError(c, msg.RequestID, dto.ErrorCodeUnknownMessageType, "Неизвестный тип сообщения.")
+			continue
 		}
+		handle(c, msg)
 	}
 }
 
-func (h *Hub) handlePatch(conn *websocket.Conn, payload json.RawMessage) {
+func (h *Hub) handlePatch(c *client, msg dto.WsMessage) {
 	var patchPayload dto.PatchPayload
-	if err := json.Unmarshal(payload, &patchPayload); err != nil {
+	if err := json.Unmarshal(msg.Payload, &patchPayload); err != nil {
 		log.Printf("Ошибка парсинга PATCH payload: %v", err)
-		h.sendError(conn, "Некорректный payload для PATCH сообщения.")
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для PATCH сообщения.")
 		return
 	}
 
-	if err := h.service.UpdateStand(context.Background(), patchPayload.ID, patchPayload.UpdateData); err != nil {
+	if err := h.service.UpdateStand(c.ctx, patchPayload.ID, patchPayload.Version, patchPayload.UpdateData); err != nil {
 		log.Printf("Ошибка при обработке PATCH сообщения от клиента: %v", err)
-		h.sendError(conn, "Не удалось обновить данные.")
+		h.sendServiceError(c, msg.RequestID, err)
 		return
 	}
+	h.sendAck(c, msg.RequestID, nil)
 }
 
-func (h *Hub) sendError(conn *websocket.Conn, message string) {
-	errorPayload := dto.ErrorPayload{Message: message}
-	payloadBytes, _ := json.Marshal(errorPayload)
-	errorMsg := dto.WsMessage{
-		Type:    "ERROR",
-		Payload: payloadBytes,
+func (h *Hub) handleCreate(c *client, msg dto.WsMessage) {
+	var createPayload dto.CreatePayload
+	if err := json.Unmarshal(msg.Payload, &createPayload); err != nil {
+		log.Printf("Ошибка парсинга CREATE payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для CREATE сообщения.")
+		return
 	}
-	if err := conn.WriteJSON(errorMsg); err != nil {
-		log.Printf("Ошибка отправки сообщения об ошибке клиенту: %v", err)
+
+	stand, err := h.service.CreateStand(c.ctx, createPayload.Stand)
+	if err != nil {
+		log.Printf("Ошибка при обработке CREATE сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
 	}
+	h.sendAck(c, msg.RequestID, stand)
+}
+
+func (h *Hub) handleClone(c *client, msg dto.WsMessage) {
+	var clonePayload dto.ClonePayload
+	if err := json.Unmarshal(msg.Payload, &clonePayload); err != nil {
+		log.Printf("Ошибка парсинга CLONE payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для CLONE сообщения.")
+		return
+	}
+
+	stand, err := h.service.CloneStand(c.ctx, clonePayload.ID, clonePayload.Stand)
+	if err != nil {
+		log.Printf("Ошибка при обработке CLONE сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, stand)
+}
+
+func (h *Hub) handleArchive(c *client, msg dto.WsMessage) {
+	var archivePayload dto.ArchivePayload
+	if err := json.Unmarshal(msg.Payload, &archivePayload); err != nil {
+		log.Printf("Ошибка парсинга ARCHIVE payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для ARCHIVE сообщения.")
+		return
+	}
+
+	if err := h.service.ArchiveStand(c.ctx, archivePayload.ID, archivePayload.Version); err != nil {
+		log.Printf("Ошибка при обработке ARCHIVE сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, nil)
+}
+
+func (h *Hub) handleBook(c *client, msg dto.WsMessage) {
+	var bookPayload dto.BookPayload
+	if err := json.Unmarshal(msg.Payload, &bookPayload); err != nil {
+		log.Printf("Ошибка парсинга BOOK payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для BOOK сообщения.")
+		return
+	}
+
+	until := time.Unix(bookPayload.Until, 0)
+	if err := h.service.Book(c.ctx, bookPayload.ID, bookPayload.User, bookPayload.Reason, until); err != nil {
+		log.Printf("Ошибка при обработке BOOK сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, nil)
+}
+
+func (h *Hub) handleExtend(c *client, msg dto.WsMessage) {
+	var extendPayload dto.ExtendPayload
+	if err := json.Unmarshal(msg.Payload, &extendPayload); err != nil {
+		log.Printf("Ошибка парсинга EXTEND payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для EXTEND сообщения.")
+		return
+	}
+
+	until := time.Unix(extendPayload.Until, 0)
+	if err := h.service.Extend(c.ctx, extendPayload.ID, extendPayload.User, until); err != nil {
+		log.Printf("Ошибка при обработке EXTEND сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, nil)
+}
+
+func (h *Hub) handleRelease(c *client, msg dto.WsMessage) {
+	var releasePayload dto.ReleasePayload
+	if err := json.Unmarshal(msg.Payload, &releasePayload); err != nil {
+		log.Printf("Ошибка парсинга RELEASE payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для RELEASE сообщения.")
+		return
+	}
+
+	if err := h.service.Release(c.ctx, releasePayload.ID, releasePayload.User); err != nil {
+		log.Printf("Ошибка при обработке RELEASE сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, nil)
+}
+
+func (h *Hub) handleEnqueue(c *client, msg dto.WsMessage) {
+	var enqueuePayload dto.EnqueuePayload
+	if err := json.Unmarshal(msg.Payload, &enqueuePayload); err != nil {
+		log.Printf("Ошибка парсинга ENQUEUE payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для ENQUEUE сообщения.")
+		return
+	}
+
+	duration := time.Duration(enqueuePayload.Duration) * time.Second
+	position, err := h.service.Enqueue(c.ctx, enqueuePayload.ID, enqueuePayload.User, enqueuePayload.Reason, duration)
+	if err != nil {
+		log.Printf("Ошибка при обработке ENQUEUE сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, dto.QueuePositionPayload{Position: position})
+}
+
+func (h *Hub) handleDequeue(c *client, msg dto.WsMessage) {
+	var dequeuePayload dto.DequeuePayload
+	if err := json.Unmarshal(msg.Payload, &dequeuePayload); err != nil {
+		log.Printf("Ошибка парсинга DEQUEUE payload: %v", err)
+		h.sendError(c, msg.RequestID, dto.ErrorCodeInvalidRequest, "Некорректный payload для DEQUEUE сообщения.")
+		return
+	}
+
+	if err := h.service.Dequeue(c.ctx, dequeuePayload.ID, dequeuePayload.User); err != nil {
+		log.Printf("Ошибка при обработке DEQUEUE сообщения от клиента: %v", err)
+		h.sendServiceError(c, msg.RequestID, err)
+		return
+	}
+	h.sendAck(c, msg.RequestID, nil)
+}
+
+// sendAck подтверждает успешную обработку запроса. Подтверждение отправляется
+// только на запросы с requestId: старые клиенты его не ожидают.
+func (h *Hub) sendAck(c *client, requestID string, payload any) {
+	if requestID == "" {
+		return
+	}
+	payloadBytes, _ := json.Marshal(payload)
+	c.enqueue(dto.WsMessage{
+		Type:      dto.MessageTypeAck,
+		RequestID: requestID,
+		Payload:   payloadBytes,
+	})
+}
+
+func (h *Hub) sendError(c *client, requestID, code, message string) {
+	h.sendErrorPayload(c, requestID, dto.ErrorPayload{Message: message, Code: code})
+}
+
+// sendServiceError отправляет клиенту ошибку сервиса стендов вместе с ее кодом.
+func (h *Hub) sendServiceError(c *client, requestID string, err error) {
+	_, payload := errorPayload(err)
+	h.sendErrorPayload(c, requestID, payload)
+}
+
+func (h *Hub) sendErrorPayload(c *client, requestID string, errorPayload dto.ErrorPayload) {
+	payloadBytes, _ := json.Marshal(errorPayload)
+	c.enqueue(dto.WsMessage{
+		Type:      dto.MessageTypeError,
+		RequestID: requestID,
+		Payload:   payloadBytes,
+	})
 }
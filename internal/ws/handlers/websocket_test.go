@@ -6,32 +6,137 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/services/standservice"
 	"mts/booking_service/internal/ws/dto"
 )
 
 // MockStandUpdater - это мок для сервиса стендов.
 type MockStandUpdater struct {
-	UpdateStandFunc      func(ctx context.Context, id string, data []byte) error
-	GetInitialStandsFunc func(ctx context.Context) ([]byte, error)
+	UpdateStandFunc      func(ctx context.Context, id string, version *int64, patch models.StandPatch) error
+	GetInitialStandsFunc func(ctx context.Context) ([]models.Stand, error)
+	GetStandsFunc        func(ctx context.Context) ([]models.Stand, error)
+	QueryStandsFunc      func(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error)
+	GetStandFunc         func(ctx context.Context, id string) (*models.Stand, error)
+	BookFunc             func(ctx context.Context, id, user, reason string, until time.Time) error
+	ExtendFunc           func(ctx context.Context, id, user string, until time.Time) error
+	ReleaseFunc          func(ctx context.Context, id, user string) error
+	EnqueueFunc          func(ctx context.Context, id, user, reason string, duration time.Duration) (int, error)
+	DequeueFunc          func(ctx context.Context, id, user string) error
+	GetQueueFunc         func(ctx context.Context, id string) ([]models.QueueEntry, error)
+	CreateStandFunc      func(ctx context.Context, fields models.StandPatch) (*models.Stand, error)
+	CloneStandFunc       func(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error)
+	ArchiveStandFunc     func(ctx context.Context, id string, version *int64) error
 }
 
-func (m *MockStandUpdater) UpdateStand(ctx context.Context, id string, data []byte) error {
+func (m *MockStandUpdater) UpdateStand(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
 	if m.UpdateStandFunc != nil {
-		return m.UpdateStandFunc(ctx, id, data)
+		return m.UpdateStandFunc(ctx, id, version, patch)
 	}
 	return nil
 }
 
-func (m *MockStandUpdater) GetInitialStands(ctx context.Context) ([]byte, error) {
+func (m *MockStandUpdater) GetInitialStands(ctx context.Context) ([]models.Stand, error) {
 	if m.GetInitialStandsFunc != nil {
 		return m.GetInitialStandsFunc(ctx)
 	}
-	return []byte(`[{"id":"initial"}]`), nil
+	return []models.Stand{{ID: 1, Name: "initial"}}, nil
+}
+
+func (m *MockStandUpdater) GetStands(ctx context.Context) ([]models.Stand, error) {
+	if m.GetStandsFunc != nil {
+		return m.GetStandsFunc(ctx)
+	}
+	return m.GetInitialStands(ctx)
+}
+
+func (m *MockStandUpdater) QueryStands(ctx context.Context, query models.StandQuery) ([]models.Stand, int, error) {
+	if m.QueryStandsFunc != nil {
+		return m.QueryStandsFunc(ctx, query)
+	}
+	stands, err := m.GetStands(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := query.Apply(stands)
+	return page, total, nil
+}
+
+func (m *MockStandUpdater) GetStand(ctx context.Context, id string) (*models.Stand, error) {
+	if m.GetStandFunc != nil {
+		return m.GetStandFunc(ctx, id)
+	}
+	return &models.Stand{ID: 1, Name: "initial"}, nil
+}
+
+func (m *MockStandUpdater) Book(ctx context.Context, id, user, reason string, until time.Time) error {
+	if m.BookFunc != nil {
+		return m.BookFunc(ctx, id, user, reason, until)
+	}
+	return nil
+}
+
+func (m *MockStandUpdater) Extend(ctx context.Context, id, user string, until time.Time) error {
+	if m.ExtendFunc != nil {
+		return m.ExtendFunc(ctx, id, user, until)
+	}
+	return nil
+}
+
+func (m *MockStandUpdater) Release(ctx context.Context, id, user string) error {
+	if m.ReleaseFunc != nil {
+		return m.ReleaseFunc(ctx, id, user)
+	}
+	return nil
+}
+
+func (m *MockStandUpdater) Enqueue(ctx context.Context, id, user, reason string, duration time.Duration) (int, error) {
+	if m.EnqueueFunc != nil {
+		return m.EnqueueFunc(ctx, id, user, reason, duration)
+	}
+	return 1, nil
+}
+
+func (m *MockStandUpdater) Dequeue(ctx context.Context, id, user string) error {
+	if m.DequeueFunc != nil {
+		return m.DequeueFunc(ctx, id, user)
+	}
+	return nil
+}
+
+func (m *MockStandUpdater) GetQueue(ctx context.Context, id string) ([]models.QueueEntry, error) {
+	if m.GetQueueFunc != nil {
+		return m.GetQueueFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockStandUpdater) CreateStand(ctx context.Context, fields models.StandPatch) (*models.Stand, error) {
+	if m.CreateStandFunc != nil {
+		return m.CreateStandFunc(ctx, fields)
+	}
+	return &models.Stand{ID: 2, Name: "created"}, nil
+}
+
+func (m *MockStandUpdater) CloneStand(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error) {
+	if m.CloneStandFunc != nil {
+		return m.CloneStandFunc(ctx, id, fields)
+	}
+	return &models.Stand{ID: 2, Name: "cloned"}, nil
+}
+
+func (m *MockStandUpdater) ArchiveStand(ctx context.Context, id string, version *int64) error {
+	if m.ArchiveStandFunc != nil {
+		return m.ArchiveStandFunc(ctx, id, version)
+	}
+	return nil
 }
 
 // Helper для создания тестового websocket клиента
@@ -66,11 +171,238 @@ func TestHub_ClientCommunication(t *testing.T) {
 		if receivedMsg.Type != "UPDATE" {
 			t.Errorf("Ожидался тип 'UPDATE', получено '%s'", receivedMsg.Type)
 		}
-		if string(receivedMsg.Payload) != `[{"id":"initial"}]` {
+		var stands []models.Stand
+		if err := json.Unmarshal(receivedMsg.Payload, &stands); err != nil {
+			t.Fatalf("Не удалось разобрать payload: %v", err)
+		}
+		if len(stands) != 1 || stands[0].ID != 1 || stands[0].Name != "initial" {
 			t.Errorf("Получен неожиданный payload: %s", string(receivedMsg.Payload))
 		}
 	})
 
+	t.Run("рассылка во время чтения начального состояния приходит после него", func(t *testing.T) {
+		hub := NewHub()
+		hub.SetService(&MockStandUpdater{
+			GetInitialStandsFunc: func(ctx context.Context) ([]models.Stand, error) {
+				go hub.StandChanged(models.Stand{ID: 1, Name: "changed"})
+				time.Sleep(50 * time.Millisecond)
+				return []models.Stand{{ID: 1, Name: "initial"}}, nil
+			},
+		})
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+
+		for _, want := range []string{dto.MessageTypeUpdate, dto.MessageTypeStandChanged} {
+			var msg dto.WsMessage
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Не удалось прочитать JSON сообщение: %v", err)
+			}
+			if msg.Type != want {
+				t.Errorf("Ожидался тип '%s', получено '%s'", want, msg.Type)
+			}
+		}
+	})
+
+	t.Run("рассылка изменения одного стенда", func(t *testing.T) {
+		hub := NewHub()
+		hub.SetService(&MockStandUpdater{})
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		hub.StandChanged(models.Stand{ID: 41, Name: "alpha", Users: "Иванов"})
+		hub.StandRemoved(42)
+
+		var changedMsg, removedMsg dto.WsMessage
+		conn.ReadJSON(&changedMsg)
+		conn.ReadJSON(&removedMsg)
+		var changed models.Stand
+		if changedMsg.Type != "STAND_CHANGED" || json.Unmarshal(changedMsg.Payload, &changed) != nil || changed.ID != 41 || changed.Users != "Иванов" {
+			t.Errorf("Получено неожиданное сообщение STAND_CHANGED: %s %s", changedMsg.Type, changedMsg.Payload)
+		}
+		if removedMsg.Type != "STAND_REMOVED" || string(removedMsg.Payload) != `{"id":42}` {
+			t.Errorf("Получено неожиданное сообщение STAND_REMOVED: %s %s", removedMsg.Type, removedMsg.Payload)
+		}
+	})
+
+	t.Run("напоминание получают только сессии владельца", func(t *testing.T) {
+		hub := NewHub()
+		hub.SetService(&MockStandUpdater{})
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		owner := newTestWsClient(t, server.URL+"/?user="+url.QueryEscape("Иванов"))
+		defer owner.Close()
+		other := newTestWsClient(t, server.URL+"/?user="+url.QueryEscape("Петров"))
+		defer other.Close()
+		owner.ReadJSON(new(dto.WsMessage))
+		other.ReadJSON(new(dto.WsMessage))
+
+		stand := models.Stand{ID: 41, Name: "alpha", Users: "Иванов", EndDate: 1_700_003_600}
+		hub.Remind(context.Background(), models.Reminder{Stand: stand, User: "Иванов", Offset: 3600, ExtendUntil: 1_700_090_000, ExtendToken: "token"})
+		hub.StandRemoved(42)
+
+		var reminderMsg dto.WsMessage
+		owner.ReadJSON(&reminderMsg)
+		var reminder dto.ReminderPayload
+		if reminderMsg.Type != "REMINDER" || json.Unmarshal(reminderMsg.Payload, &reminder) != nil ||
+			reminder.ID != 41 || reminder.ExtendUntil != 1_700_090_000 || reminder.ExtendToken != "token" {
+			t.Errorf("Получено неожиданное сообщение REMINDER: %s %s", reminderMsg.Type, reminderMsg.Payload)
+		}
+
+		var otherMsg dto.WsMessage
+		other.ReadJSON(&otherMsg)
+		if otherMsg.Type != "STAND_REMOVED" {
+			t.Errorf("Напоминание не должно приходить другим пользователям, получено %s", otherMsg.Type)
+		}
+	})
+
+	t.Run("медленный клиент отключается и не задерживает рассылку", func(t *testing.T) {
+		hub := NewHub()
+		hub.SetService(&MockStandUpdater{})
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		// Клиент без writePump никогда не разбирает свою очередь.
+		slowConn := newTestWsClient(t, server.URL)
+		defer slowConn.Close()
+		slow := newClient(context.Background(), slowConn, DefaultHeartbeat)
+		hub.register <- slow
+
+		for i := 0; i <= clientSendBuffer; i++ {
+			hub.StandRemoved(int64(i))
+		}
+
+		for i := 0; i <= clientSendBuffer; i++ {
+			var msg dto.WsMessage
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Быстрый клиент не получил сообщение %d: %v", i, err)
+			}
+		}
+		slow.mu.Lock()
+		closed := slow.closed
+		slow.mu.Unlock()
+		if !closed {
+			t.Error("Ожидалось отключение клиента с переполненной очередью")
+		}
+	})
+
+	t.Run("клиент без pong отключается", func(t *testing.T) {
+		hub := NewHub()
+		hub.SetService(&MockStandUpdater{})
+		hub.SetHeartbeat(Heartbeat{PingInterval: 20 * time.Millisecond, PongTimeout: 20 * time.Millisecond, WriteTimeout: time.Second})
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+
+		// Пока клиент не читает, он не отвечает на ping.
+		time.Sleep(200 * time.Millisecond)
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg dto.WsMessage
+			err := conn.ReadJSON(&msg)
+			if err == nil {
+				continue
+			}
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+				t.Fatal("Сервер не закрыл соединение с клиентом, не отвечающим на ping")
+			}
+			break
+		}
+	})
+
+	t.Run("клиент, отвечающий на ping, остается подключен", func(t *testing.T) {
+		hub := NewHub()
+		hub.SetService(&MockStandUpdater{})
+		// Запас PongTimeout нужен, чтобы pong успевал прийти под нагрузкой, но ожидание
+		// ниже все равно в несколько раз дольше дедлайна чтения без pong.
+		hub.SetHeartbeat(Heartbeat{PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond, WriteTimeout: time.Second})
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+
+		received := make(chan dto.WsMessage, 2)
+		go func() {
+			for {
+				var msg dto.WsMessage
+				if err := conn.ReadJSON(&msg); err != nil {
+					close(received)
+					return
+				}
+				received <- msg
+			}
+		}()
+		<-received // Пропускаем начальное сообщение
+
+		time.Sleep(500 * time.Millisecond)
+		hub.StandRemoved(1)
+
+		select {
+		case msg, ok := <-received:
+			if !ok || msg.Type != "STAND_REMOVED" {
+				t.Errorf("Ожидалось сообщение STAND_REMOVED, получено %+v", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Клиент не получил сообщение")
+		}
+	})
+
+	t.Run("полное состояние по запросу RESYNC", func(t *testing.T) {
+		service := &MockStandUpdater{}
+		hub := NewHub()
+		hub.SetService(service)
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		service.GetInitialStandsFunc = func(ctx context.Context) ([]models.Stand, error) {
+			return []models.Stand{{ID: 1, Name: "resynced"}}, nil
+		}
+		conn.WriteJSON(dto.WsMessage{Type: "RESYNC"})
+
+		var receivedMsg dto.WsMessage
+		conn.ReadJSON(&receivedMsg)
+		var stands []models.Stand
+		json.Unmarshal(receivedMsg.Payload, &stands)
+		if receivedMsg.Type != "UPDATE" || len(stands) != 1 || stands[0].Name != "resynced" {
+			t.Errorf("Ожидалось полное состояние стендов, получено %s %s", receivedMsg.Type, receivedMsg.Payload)
+		}
+	})
+
 	t.Run("успешная обработка PATCH сообщения", func(t *testing.T) {
 		service := &MockStandUpdater{}
 		hub := NewHub()
@@ -87,20 +419,20 @@ func TestHub_ClientCommunication(t *testing.T) {
 		var wg sync.WaitGroup
 		wg.Add(1)
 
-		service.UpdateStandFunc = func(ctx context.Context, id string, data []byte) error {
+		service.UpdateStandFunc = func(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
 			defer wg.Done()
 			if id != "stand1" {
 				t.Errorf("Ожидался id 'stand1', получено '%s'", id)
 			}
-			if string(data) != `{"status":"occupied"}` {
-				t.Errorf("Получены неожиданные данные: %s", string(data))
+			if len(patch) != 1 || string(patch["users"]) != `"Иванов"` {
+				t.Errorf("Получены неожиданные данные: %v", patch)
 			}
 			return nil
 		}
 
 		patchPayload, _ := json.Marshal(dto.PatchPayload{
 			ID:         "stand1",
-			UpdateData: json.RawMessage(`{"status":"occupied"}`),
+			UpdateData: models.StandPatch{"users": json.RawMessage(`"Иванов"`)},
 		})
 		patchMsg := dto.WsMessage{Type: "PATCH", Payload: patchPayload}
 
@@ -111,6 +443,186 @@ func TestHub_ClientCommunication(t *testing.T) {
 		wg.Wait()
 	})
 
+	t.Run("подтверждение и ошибка возвращают requestId", func(t *testing.T) {
+		service := &MockStandUpdater{}
+		hub := NewHub()
+		hub.SetService(service)
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		service.EnqueueFunc = func(ctx context.Context, id, user, reason string, duration time.Duration) (int, error) {
+			return 2, nil
+		}
+		service.BookFunc = func(ctx context.Context, id, user, reason string, until time.Time) error {
+			return standservice.ErrStandOccupied
+		}
+
+		enqueuePayload, _ := json.Marshal(dto.EnqueuePayload{ID: "41", User: "Иванов", Duration: 3600})
+		conn.WriteJSON(dto.WsMessage{Type: "ENQUEUE", RequestID: "req-1", Payload: enqueuePayload})
+		bookPayload, _ := json.Marshal(dto.BookPayload{ID: "41", User: "Иванов", Until: 1764704428})
+		conn.WriteJSON(dto.WsMessage{Type: "BOOK", RequestID: "req-2", Payload: bookPayload})
+
+		var ack, errRsp dto.WsMessage
+		conn.ReadJSON(&ack)
+		conn.ReadJSON(&errRsp)
+		var position dto.QueuePositionPayload
+		json.Unmarshal(ack.Payload, &position)
+		if ack.Type != "ACK" || ack.RequestID != "req-1" || position.Position != 2 {
+			t.Errorf("Ожидалось подтверждение req-1 с позицией 2, получено %s %s %s", ack.Type, ack.RequestID, ack.Payload)
+		}
+		if errRsp.Type != "ERROR" || errRsp.RequestID != "req-2" {
+			t.Errorf("Ожидалась ошибка req-2, получено %s %s", errRsp.Type, errRsp.RequestID)
+		}
+	})
+
+	t.Run("без requestId подтверждение не отправляется", func(t *testing.T) {
+		service := &MockStandUpdater{}
+		hub := NewHub()
+		hub.SetService(service)
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		releasePayload, _ := json.Marshal(dto.ReleasePayload{ID: "41", User: "Иванов"})
+		conn.WriteJSON(dto.WsMessage{Type: "RELEASE", Payload: releasePayload})
+		conn.WriteJSON(dto.WsMessage{Type: "UNKNOWN", RequestID: "req-3"})
+
+		var msg dto.WsMessage
+		conn.ReadJSON(&msg)
+		if msg.Type != "ERROR" || msg.RequestID != "req-3" {
+			t.Errorf("Ожидалась только ошибка req-3, получено %s %s", msg.Type, msg.RequestID)
+		}
+	})
+
+	t.Run("ошибка бронирования занятого стенда", func(t *testing.T) {
+		service := &MockStandUpdater{}
+		hub := NewHub()
+		hub.SetService(service)
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		service.BookFunc = func(ctx context.Context, id, user, reason string, until time.Time) error {
+			if id != "41" || user != "Иванов" || until.Unix() != 1764704428 {
+				t.Errorf("Неожиданные параметры бронирования: %s %s %v", id, user, until)
+			}
+			return standservice.ErrStandOccupied
+		}
+
+		bookPayload, _ := json.Marshal(dto.BookPayload{ID: "41", User: "Иванов", Until: 1764704428})
+		conn.WriteJSON(dto.WsMessage{Type: "BOOK", Payload: bookPayload})
+
+		var errRsp dto.WsMessage
+		conn.ReadJSON(&errRsp)
+		if errRsp.Type != "ERROR" {
+			t.Fatalf("Ожидался тип 'ERROR', получено '%s'", errRsp.Type)
+		}
+		var errPayload dto.ErrorPayload
+		json.Unmarshal(errRsp.Payload, &errPayload)
+		if errPayload.Message != "Стенд уже занят." || errPayload.Code != dto.ErrorCodeStandOccupied {
+			t.Errorf("Неожиданная ошибка: %+v", errPayload)
+		}
+	})
+
+	t.Run("конфликт версий при PATCH", func(t *testing.T) {
+		service := &MockStandUpdater{}
+		hub := NewHub()
+		hub.SetService(service)
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		service.UpdateStandFunc = func(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
+			if version == nil || *version != 2 {
+				t.Errorf("Ожидалась версия 2, получено %v", version)
+			}
+			return &models.ConflictError{Current: &models.Stand{ID: 41, Version: 3}}
+		}
+
+		version := int64(2)
+		patchPayload, _ := json.Marshal(dto.PatchPayload{
+			ID:         "41",
+			UpdateData: models.StandPatch{"users": json.RawMessage(`"Иванов"`)},
+			Version:    &version,
+		})
+		conn.WriteJSON(dto.WsMessage{Type: "PATCH", Payload: patchPayload})
+
+		var errRsp dto.WsMessage
+		conn.ReadJSON(&errRsp)
+		var errPayload dto.ErrorPayload
+		json.Unmarshal(errRsp.Payload, &errPayload)
+		if errRsp.Type != "ERROR" || errPayload.Code != "conflict" {
+			t.Fatalf("Ожидалась ошибка с кодом conflict, получено %s %+v", errRsp.Type, errPayload)
+		}
+		if errPayload.Stand == nil || errPayload.Stand.Version != 3 {
+			t.Errorf("Ожидалось актуальное состояние стенда, получено %+v", errPayload.Stand)
+		}
+	})
+
+	t.Run("создание, клонирование и архивирование стенда", func(t *testing.T) {
+		service := &MockStandUpdater{}
+		hub := NewHub()
+		hub.SetService(service)
+		go hub.Run()
+
+		server := httptest.NewServer(http.HandlerFunc(hub.ServeHTTP))
+		defer server.Close()
+
+		conn := newTestWsClient(t, server.URL)
+		defer conn.Close()
+		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
+
+		service.CloneStandFunc = func(ctx context.Context, id string, fields models.StandPatch) (*models.Stand, error) {
+			if id != "41" || string(fields["name"]) != `"alpha-2"` {
+				t.Errorf("Неожиданные параметры клонирования: %s %v", id, fields)
+			}
+			return &models.Stand{ID: 42, Name: "alpha-2"}, nil
+		}
+		service.ArchiveStandFunc = func(ctx context.Context, id string, version *int64) error {
+			return models.ErrStandArchived
+		}
+
+		clonePayload, _ := json.Marshal(dto.ClonePayload{ID: "41", Stand: models.StandPatch{"name": json.RawMessage(`"alpha-2"`)}})
+		conn.WriteJSON(dto.WsMessage{Type: "CLONE", RequestID: "req-1", Payload: clonePayload})
+		archivePayload, _ := json.Marshal(dto.ArchivePayload{ID: "41"})
+		conn.WriteJSON(dto.WsMessage{Type: "ARCHIVE", RequestID: "req-2", Payload: archivePayload})
+
+		var ack, errRsp dto.WsMessage
+		conn.ReadJSON(&ack)
+		conn.ReadJSON(&errRsp)
+		var stand models.Stand
+		json.Unmarshal(ack.Payload, &stand)
+		if ack.Type != "ACK" || ack.RequestID != "req-1" || stand.ID != 42 {
+			t.Errorf("Ожидалось подтверждение req-1 с новым стендом, получено %s %s %s", ack.Type, ack.RequestID, ack.Payload)
+		}
+		var errPayload dto.ErrorPayload
+		json.Unmarshal(errRsp.Payload, &errPayload)
+		if errRsp.Type != "ERROR" || errRsp.RequestID != "req-2" || errPayload.Code != dto.ErrorCodeStandArchived {
+			t.Errorf("Ожидалась ошибка stand_archived для req-2, получено %s %s %+v", errRsp.Type, errRsp.RequestID, errPayload)
+		}
+	})
+
 	t.Run("обработка неизвестного типа сообщения", func(t *testing.T) {
 		service := &MockStandUpdater{}
 		hub := NewHub()
@@ -131,8 +643,10 @@ func TestHub_ClientCommunication(t *testing.T) {
 
 		var errRsp dto.WsMessage
 		conn.ReadJSON(&errRsp)
-		if errRsp.Type != "ERROR" {
-			t.Errorf("Ожидался тип 'ERROR', получено '%s'", errRsp.Type)
+		var errPayload dto.ErrorPayload
+		json.Unmarshal(errRsp.Payload, &errPayload)
+		if errRsp.Type != "ERROR" || errPayload.Code != dto.ErrorCodeUnknownMessageType {
+			t.Errorf("Ожидалась ошибка с кодом unknown_message_type, получено %s %+v", errRsp.Type, errPayload)
 		}
 	})
 
@@ -149,7 +663,7 @@ func TestHub_ClientCommunication(t *testing.T) {
 		defer conn.Close()
 		conn.ReadJSON(new(dto.WsMessage)) // Пропускаем начальное сообщение
 
-		service.UpdateStandFunc = func(ctx context.Context, id string, data []byte) error {
+		service.UpdateStandFunc = func(ctx context.Context, id string, version *int64, patch models.StandPatch) error {
 			return errors.New("service error")
 		}
 
@@ -164,3 +678,32 @@ func TestHub_ClientCommunication(t *testing.T) {
 		}
 	})
 }
+
+func TestHub_CheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "без Origin", origin: "", want: true},
+		{name: "страница сервиса при пустом списке", origin: "http://booking.local", want: true},
+		{name: "чужой Origin при пустом списке", origin: "http://evil.example", want: false},
+		{name: "Origin из списка", allowed: []string{"http://ui.example"}, origin: "http://ui.example", want: true},
+		{name: "Origin не из списка", allowed: []string{"http://ui.example"}, origin: "http://evil.example", want: false},
+		{name: "любой Origin", allowed: []string{AnyOrigin}, origin: "http://evil.example", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			hub.SetAllowedOrigins(tt.allowed)
+			r := httptest.NewRequest(http.MethodGet, "http://booking.local/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := hub.checkOrigin(r); got != tt.want {
+				t.Errorf("Ожидалось %v, получено %v", tt.want, got)
+			}
+		})
+	}
+}
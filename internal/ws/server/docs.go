@@ -0,0 +1,26 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec - спецификация OpenAPI 3 REST API сервиса.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// asyncAPISpec - спецификация AsyncAPI 3 сообщений WebSocket /ws. Общие с REST схемы
+// payload она берет из openapi.json по относительной ссылке.
+//
+//go:embed asyncapi.json
+var asyncAPISpec []byte
+
+// serveSpec отдает встроенную в сервис спецификацию API.
+func serveSpec(spec []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(spec)
+	}
+}
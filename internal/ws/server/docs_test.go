@@ -0,0 +1,262 @@
+package server
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"mts/booking_service/internal/config"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/ws/dto"
+	"mts/booking_service/internal/ws/handlers"
+)
+
+// serverRoutes - операции, которые регистрирует сам Server, а не обработчики.
+var serverRoutes = []string{"GET /healthcheck", "GET /openapi.json", "GET /asyncapi.json"}
+
+// specSchemas сопоставляет схемы спецификаций с Go-типами, чьи JSON-поля они описывают.
+// nil - схема без фиксированного набора полей.
+var specSchemas = map[string]any{
+	"Stand":                nil,
+	"StandPatch":           nil,
+	"Deployment":           models.Deployment{},
+	"QueueEntry":           models.QueueEntry{},
+	"PatchPayload":         dto.PatchPayload{},
+	"CreatePayload":        dto.CreatePayload{},
+	"ClonePayload":         dto.ClonePayload{},
+	"ArchivePayload":       dto.ArchivePayload{},
+	"BookPayload":          dto.BookPayload{},
+	"ExtendPayload":        dto.ExtendPayload{},
+	"ReleasePayload":       dto.ReleasePayload{},
+	"EnqueuePayload":       dto.EnqueuePayload{},
+	"DequeuePayload":       dto.DequeuePayload{},
+	"QueuePositionPayload": dto.QueuePositionPayload{},
+	"ErrorPayload":         dto.ErrorPayload{},
+	"AuditEntry":           models.AuditEntry{},
+	"FieldChange":          models.FieldChange{},
+	"StandReport":          models.StandReport{},
+	"ExtendResultPayload":  dto.ExtendResultPayload{},
+	"DeployHookPayload":    dto.DeployHookPayload{},
+	"DeployHookResult":     dto.DeployHookResult{},
+	"StandRemovedPayload":  dto.StandRemovedPayload{},
+	"ReminderPayload":      dto.ReminderPayload{},
+}
+
+// serverMessageTypes - сообщения, которые сервер отправляет клиентам WebSocket.
+var serverMessageTypes = []string{
+	dto.MessageTypeUpdate,
+	dto.MessageTypeStandChanged,
+	dto.MessageTypeStandRemoved,
+	dto.MessageTypeReminder,
+	dto.MessageTypeAck,
+	dto.MessageTypeError,
+}
+
+// specDoc - разобранный документ спецификации.
+type specDoc map[string]any
+
+func parseSpec(t *testing.T, data []byte) specDoc {
+	t.Helper()
+	var doc specDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Спецификация не является корректным JSON: %v", err)
+	}
+	return doc
+}
+
+// lookup возвращает узел документа по JSON Pointer вида /components/schemas/Stand.
+func (d specDoc) lookup(pointer string) map[string]any {
+	var node any = map[string]any(d)
+	for _, key := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = object[key]
+	}
+	object, _ := node.(map[string]any)
+	return object
+}
+
+// resolve разрешает $ref схемы: локальную или на openapi.json. Для схемы без $ref
+// возвращается она сама.
+func resolve(schema map[string]any, local, openAPI specDoc) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	file, pointer, _ := strings.Cut(ref, "#")
+	doc := local
+	if file == "openapi.json" {
+		doc = openAPI
+	}
+	return doc.lookup(pointer)
+}
+
+// jsonFields возвращает имена JSON-полей структуры v.
+func jsonFields(v any) []string {
+	var fields []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = typ.Field(i).Name
+		}
+		fields = append(fields, name)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// checkSchemas сверяет свойства схем components.schemas с JSON-полями Go-типов.
+func checkSchemas(t *testing.T, doc specDoc) {
+	t.Helper()
+	schemas := doc.lookup("/components/schemas")
+	for name, node := range schemas {
+		goType, known := specSchemas[name]
+		if !known {
+			t.Errorf("Схема %s не сопоставлена с Go-типом в specSchemas", name)
+			continue
+		}
+		properties, _ := node.(map[string]any)["properties"].(map[string]any)
+		documented := slices.Sorted(maps.Keys(properties))
+
+		var expected []string
+		switch name {
+		case "Stand":
+			// Stand сериализуется вручную: базовые поля, развертывания и очередь.
+			expected = append(models.BaseFields(), "deployments", "queue")
+			slices.Sort(expected)
+		case "StandPatch":
+			continue
+		default:
+			expected = jsonFields(goType)
+		}
+		if !slices.Equal(documented, expected) {
+			t.Errorf("Схема %s описывает поля %v, а Go-тип сериализует %v", name, documented, expected)
+		}
+	}
+}
+
+func TestOpenAPI_MatchesHandlers(t *testing.T) {
+	doc := parseSpec(t, openAPISpec)
+
+	var documented []string
+	for path, node := range doc.lookup("/paths") {
+		for method := range node.(map[string]any) {
+			if method == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	slices.Sort(documented)
+
+	routes := slices.Clone(serverRoutes)
+	for _, handler := range []interface{ Routes() []string }{
+		handlers.NewStandsHandler(nil),
+		handlers.NewAuditHandler(nil),
+		handlers.NewReportsHandler(nil),
+		handlers.NewRemindersHandler(nil),
+		handlers.NewDeployHookHandler(nil, config.HooksConfig{}),
+	} {
+		routes = append(routes, handler.Routes()...)
+	}
+	slices.Sort(routes)
+
+	for _, route := range routes {
+		if !slices.Contains(documented, route) {
+			t.Errorf("Операция %s не описана в openapi.json", route)
+		}
+	}
+	for _, route := range documented {
+		if !slices.Contains(routes, route) {
+			t.Errorf("Операция %s из openapi.json не обрабатывается сервером", route)
+		}
+	}
+
+	checkSchemas(t, doc)
+}
+
+func TestAsyncAPI_MatchesHub(t *testing.T) {
+	openAPI := parseSpec(t, openAPISpec)
+	doc := parseSpec(t, asyncAPISpec)
+
+	operationMessages := func(operation string) []string {
+		var names []string
+		refs, _ := doc.lookup("/operations/" + operation)["messages"].([]any)
+		for _, ref := range refs {
+			pointer := strings.TrimPrefix(ref.(map[string]any)["$ref"].(string), "#")
+			if doc.lookup(pointer) == nil {
+				t.Errorf("Операция %s ссылается на отсутствующее сообщение %s", operation, pointer)
+			}
+			names = append(names, pointer[strings.LastIndex(pointer, "/")+1:])
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	if received, handled := operationMessages("receiveClientMessages"), handlers.NewHub().MessageTypes(); !slices.Equal(received, handled) {
+		t.Errorf("asyncapi.json описывает сообщения клиента %v, а хаб обрабатывает %v", received, handled)
+	}
+	sent := slices.Sorted(slices.Values(serverMessageTypes))
+	if documented := operationMessages("sendServerMessages"); !slices.Equal(documented, sent) {
+		t.Errorf("asyncapi.json описывает сообщения сервера %v, а сервер отправляет %v", documented, sent)
+	}
+
+	// Каждое сообщение - конверт WsMessage, а схемы payload существуют и описывают Go-типы.
+	envelope := jsonFields(dto.WsMessage{})
+	for name, node := range doc.lookup("/components/messages") {
+		payload, _ := node.(map[string]any)["payload"].(map[string]any)
+		properties, _ := payload["properties"].(map[string]any)
+		for property := range properties {
+			if !slices.Contains(envelope, property) {
+				t.Errorf("Сообщение %s: поля %s нет в конверте WsMessage", name, property)
+			}
+		}
+		body, ok := properties["payload"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, schema := range append([]any{body, body["items"]}, anySlice(body["oneOf"])...) {
+			schema, ok := schema.(map[string]any)
+			if !ok {
+				continue
+			}
+			if resolve(schema, doc, openAPI) == nil {
+				t.Errorf("Сообщение %s: схема %s не найдена", name, schema["$ref"])
+			}
+		}
+	}
+
+	checkSchemas(t, doc)
+}
+
+func TestServer_ServesSpecs(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	for path, version := range map[string]string{"/openapi.json": "openapi", "/asyncapi.json": "asyncapi"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Ошибка запроса %s: %v", path, err)
+		}
+		var doc map[string]any
+		err = json.NewDecoder(resp.Body).Decode(&doc)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || err != nil || doc[version] == nil {
+			t.Errorf("%s: ожидался документ %s, получено %d %v", path, version, resp.StatusCode, err)
+		}
+	}
+}
+
+func anySlice(v any) []any {
+	values, _ := v.([]any)
+	return values
+}
@@ -13,6 +13,7 @@ import (
 // Server представляет HTTP-сервер.
 type Server struct {
 	httpServer *http.Server
+	mux        *http.ServeMux
 }
 
 // New создает новый экземпляр Server.
@@ -20,25 +21,35 @@ func New(addr string, wsHandler, standsHandler http.Handler) *Server {
 	mux := http.NewServeMux()
 	mux.Handle("/ws", wsHandler)
 	mux.Handle("/stands", standsHandler)
+	mux.Handle("/stands/", standsHandler)
 	mux.HandleFunc("/healthcheck", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"success": true}`))
 	})
+	// Спецификации не требуют токена: по ним клиенты настраивают работу с API.
+	mux.HandleFunc("GET /openapi.json", serveSpec(openAPISpec))
+	mux.HandleFunc("GET /asyncapi.json", serveSpec(asyncAPISpec))
 
 	return &Server{
 		httpServer: &http.Server{
 			Addr:    addr,
 			Handler: corsMiddleware(mux),
 		},
+		mux: mux,
 	}
 }
 
+// Handle регистрирует дополнительный обработчик для pattern.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"mts/booking_service/internal/models"
+	"mts/booking_service/internal/repository/memory"
+	"mts/booking_service/internal/services/standservice"
+	"mts/booking_service/internal/ws/dto"
+	"mts/booking_service/internal/ws/handlers"
+)
+
+// newTestServer собирает сервис целиком поверх хранилища в памяти.
+func newTestServer(t *testing.T, stands []models.Stand) *httptest.Server {
+	repo := memory.NewStandsRepository(stands)
+	hub := handlers.NewHub()
+	standSvc := standservice.NewStandService(repo, hub)
+	hub.SetService(standSvc)
+	go hub.Run()
+
+	srv := New(":0", hub, handlers.NewStandsHandler(standSvc))
+	server := httptest.NewServer(srv.httpServer.Handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// readMessage читает сообщение ожидаемого типа и разбирает его payload в v.
+func readMessage(t *testing.T, conn *websocket.Conn, messageType string, v any) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var msg dto.WsMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Не удалось прочитать сообщение: %v", err)
+	}
+	if msg.Type != messageType {
+		t.Fatalf("Ожидался тип '%s', получено '%s': %s", messageType, msg.Type, msg.Payload)
+	}
+	if err := json.Unmarshal(msg.Payload, v); err != nil {
+		t.Fatalf("Не удалось разобрать payload: %v", err)
+	}
+}
+
+func readStands(t *testing.T, conn *websocket.Conn) []models.Stand {
+	t.Helper()
+	var stands []models.Stand
+	readMessage(t, conn, "UPDATE", &stands)
+	return stands
+}
+
+func readStandChanged(t *testing.T, conn *websocket.Conn) models.Stand {
+	t.Helper()
+	var stand models.Stand
+	readMessage(t, conn, "STAND_CHANGED", &stand)
+	return stand
+}
+
+func TestServer_BookingFlow(t *testing.T) {
+	server := newTestServer(t, []models.Stand{{ID: 1, Name: "alpha"}})
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Не удалось подключиться к WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	if initial := readStands(t, conn); len(initial) != 1 || initial[0].IsBooked(time.Now()) {
+		t.Fatalf("Ожидался один свободный стенд, получено %+v", initial)
+	}
+
+	until := time.Now().Add(time.Hour).Unix()
+	body := fmt.Sprintf(`{"id":"1","user":"Иванов","reason":"релиз","until":%d}`, until)
+	resp, err := http.Post(server.URL+"/stands/book", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Ошибка запроса бронирования: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Ожидался статус 204, получено %d", resp.StatusCode)
+	}
+
+	updated := readStandChanged(t, conn)
+	if updated.ID != 1 || updated.Users != "Иванов" || updated.EndDate != until || updated.Version != 1 {
+		t.Errorf("Клиент получил неожиданное состояние: %+v", updated)
+	}
+
+	resp, err = http.Post(server.URL+"/stands/book", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Ошибка запроса бронирования: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Повторное бронирование должно вернуть 409, получено %d", resp.StatusCode)
+	}
+
+	if err := conn.WriteJSON(dto.WsMessage{Type: "RESYNC"}); err != nil {
+		t.Fatalf("Не удалось отправить RESYNC: %v", err)
+	}
+	if resynced := readStands(t, conn); len(resynced) != 1 || resynced[0].Users != "Иванов" {
+		t.Errorf("После RESYNC получено неожиданное состояние: %+v", resynced)
+	}
+}